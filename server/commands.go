@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"server/internal/appcontext"
+	"server/internal/cli"
+	"server/internal/config"
+	"time"
+)
+
+// clientFlags are the flags of the CLI commands. With -url they call a running server over HTTP,
+// authenticated with the PACKAGING_API_KEY or PACKAGING_TOKEN env vars (or their _FILE variants),
+// otherwise they call the services directly against the storage of the config.
+type clientFlags struct {
+	flags   *flag.FlagSet
+	loader  *config.Loader
+	url     string
+	timeout time.Duration
+	json    bool
+}
+
+func newClientFlags(command string, usage string) *clientFlags {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	clientFlags := &clientFlags{flags: flags, loader: config.NewLoader(flags)}
+	flags.StringVar(&clientFlags.url, "url", os.Getenv("PACKAGING_URL"),
+		"URL of a running server, e.g. http://localhost:8080, instead of the configured storage (PACKAGING_URL)")
+	flags.DurationVar(&clientFlags.timeout, "timeout", 30*time.Second, "timeout of the command")
+	flags.BoolVar(&clientFlags.json, "json", false, "print JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: server %s [flags]\n", usage)
+		flags.PrintDefaults()
+	}
+
+	return clientFlags
+}
+
+// parse parses the flags wherever they are in args, and returns the other args,
+// exiting when their number is not between min and max.
+func (clientFlags *clientFlags) parse(args []string, min int, max int) []string {
+	positional, err := cli.ParseArgs(clientFlags.flags, args)
+	if err != nil {
+		os.Exit(2)
+	}
+	if len(positional) < min || len(positional) > max {
+		clientFlags.flags.Usage()
+		os.Exit(2)
+	}
+
+	return positional
+}
+
+// run calls action with the client and a context that times out, exiting on an error.
+func (clientFlags *clientFlags) run(action func(ctx context.Context, client cli.Client) error) {
+	if err := clientFlags.runWithClient(action); err != nil {
+		fail("Error: %v", err)
+	}
+}
+
+func (clientFlags *clientFlags) runWithClient(action func(ctx context.Context, client cli.Client) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), clientFlags.timeout)
+	defer cancel()
+
+	if clientFlags.url != "" {
+		apiKey, err := cli.ReadCredential("PACKAGING_API_KEY")
+		if err != nil {
+			return err
+		}
+		token, err := cli.ReadCredential("PACKAGING_TOKEN")
+		if err != nil {
+			return err
+		}
+
+		return action(ctx, cli.NewHttpClient(clientFlags.url, apiKey, token, &http.Client{}))
+	}
+
+	loaded, err := clientFlags.loader.Load()
+	if err != nil {
+		return err
+	}
+
+	appContext := appcontext.BuildPackagingContext(loaded)
+	defer appContext.Close()
+
+	return action(
+		ctx, cli.NewServiceClient(appContext.PackingService, appContext.PacksService, appContext.HistoryService),
+	)
+}
+
+// printJSON prints value as indented JSON.
+func printJSON(value any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// fail prints the error of a command and exits.
+func fail(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+)
+
+// runConfigCommand runs the config subcommands, i.e. "config print" printing the effective config
+// with the secrets redacted.
+func runConfigCommand(args []string) {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "Usage: server config print [flags]")
+		os.Exit(2)
+	}
+
+	loaded := loadConfig("config print", args[1:])
+
+	fmt.Println("# the effective config, with the secrets redacted")
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(loaded.Redacted()); err != nil {
+		fail("Error printing the config: %v", err)
+	}
+}
@@ -1,4 +1,118 @@
 CREATE TABLE packs
 (
     size BIGINT PRIMARY KEY
-);
\ No newline at end of file
+);
+
+CREATE TABLE packaging_results
+(
+    id              BIGSERIAL PRIMARY KEY,
+    number_of_items BIGINT       NOT NULL,
+    packs           JSONB        NOT NULL,
+    pack_sizes      JSONB        NOT NULL,
+    objective       VARCHAR(64)  NOT NULL,
+    packs_version   VARCHAR(64)  NOT NULL,
+    caller          VARCHAR(255) NOT NULL,
+    created_at      TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX packaging_results_created_at_idx ON packaging_results (created_at);
+
+CREATE TABLE idempotency_records
+(
+    scope        VARCHAR(255) NOT NULL,
+    key          VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64)  NOT NULL,
+    completed    BOOLEAN      NOT NULL,
+    status_code  INTEGER      NOT NULL,
+    headers      JSONB        NOT NULL,
+    body         BYTEA,
+    created_at   TIMESTAMPTZ  NOT NULL,
+    expires_at   TIMESTAMPTZ  NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idempotency_records_expires_at_idx ON idempotency_records (expires_at);
+
+CREATE TABLE rate_limit_buckets
+(
+    key        VARCHAR(512)     PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ      NOT NULL,
+    quota_day  VARCHAR(10)      NOT NULL,
+    quota_used INTEGER          NOT NULL
+);
+
+CREATE TABLE packaging_jobs
+(
+    id              VARCHAR(36)  PRIMARY KEY,
+    status          VARCHAR(16)  NOT NULL,
+    total_lines     INTEGER      NOT NULL,
+    processed_lines INTEGER      NOT NULL,
+    failed_lines    INTEGER      NOT NULL,
+    error           TEXT         NOT NULL,
+    pack_sizes      JSONB,
+    packs_version   VARCHAR(64)  NOT NULL,
+    caller          VARCHAR(255) NOT NULL,
+    created_at      TIMESTAMPTZ  NOT NULL,
+    started_at      TIMESTAMPTZ,
+    completed_at    TIMESTAMPTZ,
+    heartbeat_at    TIMESTAMPTZ
+);
+
+CREATE INDEX packaging_jobs_status_created_at_idx ON packaging_jobs (status, created_at);
+
+CREATE TABLE packaging_job_lines
+(
+    job_id          VARCHAR(36)  NOT NULL REFERENCES packaging_jobs (id) ON DELETE CASCADE,
+    line_number     INTEGER      NOT NULL,
+    order_id        VARCHAR(255) NOT NULL,
+    number_of_items BIGINT       NOT NULL,
+    processed       BOOLEAN      NOT NULL,
+    packs           JSONB,
+    packs_version   VARCHAR(64)  NOT NULL,
+    error           TEXT         NOT NULL,
+    PRIMARY KEY (job_id, line_number)
+);
+
+CREATE TABLE orders
+(
+    id                 VARCHAR(36)  PRIMARY KEY,
+    customer_reference VARCHAR(255) NOT NULL,
+    number_of_items    BIGINT       NOT NULL,
+    packs              JSONB        NOT NULL,
+    packs_version      VARCHAR(64)  NOT NULL,
+    status             VARCHAR(16)  NOT NULL,
+    caller             VARCHAR(255) NOT NULL,
+    created_at         TIMESTAMPTZ  NOT NULL,
+    updated_at         TIMESTAMPTZ  NOT NULL,
+    quoted_at          TIMESTAMPTZ,
+    confirmed_at       TIMESTAMPTZ,
+    packed_at          TIMESTAMPTZ,
+    shipped_at         TIMESTAMPTZ,
+    cancelled_at       TIMESTAMPTZ
+);
+
+CREATE INDEX orders_customer_reference_idx ON orders (customer_reference);
+
+CREATE TABLE quotes
+(
+    id              VARCHAR(36)  PRIMARY KEY,
+    number_of_items BIGINT       NOT NULL,
+    packs           JSONB        NOT NULL,
+    pack_sizes      JSONB        NOT NULL,
+    packs_version   VARCHAR(64)  NOT NULL,
+    objective       VARCHAR(64)  NOT NULL,
+    caller          VARCHAR(255) NOT NULL,
+    created_at      TIMESTAMPTZ  NOT NULL,
+    expires_at      TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX quotes_expires_at_idx ON quotes (expires_at);
+
+-- the version of this schema, checked by the readiness probe
+CREATE TABLE schema_version
+(
+    version INTEGER PRIMARY KEY
+);
+
+INSERT INTO schema_version (version) VALUES (5);
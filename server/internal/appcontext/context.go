@@ -1,58 +1,460 @@
 package appcontext
 
 import (
-	"fmt"
+	"context"
+	"crypto/tls"
+	"gopkg.in/yaml.v3"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"log"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/plugin/opentelemetry/tracing"
+	"log/slog"
 	"os"
+	"server/internal/auth"
+	"server/internal/config"
+	"server/internal/logging"
+	"server/internal/model"
 	"server/internal/repository"
 	"server/internal/service"
+	"server/internal/tlsconfig"
+	"time"
 )
 
+// slowQueryThreshold is how long a DB query can take before it is logged as slow
+const slowQueryThreshold = 200 * time.Millisecond
+
+// quotesPurgeInterval is how often the quotes long expired are deleted
+const quotesPurgeInterval = time.Hour
+
 type AppContext struct {
+	// Config is the effective configuration the app is built from
+	Config *config.Config
+
 	DB             *gorm.DB
 	PacksService   service.PacksService
 	PackingService service.PackagingService
+	HistoryService service.PackagingHistoryService
+
+	IdempotencyService service.IdempotencyService
+	HealthService      service.HealthService
+	// PackagingJobsService processes the bulk packaging jobs, nil without a DB
+	PackagingJobsService service.PackagingJobsService
+	// OrdersService quotes the orders and changes their status, nil without a DB
+	OrdersService service.OrdersService
+	// QuotesService keeps the quotes and verifies their tokens, nil without a DB
+	QuotesService service.QuotesService
+	// MaxJobUploadBytes is the max size of the CSV of a packaging job
+	MaxJobUploadBytes int64
+
+	// Authenticator authenticates the API requests, nil when no credentials are configured
+	Authenticator auth.Authenticator
+	// AuthDisabled lets all the requests through without an Authenticator. Otherwise, the admin role
+	// can not be checked, so the routes needing it are refused.
+	AuthDisabled bool
+	// RateLimitService limits the API requests of the clients, nil when rate limiting is disabled
+	RateLimitService service.RateLimitService
+
+	// RequestTimeout is the deadline of the API requests that do not set one, zero means no deadline
+	RequestTimeout time.Duration
+	// MaxRequestTimeout caps the deadline of the API requests, zero means no cap
+	MaxRequestTimeout time.Duration
+
+	// CorsConfig is the CORS policy of the API
+	CorsConfig model.CorsConfig
+
+	// HttpServerConfig configures the HTTP server
+	HttpServerConfig model.HttpServerConfig
+	// TlsConfig is the TLS config of the HTTP and gRPC servers, nil when TLS is disabled
+	TlsConfig *tls.Config
+	// GrpcAddress is the address the gRPC server listens on, empty when the gRPC server is disabled
+	GrpcAddress string
+	// AdminEnabled is whether the admin diagnostics routes are served
+	AdminEnabled bool
+	// AdminAddress is the address of the admin server, empty when the admin routes are served by the API server
+	AdminAddress string
+}
+
+func BuildAppContext(config *config.Config) *AppContext {
+	// when a packs file is configured, the app runs without a database
+	if !config.UsesDatabase() {
+		repo := createFilePacksRepository(config.Packs)
+		packsService := service.NewPacksService(repo)
+		return &AppContext{
+			Config:             config,
+			PacksService:       packsService,
+			PackingService:     createPackagingService(config.Packaging, packsService),
+			IdempotencyService: createIdempotencyService(config.Idempotency, repository.NewInMemoryIdempotencyRepository()),
+			HealthService:      service.NewHealthService(nil, packsService),
+			MaxJobUploadBytes:  config.Jobs.MaxUploadBytes,
+			Authenticator:      createAuthenticator(config.Auth),
+			AuthDisabled:       config.Auth.Disabled,
+			RateLimitService:   createRateLimitService(config.RateLimit, nil),
+			RequestTimeout:     config.Requests.Timeout,
+			MaxRequestTimeout:  config.Requests.MaxTimeout,
+			CorsConfig:         config.Cors,
+			HttpServerConfig:   config.Http.ServerConfig(),
+			TlsConfig:          createTlsConfig(config.Tls),
+			GrpcAddress:        config.Grpc.Address(),
+			AdminEnabled:       config.Admin.Enabled,
+			AdminAddress:       config.Admin.ServerAddress(),
+		}
+	}
+
+	db := createDbConnection(config.Database)
+	repo := repository.NewCircuitBreakerPacksRepository(
+		repository.NewPacksRepository(db), config.Database.BreakerFailureThreshold, config.Database.BreakerOpenTimeout,
+		config.Database.BreakerCallTimeout,
+	)
+	packsService := createPacksService(config.Packs, repo)
+	packingService := createPackagingService(config.Packaging, packsService)
+	historyService := service.NewPackagingHistoryService(repository.NewPackagingResultsRepository(db))
+	packagingJobsService := createPackagingJobsService(
+		config.Jobs, repository.NewPackagingJobsRepository(db), packsService, packingService, historyService,
+	)
+	ordersService := service.NewOrdersService(repository.NewOrdersRepository(db), packingService, historyService)
+	quotesService := createQuotesService(
+		config.Quotes, repository.NewQuotesRepository(db), packingService, historyService,
+	)
+	return &AppContext{
+		Config:               config,
+		DB:                   db,
+		PacksService:         packsService,
+		PackingService:       packingService,
+		HistoryService:       historyService,
+		IdempotencyService:   createIdempotencyService(config.Idempotency, repository.NewIdempotencyRepository(db)),
+		HealthService:        service.NewHealthService(repository.NewHealthRepository(db), packsService),
+		PackagingJobsService: packagingJobsService,
+		OrdersService:        ordersService,
+		QuotesService:        quotesService,
+		MaxJobUploadBytes:    config.Jobs.MaxUploadBytes,
+		Authenticator:        createAuthenticator(config.Auth),
+		AuthDisabled:         config.Auth.Disabled,
+		RateLimitService:     createRateLimitService(config.RateLimit, db),
+		RequestTimeout:       config.Requests.Timeout,
+		MaxRequestTimeout:    config.Requests.MaxTimeout,
+		CorsConfig:           config.Cors,
+		HttpServerConfig:     config.Http.ServerConfig(),
+		TlsConfig:            createTlsConfig(config.Tls),
+		GrpcAddress:          config.Grpc.Address(),
+		AdminEnabled:         config.Admin.Enabled,
+		AdminAddress:         config.Admin.ServerAddress(),
+	}
 }
 
-func BuildAppContext() *AppContext {
-	db := createDbConnection()
-	repo := repository.NewPacksRepository(db)
+// BuildPackagingContext builds an app context with only the packs, the packaging and, with a DB,
+// the history services, e.g. for the CLI, without the servers and the background jobs of BuildAppContext.
+func BuildPackagingContext(config *config.Config) *AppContext {
+	var db *gorm.DB
+	var repo repository.PacksRepository
+	var historyService service.PackagingHistoryService
+	if config.UsesDatabase() {
+		db = createDbConnection(config.Database)
+		repo = repository.NewPacksRepository(db)
+		historyService = service.NewPackagingHistoryService(repository.NewPackagingResultsRepository(db))
+	} else {
+		repo = createFilePacksRepository(config.Packs)
+	}
+
 	packsService := service.NewPacksService(repo)
-	packingService := service.NewPackagingService(packsService)
 	return &AppContext{
+		Config:         config,
 		DB:             db,
 		PacksService:   packsService,
-		PackingService: packingService,
+		PackingService: createPackagingService(config.Packaging, packsService),
+		HistoryService: historyService,
 	}
 }
 
-func createDbConnection() *gorm.DB {
-	dbHost := readOsEnv("DB_HOST")
-	dbPort := readOsEnv("DB_PORT")
-	dbUsername := readOsEnv("DB_USERNAME")
-	dbPassword := readOsEnv("DB_PASSWORD")
-	dbName := readOsEnv("DB_NAME")
+// Close releases the resources of the app, i.e. closes the DB connection pool.
+func (appContext *AppContext) Close() error {
+	if appContext.DB == nil {
+		return nil
+	}
 
-	dsn := fmt.Sprintf(
-		"host=%v user=%v password=%v dbname=%v port=%v sslmode=disable",
-		dbHost, dbUsername, dbPassword, dbName, dbPort,
-	)
+	sqlDB, err := appContext.DB.DB()
+	if err != nil {
+		return err
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	return sqlDB.Close()
+}
+
+func createDbConnection(config config.DatabaseConfig) *gorm.DB {
+	db, err := openDbWithRetry(config)
+	if err != nil {
+		logging.Fatal("Error opening DB connection", "error", err)
+	}
+
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Error opening DB connection: %v", err)
+		logging.Fatal("Error getting DB connection pool", "error", err)
+	}
+
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+
+	// the queries are traced without their values, which can be personal data
+	if err := db.Use(tracing.NewPlugin(tracing.WithoutMetrics(), tracing.WithoutQueryVariables())); err != nil {
+		logging.Fatal("Error setting up DB tracing", "error", err)
 	}
 
 	return db
 }
 
-func readOsEnv(key string) string {
-	res := os.Getenv(key)
-	if res == "" {
-		log.Fatalf("Required env var %s is NOT present", key)
+// openDbWithRetry keeps trying to connect to the DB with an exponential backoff,
+// so the app does not crash when it starts before the DB is ready to accept connections.
+func openDbWithRetry(config config.DatabaseConfig) (*gorm.DB, error) {
+	backoff := config.ConnectBackoff
+	// the failed and the slow queries are logged, without their values
+	dbLogger := gormlogger.NewSlogLogger(
+		slog.Default(), gormlogger.Config{
+			SlowThreshold:             slowQueryThreshold,
+			LogLevel:                  gormlogger.Warn,
+			IgnoreRecordNotFoundError: true,
+			ParameterizedQueries:      true,
+		},
+	)
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var db *gorm.DB
+		// gorm pings the DB on open, so a successful open means the DB is reachable
+		db, err = gorm.Open(postgres.Open(config.DSN()), &gorm.Config{Logger: dbLogger})
+		if err == nil {
+			return db, nil
+		}
+
+		if attempt >= config.ConnectAttempts {
+			return nil, err
+		}
+
+		slog.Warn(
+			"Failed to connect to DB, retrying", "attempt", attempt, "max_attempts", config.ConnectAttempts,
+			"backoff", backoff.String(), "error", err,
+		)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, config.ConnectMaxBackoff)
+	}
+}
+
+func createPacksService(config config.PacksConfig, repo repository.PacksRepository) service.PacksService {
+	if config.SnapshotFile == "" {
+		return service.NewPacksService(repo)
+	}
+
+	return service.NewPacksServiceWithSnapshot(repo, repository.NewFilePacksSnapshotStore(config.SnapshotFile))
+}
+
+func createPackagingService(config config.PackagingConfig, packsService service.PacksService) service.PackagingService {
+	return service.NewPackagingServiceWithItemsBuffer(packsService, config.LargePackItemsBuffer)
+}
+
+// createIdempotencyService keeps the responses of the idempotent requests for the key retention,
+// and purges the expired ones in the background.
+func createIdempotencyService(
+	config config.IdempotencyConfig, repo repository.IdempotencyRepository,
+) service.IdempotencyService {
+	idempotencyService := service.NewIdempotencyService(repo, config.KeyRetention)
+
+	go func() {
+		for range time.Tick(config.KeyPurgeInterval) {
+			purged, err := idempotencyService.PurgeExpired(context.Background())
+			if err != nil {
+				slog.Error("Error purging expired idempotency keys", "error", err)
+			} else if purged > 0 {
+				slog.Info("Purged expired idempotency keys", "count", purged)
+			}
+		}
+	}()
+
+	return idempotencyService
+}
+
+// createPackagingJobsService processes the packaging jobs with the configured workers, once its Run is called,
+// and purges the finished jobs older than the retention in the background.
+func createPackagingJobsService(
+	config config.JobsConfig, repo repository.PackagingJobsRepository, packsService service.PacksService,
+	packagingService service.PackagingService, historyService service.PackagingHistoryService,
+) service.PackagingJobsService {
+	packagingJobsService := service.NewPackagingJobsService(
+		repo, packsService, packagingService, historyService, config.PackagingJobsConfig,
+	)
+
+	go func() {
+		for range time.Tick(config.PurgeInterval) {
+			purged, err := packagingJobsService.PurgeFinished(context.Background())
+			if err != nil {
+				slog.Error("Error purging finished packaging jobs", "error", err)
+			} else if purged > 0 {
+				slog.Info("Purged finished packaging jobs", "count", purged)
+			}
+		}
+	}()
+
+	return packagingJobsService
+}
+
+// createQuotesService purges the quotes long expired in the background.
+func createQuotesService(
+	config config.QuotesConfig, repo repository.QuotesRepository, packagingService service.PackagingService,
+	historyService service.PackagingHistoryService,
+) service.QuotesService {
+	quotesService := service.NewQuotesService(
+		repo, packagingService, historyService, config.TTL, []byte(config.SigningKey),
+	)
+
+	go func() {
+		for range time.Tick(quotesPurgeInterval) {
+			purged, err := quotesService.PurgeExpired(context.Background())
+			if err != nil {
+				slog.Error("Error purging expired quotes", "error", err)
+			} else if purged > 0 {
+				slog.Info("Purged expired quotes", "count", purged)
+			}
+		}
+	}()
+
+	return quotesService
+}
+
+// createAuthenticator authenticates the static API keys from the API keys file, the JWT bearer tokens
+// signed with the keys from the JWKS and the TLS client certificates from the client certificates file.
+// When none is configured, authentication is disabled.
+func createAuthenticator(config config.AuthConfig) auth.Authenticator {
+	var authenticators []auth.Authenticator
+
+	if apiKeysFile := config.APIKeysFile; apiKeysFile != "" {
+		keys, err := auth.LoadAPIKeys(apiKeysFile)
+		if err != nil {
+			logging.Fatal("Error loading API keys file", "path", apiKeysFile, "error", err)
+		}
+		authenticator, err := auth.NewAPIKeyAuthenticator(keys)
+		if err != nil {
+			logging.Fatal("Error loading API keys file", "path", apiKeysFile, "error", err)
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+
+	if jwksSource := config.JWKS; jwksSource != "" {
+		jwks, err := auth.NewJWKS(context.Background(), jwksSource, config.JWKSRefreshInterval)
+		if err != nil {
+			logging.Fatal("Error loading JWKS", "source", jwksSource, "error", err)
+		}
+		authenticators = append(
+			authenticators, auth.NewJWTAuthenticator(
+				jwks, auth.JWTConfig{
+					Issuer:     config.JWTIssuer,
+					Audience:   config.JWTAudience,
+					RolesClaim: config.JWTRolesClaim,
+				},
+			),
+		)
+	}
+
+	if clientCertificatesFile := config.ClientCertificatesFile; clientCertificatesFile != "" {
+		certificates, err := auth.LoadClientCertificates(clientCertificatesFile)
+		if err != nil {
+			logging.Fatal("Error loading client certificates file", "path", clientCertificatesFile, "error", err)
+		}
+		authenticator, err := auth.NewClientCertificateAuthenticator(certificates)
+		if err != nil {
+			logging.Fatal("Error loading client certificates file", "path", clientCertificatesFile, "error", err)
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+
+	if len(authenticators) == 0 {
+		if config.Disabled {
+			slog.Warn("Authentication is disabled, all the requests are let through")
+		} else {
+			slog.Warn("No API keys, JWKS or client certificates configured, the routes needing the admin role are refused")
+		}
+		return nil
+	}
+
+	return auth.NewChainAuthenticator(authenticators...)
+}
+
+// createRateLimitService limits the requests with the limits from the limits file, keeping the buckets in memory,
+// or in the DB when the store is postgres. Without the file, rate limiting is disabled.
+func createRateLimitService(config config.RateLimitConfig, db *gorm.DB) service.RateLimitService {
+	rateLimitsFile := config.LimitsFile
+	if rateLimitsFile == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(rateLimitsFile)
+	if err != nil {
+		logging.Fatal("Error reading rate limits file", "path", rateLimitsFile, "error", err)
+	}
+	var limits model.RateLimitsFile
+	if err := yaml.Unmarshal(content, &limits); err != nil {
+		logging.Fatal("Error parsing rate limits file", "path", rateLimitsFile, "error", err)
+	}
+	if err := service.ValidateRateLimits(limits); err != nil {
+		logging.Fatal("Error in rate limits file", "path", rateLimitsFile, "error", err)
+	}
+
+	// the store is validated with the config, postgres being only allowed with a DB
+	var repo repository.RateLimitRepository
+	if config.Store == "postgres" {
+		repo = repository.NewRateLimitRepository(db)
+	} else {
+		repo = repository.NewInMemoryRateLimitRepository()
+	}
+
+	rateLimitService := service.NewRateLimitService(repo, limits)
+	go func() {
+		for range time.Tick(time.Hour) {
+			if _, err := rateLimitService.PurgeIdle(context.Background()); err != nil {
+				slog.Error("Error purging idle rate limit buckets", "error", err)
+			}
+		}
+	}()
+
+	return rateLimitService
+}
+
+func createFilePacksRepository(config config.PacksConfig) repository.PacksRepository {
+	repo, err := repository.NewFilePacksRepository(config.File, config.FileWritable, config.FilePollInterval)
+	if err != nil {
+		logging.Fatal("Error loading packs file", "path", config.File, "error", err)
+	}
+
+	return repo
+}
+
+// createTlsConfig creates the TLS config from the certificate and key files, with mutual TLS when the client CA file
+// is set. The files are reloaded when they change. Without the certificate, TLS is disabled.
+func createTlsConfig(config model.TlsConfig) *tls.Config {
+	if config.CertFile == "" && config.KeyFile == "" {
+		return nil
+	}
+
+	reloader, err := tlsconfig.NewCertificateReloader(config.CertFile, config.KeyFile, config.ClientCAFile)
+	if err != nil {
+		logging.Fatal("Error loading TLS certificate", "error", err)
+	}
+	tlsConfig, err := tlsconfig.NewServerConfig(config, reloader)
+	if err != nil {
+		logging.Fatal("Error configuring TLS", "error", err)
+	}
+
+	if config.ReloadInterval > 0 {
+		go func() {
+			for range time.Tick(config.ReloadInterval) {
+				reloaded, err := reloader.Reload()
+				if err != nil {
+					slog.Error("Error reloading TLS certificate, keeping the current one", "error", err)
+				} else if reloaded {
+					slog.Info("Reloaded TLS certificate", "path", config.CertFile)
+				}
+			}
+		}()
 	}
 
-	return res
+	slog.Info("TLS enabled", "client_auth", tlsconfig.ClientAuthOf(config))
+	return tlsConfig
 }
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"server/internal/model"
+)
+
+// APIKey is a static API key, as defined in the API keys file.
+type APIKey struct {
+	Name  string   `json:"name" yaml:"name"`
+	Key   string   `json:"key" yaml:"key"`
+	Roles []string `json:"roles" yaml:"roles"`
+}
+
+type APIKeysFile struct {
+	APIKeys []APIKey `json:"apiKeys" yaml:"apiKeys"`
+}
+
+// APIKeyAuthenticatorImpl authenticates the static API keys. Only the hashes of the keys are kept in memory.
+type APIKeyAuthenticatorImpl struct {
+	principals map[[sha256.Size]byte]*Principal
+}
+
+func NewAPIKeyAuthenticator(keys []APIKey) (Authenticator, error) {
+	principals := make(map[[sha256.Size]byte]*Principal, len(keys))
+	for _, key := range keys {
+		if key.Name == "" || key.Key == "" {
+			return nil, fmt.Errorf("API key must have a name and a key")
+		}
+
+		hash := sha256.Sum256([]byte(key.Key))
+		if _, ok := principals[hash]; ok {
+			return nil, fmt.Errorf("API key %s is defined more than once", key.Name)
+		}
+		principals[hash] = &Principal{Name: key.Name, Roles: key.Roles}
+	}
+
+	return &APIKeyAuthenticatorImpl{principals: principals}, nil
+}
+
+// LoadAPIKeys reads the API keys from a YAML or JSON file.
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file APIKeysFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+
+	return file.APIKeys, nil
+}
+
+func (authenticator *APIKeyAuthenticatorImpl) Authenticate(_ context.Context, credentials Credentials) (
+	*Principal, error,
+) {
+	if credentials.APIKey == "" {
+		return nil, nil
+	}
+
+	principal, ok := authenticator.principals[sha256.Sum256([]byte(credentials.APIKey))]
+	if !ok {
+		return nil, &model.InvalidCredentials{Reason: "unknown API key"}
+	}
+
+	return principal, nil
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"slices"
+)
+
+const (
+	RoleReader = "reader"
+	RoleAdmin  = "admin"
+)
+
+// Principal is the authenticated caller.
+type Principal struct {
+	Name  string
+	Roles []string
+}
+
+// HasRole reports whether the principal has the role. An admin has the reader role as well.
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role) || (role == RoleReader && slices.Contains(p.Roles, RoleAdmin))
+}
+
+// Credentials are the credentials sent with a request, empty when not sent.
+type Credentials struct {
+	APIKey      string
+	BearerToken string
+	// ClientCertificate is the TLS client certificate, only set once it was verified against the client CAs
+	ClientCertificate *x509.Certificate
+}
+
+type Authenticator interface {
+	// Authenticate returns the principal the credentials belong to, or nil when the authenticator does not handle
+	// the kind of credentials that were sent. Credentials it handles but are not valid give model.InvalidCredentials.
+	Authenticate(ctx context.Context, credentials Credentials) (*Principal, error)
+}
+
+type ChainAuthenticatorImpl struct {
+	authenticators []Authenticator
+}
+
+// NewChainAuthenticator authenticates with the first of the authenticators that handles the credentials.
+func NewChainAuthenticator(authenticators ...Authenticator) Authenticator {
+	return &ChainAuthenticatorImpl{authenticators: authenticators}
+}
+
+func (chain *ChainAuthenticatorImpl) Authenticate(ctx context.Context, credentials Credentials) (*Principal, error) {
+	for _, authenticator := range chain.authenticators {
+		principal, err := authenticator.Authenticate(ctx, credentials)
+		if err != nil || principal != nil {
+			return principal, err
+		}
+	}
+
+	return nil, nil
+}
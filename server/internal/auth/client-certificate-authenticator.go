@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"server/internal/model"
+)
+
+// ClientCertificate maps the client certificates to roles, as defined in the client certificates file.
+// A certificate is matched by its full subject (e.g. "CN=billing,OU=Payments,O=Acme") or by its common name.
+type ClientCertificate struct {
+	Subject    string   `json:"subject" yaml:"subject"`
+	CommonName string   `json:"commonName" yaml:"commonName"`
+	Roles      []string `json:"roles" yaml:"roles"`
+}
+
+type ClientCertificatesFile struct {
+	ClientCertificates []ClientCertificate `json:"clientCertificates" yaml:"clientCertificates"`
+}
+
+// ClientCertificateAuthenticatorImpl authenticates the verified TLS client certificates. As the certificate
+// is verified by the TLS handshake, it only maps the subject of the certificate to roles.
+type ClientCertificateAuthenticatorImpl struct {
+	bySubject    map[string][]string
+	byCommonName map[string][]string
+}
+
+func NewClientCertificateAuthenticator(certificates []ClientCertificate) (Authenticator, error) {
+	authenticator := &ClientCertificateAuthenticatorImpl{
+		bySubject:    map[string][]string{},
+		byCommonName: map[string][]string{},
+	}
+	for _, certificate := range certificates {
+		var roles map[string][]string
+		var name string
+		switch {
+		case certificate.Subject != "" && certificate.CommonName != "":
+			return nil, fmt.Errorf(
+				"client certificate %s must have either a subject or a common name", certificate.Subject,
+			)
+		case certificate.Subject != "":
+			roles, name = authenticator.bySubject, certificate.Subject
+		case certificate.CommonName != "":
+			roles, name = authenticator.byCommonName, certificate.CommonName
+		default:
+			return nil, fmt.Errorf("client certificate must have a subject or a common name")
+		}
+
+		if _, ok := roles[name]; ok {
+			return nil, fmt.Errorf("client certificate %s is defined more than once", name)
+		}
+		roles[name] = certificate.Roles
+	}
+
+	return authenticator, nil
+}
+
+// LoadClientCertificates reads the client certificates from a YAML or JSON file.
+func LoadClientCertificates(path string) ([]ClientCertificate, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file ClientCertificatesFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+
+	return file.ClientCertificates, nil
+}
+
+func (authenticator *ClientCertificateAuthenticatorImpl) Authenticate(_ context.Context, credentials Credentials) (
+	*Principal, error,
+) {
+	certificate := credentials.ClientCertificate
+	if certificate == nil {
+		return nil, nil
+	}
+
+	subject := certificate.Subject.String()
+	roles, ok := authenticator.bySubject[subject]
+	if !ok {
+		roles, ok = authenticator.byCommonName[certificate.Subject.CommonName]
+	}
+	if !ok {
+		return nil, &model.InvalidCredentials{Reason: fmt.Sprintf("unknown client certificate subject %s", subject)}
+	}
+
+	return &Principal{Name: principalName(certificate), Roles: roles}, nil
+}
+
+func principalName(certificate *x509.Certificate) string {
+	if certificate.Subject.CommonName != "" {
+		return certificate.Subject.CommonName
+	}
+
+	return certificate.Subject.String()
+}
@@ -0,0 +1,264 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minJWKSRefreshInterval limits how often an unknown key id makes the key set to be fetched again.
+const minJWKSRefreshInterval = time.Minute
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// JWKS is the set of public keys the JWT tokens are verified with, loaded from a file or a URL.
+// The keys are loaded again once they are older than the refresh interval, or when a token is signed
+// with a key that is not known yet, so the rotated keys are picked up. They are loaded in the background,
+// so the tokens signed with the known keys are still verified meanwhile.
+type JWKS struct {
+	source          string
+	refreshInterval time.Duration
+	client          *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+	// refreshing is closed once the keys being loaded are, nil when they are not being loaded
+	refreshing chan struct{}
+}
+
+func NewJWKS(ctx context.Context, source string, refreshInterval time.Duration) (*JWKS, error) {
+	jwks := &JWKS{
+		source:          source,
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Timeout: 10 * time.Second},
+	}
+
+	keys, err := jwks.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	jwks.keys = keys
+	jwks.fetchedAt = time.Now()
+
+	return jwks, nil
+}
+
+// Key returns the key with the id. Without an id, the only key of the set is returned.
+// Only the tokens signed with an unknown key wait for the keys to be loaded again.
+func (jwks *JWKS) Key(ctx context.Context, kid string) (interface{}, error) {
+	jwks.mu.Lock()
+	key, known := jwks.lookup(kid)
+	age := time.Since(jwks.fetchedAt)
+	refreshing := jwks.refreshing
+	if (jwks.refreshInterval > 0 && age > jwks.refreshInterval) || (!known && age > minJWKSRefreshInterval) {
+		refreshing = jwks.startRefresh(ctx)
+	}
+	jwks.mu.Unlock()
+
+	if known {
+		return key, nil
+	}
+
+	if refreshing != nil {
+		select {
+		case <-refreshing:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		jwks.mu.Lock()
+		key, known = jwks.lookup(kid)
+		jwks.mu.Unlock()
+	}
+
+	if !known {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	return key, nil
+}
+
+// lookup must be called with the lock held.
+func (jwks *JWKS) lookup(kid string) (interface{}, bool) {
+	if kid == "" && len(jwks.keys) == 1 {
+		for _, key := range jwks.keys {
+			return key, true
+		}
+	}
+
+	key, ok := jwks.keys[kid]
+	return key, ok
+}
+
+// startRefresh loads the keys in the background, unless they are already being loaded, and returns a channel
+// closed once they are. It must be called with the lock held.
+func (jwks *JWKS) startRefresh(ctx context.Context) chan struct{} {
+	if jwks.refreshing != nil {
+		return jwks.refreshing
+	}
+
+	refreshing := make(chan struct{})
+	jwks.refreshing = refreshing
+	// the attempt counts even when it fails, so a failing source is not hit on every request
+	jwks.fetchedAt = time.Now()
+
+	// the keys are shared, so they are loaded even when the request that needed them is cancelled
+	fetchCtx := context.WithoutCancel(ctx)
+	go func() {
+		keys, err := jwks.fetch(fetchCtx)
+
+		jwks.mu.Lock()
+		if err != nil {
+			// keep verifying with the keys we have, the source can be back on the next refresh
+			slog.ErrorContext(fetchCtx, "Error refreshing JWKS", "source", jwks.source, "error", err)
+		} else {
+			jwks.keys = keys
+		}
+		jwks.refreshing = nil
+		jwks.mu.Unlock()
+
+		close(refreshing)
+	}()
+
+	return refreshing
+}
+
+func (jwks *JWKS) fetch(ctx context.Context) (map[string]interface{}, error) {
+	content, err := jwks.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var set jsonWebKeySet
+	if err := json.Unmarshal(content, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			slog.WarnContext(ctx, "Skipping JWKS key", "kid", jwk.Kid, "error", err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS from %s has no usable signing keys", jwks.source)
+	}
+
+	return keys, nil
+}
+
+func (jwks *JWKS) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(jwks.source, "http://") && !strings.HasPrefix(jwks.source, "https://") {
+		return os.ReadFile(jwks.source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwks.source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := jwks.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching JWKS from %s returned %s", jwks.source, res.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(res.Body, 1<<20))
+}
+
+func (jwk jsonWebKey) publicKey() (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key size %d", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(decoded), nil
+}
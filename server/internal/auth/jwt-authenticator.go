@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"server/internal/model"
+	"strings"
+)
+
+// JWTConfig configures how the bearer tokens are verified.
+type JWTConfig struct {
+	// Issuer and Audience are checked when not empty
+	Issuer   string
+	Audience string
+	// RolesClaim is the claim with the roles of the caller, a dot separated path for nested claims
+	// (e.g. "realm_access.roles"). The claim can be a list or a space separated string.
+	RolesClaim string
+}
+
+// JWTAuthenticatorImpl authenticates the JWT bearer tokens, signed with one of the keys of the JWKS.
+type JWTAuthenticatorImpl struct {
+	jwks   *JWKS
+	config JWTConfig
+	parser *jwt.Parser
+}
+
+func NewJWTAuthenticator(jwks *JWKS, config JWTConfig) Authenticator {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(
+			[]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"},
+		),
+		jwt.WithExpirationRequired(),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+	if config.RolesClaim == "" {
+		config.RolesClaim = "roles"
+	}
+
+	return &JWTAuthenticatorImpl{jwks: jwks, config: config, parser: jwt.NewParser(options...)}
+}
+
+func (authenticator *JWTAuthenticatorImpl) Authenticate(ctx context.Context, credentials Credentials) (
+	*Principal, error,
+) {
+	if credentials.BearerToken == "" {
+		return nil, nil
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := authenticator.parser.ParseWithClaims(
+		credentials.BearerToken, claims, func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return authenticator.jwks.Key(ctx, kid)
+		},
+	)
+	if err != nil {
+		return nil, &model.InvalidCredentials{Reason: err.Error()}
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, &model.InvalidCredentials{Reason: "token has no subject"}
+	}
+
+	roles, err := rolesOf(claims, authenticator.config.RolesClaim)
+	if err != nil {
+		return nil, &model.InvalidCredentials{Reason: err.Error()}
+	}
+
+	return &Principal{Name: subject, Roles: roles}, nil
+}
+
+func rolesOf(claims jwt.MapClaims, rolesClaim string) ([]string, error) {
+	var value interface{} = map[string]interface{}(claims)
+	for _, name := range strings.Split(rolesClaim, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		value = object[name]
+	}
+
+	switch roles := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return strings.Fields(roles), nil
+	case []interface{}:
+		res := make([]string, 0, len(roles))
+		for _, role := range roles {
+			name, ok := role.(string)
+			if !ok {
+				return nil, fmt.Errorf("claim %s must be a list of strings", rolesClaim)
+			}
+			res = append(res, name)
+		}
+		return res, nil
+	default:
+		return nil, fmt.Errorf("claim %s must be a list or a string", rolesClaim)
+	}
+}
@@ -0,0 +1,39 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"server/internal/model"
+)
+
+// Version and Commit are set when building the app, e.g.
+// go build -ldflags "-X server/internal/buildinfo.Version=1.2.0 -X server/internal/buildinfo.Commit=abc123".
+// When not set, they are read from the build info of the binary, i.e. the module version and the VCS revision.
+var (
+	Version string
+	Commit  string
+)
+
+// Get returns the version, the commit and the Go version of the app.
+func Get() model.BuildInfo {
+	info := model.BuildInfo{Version: Version, Commit: Commit, GoVersion: runtime.Version()}
+
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" {
+			info.Version = buildInfo.Main.Version
+		}
+		for _, setting := range buildInfo.Settings {
+			if info.Commit == "" && setting.Key == "vcs.revision" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+	if info.Version == "" {
+		info.Version = "unknown"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+
+	return info
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"server/internal/repository"
+	"strconv"
+	"strings"
+)
+
+// ParseArgs parses the flags wherever they are in args, e.g. both "solve -packs 250,500 12001"
+// and "solve 12001 -packs 250,500", and returns the other args.
+func ParseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// ParsePackSizes parses pack sizes separated by commas or spaces, e.g. "250,500,1000".
+// A size that is not positive or is repeated is model.InvalidPacksConfig.
+func ParsePackSizes(values ...string) ([]int, error) {
+	var sizes []int
+	for _, value := range values {
+		for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			size, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("pack size %q is not an integer", field)
+			}
+			sizes = append(sizes, size)
+		}
+	}
+	if _, err := repository.ValidPacks(sizes); err != nil {
+		return nil, err
+	}
+
+	return sizes, nil
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"context"
+	"server/internal/model"
+	"server/internal/service"
+)
+
+// Client does the packaging and the packs operations of the CLI, over HTTP with a running server
+// or directly with the services.
+type Client interface {
+	Solve(ctx context.Context, numberOfItems int) (*model.ProductPackageResponseV2, error)
+	GetPacks(ctx context.Context) (*model.PacksResponseV2, error)
+	SyncPacks(ctx context.Context, packs []int) error
+}
+
+// historyCaller is the caller of the packaging calculations recorded by the CLI
+const historyCaller = "cli"
+
+type ServiceClientImpl struct {
+	packagingService service.PackagingService
+	packsService     service.PacksService
+	historyService   service.PackagingHistoryService
+}
+
+// NewServiceClient creates a client calling the services, e.g. against the configured storage.
+// The packaging calculations are recorded in the history, unless it is nil.
+func NewServiceClient(
+	packagingService service.PackagingService, packsService service.PacksService,
+	historyService service.PackagingHistoryService,
+) Client {
+	return &ServiceClientImpl{
+		packagingService: packagingService,
+		packsService:     packsService,
+		historyService:   historyService,
+	}
+}
+
+func (client ServiceClientImpl) Solve(ctx context.Context, numberOfItems int) (*model.ProductPackageResponseV2, error) {
+	calculation, err := client.packagingService.Calculate(ctx, numberOfItems)
+	if err != nil {
+		return nil, err
+	}
+	service.RecordPackaging(ctx, client.historyService, calculation, historyCaller)
+
+	response := service.PackagingResponse(calculation)
+	return &response, nil
+}
+
+func (client ServiceClientImpl) GetPacks(ctx context.Context) (*model.PacksResponseV2, error) {
+	packsConfig, err := client.packsService.GetPacksConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.PacksResponseV2{Packs: packsConfig.Sizes, Version: packsConfig.Version, Stale: packsConfig.Stale}, nil
+}
+
+func (client ServiceClientImpl) SyncPacks(ctx context.Context, packs []int) error {
+	return client.packsService.SyncPacks(ctx, packs)
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// credentialFileSuffix is the suffix of the env var with the path of a credential's file, e.g. PACKAGING_TOKEN_FILE.
+const credentialFileSuffix = "_FILE"
+
+// ReadCredential reads a credential from its env var, or from the file of its _FILE env var.
+// The credentials are not read from the flags, which any user of the host can see (e.g. ps, /proc/<pid>/cmdline).
+func ReadCredential(name string) (string, error) {
+	value := os.Getenv(name)
+	path := os.Getenv(name + credentialFileSuffix)
+	if path == "" {
+		return value, nil
+	}
+
+	if value != "" {
+		return "", fmt.Errorf("both %s and %s%s are set", name, name, credentialFileSuffix)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"server/internal/model"
+	"strings"
+)
+
+type HttpClientImpl struct {
+	baseURL    string
+	apiKey     string
+	token      string
+	httpClient *http.Client
+}
+
+// NewHttpClient creates a client calling the API of the server at baseURL, e.g. http://localhost:8080,
+// authenticated with the API key or the bearer token when set.
+func NewHttpClient(baseURL string, apiKey string, token string, httpClient *http.Client) Client {
+	return &HttpClientImpl{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		token:      token,
+		httpClient: httpClient,
+	}
+}
+
+func (client HttpClientImpl) Solve(ctx context.Context, numberOfItems int) (*model.ProductPackageResponseV2, error) {
+	var response model.ProductPackageResponseV2
+	request := model.ProductsPackageRequest{NumberOfItems: numberOfItems}
+	if err := client.do(ctx, http.MethodPost, "/api/v2/package", request, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+func (client HttpClientImpl) GetPacks(ctx context.Context) (*model.PacksResponseV2, error) {
+	var response model.PacksResponseV2
+	if err := client.do(ctx, http.MethodGet, "/api/v2/packs", nil, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+func (client HttpClientImpl) SyncPacks(ctx context.Context, packs []int) error {
+	return client.do(ctx, http.MethodPost, "/api/v2/packs", model.PacksSyncRequest{Packs: packs}, nil)
+}
+
+// do sends a JSON request and decodes the JSON response into result. An error response is returned
+// as a ServerError with the error message of the server.
+func (client HttpClientImpl) do(ctx context.Context, method string, path string, body any, result any) error {
+	var requestBody io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		requestBody = bytes.NewReader(content)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, client.baseURL+path, requestBody)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if client.apiKey != "" {
+		request.Header.Set("X-API-Key", client.apiKey)
+	}
+	if client.token != "" {
+		request.Header.Set("Authorization", "Bearer "+client.token)
+	}
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		var errorResponse struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(response.Body).Decode(&errorResponse); err != nil || errorResponse.Error == "" {
+			errorResponse.Error = http.StatusText(response.StatusCode)
+		}
+		return &model.ServerError{StatusCode: response.StatusCode, Message: errorResponse.Error}
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid response of the server: %w", err)
+	}
+
+	return nil
+}
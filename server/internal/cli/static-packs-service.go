@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"context"
+	"server/internal/model"
+	"server/internal/repository"
+	"server/internal/service"
+)
+
+type StaticPacksServiceImpl struct {
+	sizes []int
+}
+
+// NewStaticPacksService creates a read-only packs service with the given pack sizes, to solve with other pack sizes
+// than the stored ones.
+func NewStaticPacksService(sizes []int) (service.PacksService, error) {
+	packs, err := repository.ValidPacks(sizes)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]int, len(packs))
+	for i, pack := range packs {
+		sorted[i] = pack.Size
+	}
+	return &StaticPacksServiceImpl{sizes: sorted}, nil
+}
+
+func (packsService StaticPacksServiceImpl) GetPacks(_ context.Context) ([]int, error) {
+	return packsService.sizes, nil
+}
+
+func (packsService StaticPacksServiceImpl) GetPacksConfig(_ context.Context) (*model.PacksConfig, error) {
+	return &model.PacksConfig{Sizes: packsService.sizes, Version: service.PacksConfigVersion(packsService.sizes)}, nil
+}
+
+func (packsService StaticPacksServiceImpl) SyncPacks(_ context.Context, _ []int) error {
+	return &model.ReadOnlyPacksConfig{}
+}
+
+// CacheStats returns empty statistics, the pack sizes not being cached.
+func (packsService StaticPacksServiceImpl) CacheStats() model.PacksCacheStats {
+	return model.PacksCacheStats{}
+}
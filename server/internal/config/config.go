@@ -0,0 +1,354 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"server/internal/model"
+	"strings"
+	"time"
+)
+
+// redacted replaces the secrets when the config is printed
+const redacted = "REDACTED"
+
+// dsnPasswordPattern matches the password keywords of a keyword/value DSN, e.g. password='secret',
+// with their value quoted or not
+var dsnPasswordPattern = regexp.MustCompile(`(?i)(\w*password\s*=\s*)('(?:[^'\\]|\\.)*'|\S*)`)
+
+// Config is the configuration of the app. It is loaded from the defaults, a YAML file, the env vars
+// and the command line flags, each overriding the previous ones.
+type Config struct {
+	Database    DatabaseConfig    `yaml:"database"`
+	Packs       PacksConfig       `yaml:"packs"`
+	Packaging   PackagingConfig   `yaml:"packaging"`
+	Jobs        JobsConfig        `yaml:"jobs"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Auth        AuthConfig        `yaml:"auth"`
+	RateLimit   RateLimitConfig   `yaml:"rateLimit"`
+	Requests    RequestsConfig    `yaml:"requests"`
+	Cors        model.CorsConfig  `yaml:"cors"`
+	Http        HttpConfig        `yaml:"http"`
+	Tls         model.TlsConfig   `yaml:"tls"`
+	Grpc        GrpcConfig        `yaml:"grpc"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Log         LogConfig         `yaml:"log"`
+	Admin       AdminConfig       `yaml:"admin"`
+	Quotes      QuotesConfig      `yaml:"quotes"`
+}
+
+// DatabaseConfig configures the PostgreSQL connection. URL takes precedence over the other connection settings.
+type DatabaseConfig struct {
+	URL      string `yaml:"url"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SslMode  string `yaml:"sslMode"`
+
+	MaxOpenConns    int           `yaml:"maxOpenConns"`
+	MaxIdleConns    int           `yaml:"maxIdleConns"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime"`
+
+	// ConnectAttempts is how many times the app tries to connect on startup, waiting from ConnectBackoff
+	// up to ConnectMaxBackoff between two attempts
+	ConnectAttempts   int           `yaml:"connectAttempts"`
+	ConnectBackoff    time.Duration `yaml:"connectBackoff"`
+	ConnectMaxBackoff time.Duration `yaml:"connectMaxBackoff"`
+
+	// BreakerFailureThreshold is how many failures in a row open the circuit breaker of the packs repository
+	BreakerFailureThreshold int           `yaml:"breakerFailureThreshold"`
+	BreakerOpenTimeout      time.Duration `yaml:"breakerOpenTimeout"`
+	// BreakerCallTimeout is how long a call to the packs repository can take before it counts as a failure.
+	// Shorter than the request deadline, so a hung DB opens the circuit instead of timing out every request.
+	BreakerCallTimeout time.Duration `yaml:"breakerCallTimeout"`
+}
+
+// PacksConfig configures where the pack sizes are stored. With File, the app runs without a database.
+type PacksConfig struct {
+	File             string        `yaml:"file"`
+	FileWritable     bool          `yaml:"fileWritable"`
+	FilePollInterval time.Duration `yaml:"filePollInterval"`
+	// SnapshotFile keeps the last known pack sizes, used while the database is unavailable
+	SnapshotFile string `yaml:"snapshotFile"`
+}
+
+type PackagingConfig struct {
+	// LargePackItemsBuffer is how many of the largest packs are left to the calculation of a large number of items
+	LargePackItemsBuffer int `yaml:"largePackItemsBuffer"`
+}
+
+type JobsConfig struct {
+	model.PackagingJobsConfig `yaml:",inline"`
+	MaxUploadBytes            int64         `yaml:"maxUploadBytes"`
+	PurgeInterval             time.Duration `yaml:"purgeInterval"`
+}
+
+type IdempotencyConfig struct {
+	KeyRetention     time.Duration `yaml:"keyRetention"`
+	KeyPurgeInterval time.Duration `yaml:"keyPurgeInterval"`
+}
+
+// AuthConfig configures the authentication. When no credentials are configured, authentication is disabled.
+type AuthConfig struct {
+	// Disabled lets all the requests through when no credentials are configured,
+	// otherwise the routes needing the admin role are refused
+	Disabled               bool          `yaml:"disabled"`
+	APIKeysFile            string        `yaml:"apiKeysFile"`
+	JWKS                   string        `yaml:"jwks"`
+	JWKSRefreshInterval    time.Duration `yaml:"jwksRefreshInterval"`
+	JWTIssuer              string        `yaml:"jwtIssuer"`
+	JWTAudience            string        `yaml:"jwtAudience"`
+	JWTRolesClaim          string        `yaml:"jwtRolesClaim"`
+	ClientCertificatesFile string        `yaml:"clientCertificatesFile"`
+}
+
+// RateLimitConfig configures the rate limiting. Without LimitsFile, rate limiting is disabled.
+type RateLimitConfig struct {
+	LimitsFile string `yaml:"limitsFile"`
+	// Store is where the buckets are kept: memory or postgres
+	Store string `yaml:"store"`
+}
+
+type RequestsConfig struct {
+	// Timeout is the deadline of the requests that do not set one, zero means no deadline
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxTimeout caps the deadline of the requests, zero means no cap
+	MaxTimeout time.Duration `yaml:"maxTimeout"`
+}
+
+// HttpConfig configures the HTTP server, listening on Port of all the interfaces unless Address is set.
+type HttpConfig struct {
+	model.HttpServerConfig `yaml:",inline"`
+	Port                   int `yaml:"port"`
+}
+
+type GrpcConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Port    int  `yaml:"port"`
+}
+
+// TracingConfig configures the OpenTelemetry tracing. When disabled, the trace context of the requests
+// is still propagated.
+type TracingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Endpoint is the URL of the OTLP/HTTP collector the spans are exported to
+	Endpoint string `yaml:"endpoint"`
+	// SampleRatio is the fraction of the traces started by the app that are sampled, from 0 to 1
+	SampleRatio float64 `yaml:"sampleRatio"`
+	ServiceName string  `yaml:"serviceName"`
+}
+
+// LogConfig configures the JSON logs. The levels can be changed at runtime with the log levels endpoint.
+type LogConfig struct {
+	// Level is the min level of the logs: debug, info, warn or error
+	Level string `yaml:"level"`
+	// Packages overrides the level of some packages, e.g. service=debug for server/internal/service
+	Packages []string `yaml:"packages"`
+}
+
+// AdminConfig configures the admin diagnostics routes. They are served on their own server, listening on Address
+// or else on Port of the loopback interface, or on the API port when neither is set. They need the admin role
+// whenever authentication is enabled.
+type AdminConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Address string `yaml:"address"`
+	Port    int    `yaml:"port"`
+}
+
+// QuotesConfig configures the packaging quotes. With SigningKey, the quotes come with a token signed with it,
+// which can be verified without the DB.
+type QuotesConfig struct {
+	// TTL is how long a quote is valid
+	TTL        time.Duration `yaml:"ttl"`
+	SigningKey string        `yaml:"signingKey"`
+}
+
+// Default is the config used for the settings that are not set.
+func Default() Config {
+	return Config{
+		Database: DatabaseConfig{
+			Port:                    5432,
+			SslMode:                 "disable",
+			MaxOpenConns:            10,
+			MaxIdleConns:            5,
+			ConnMaxLifetime:         30 * time.Minute,
+			ConnMaxIdleTime:         5 * time.Minute,
+			ConnectAttempts:         10,
+			ConnectBackoff:          time.Second,
+			ConnectMaxBackoff:       30 * time.Second,
+			BreakerFailureThreshold: 5,
+			BreakerOpenTimeout:      30 * time.Second,
+			BreakerCallTimeout:      5 * time.Second,
+		},
+		Packs: PacksConfig{
+			FilePollInterval: 2 * time.Second,
+		},
+		Packaging: PackagingConfig{
+			LargePackItemsBuffer: model.DefaultLargePackItemsBuffer,
+		},
+		Jobs: JobsConfig{
+			PackagingJobsConfig: model.PackagingJobsConfig{
+				Workers:      2,
+				BatchSize:    500,
+				MaxLines:     1_000_000,
+				LeaseTimeout: time.Minute,
+				PollInterval: 5 * time.Second,
+				Retention:    7 * 24 * time.Hour,
+			},
+			MaxUploadBytes: 64 << 20,
+			PurgeInterval:  time.Hour,
+		},
+		Idempotency: IdempotencyConfig{
+			KeyRetention:     24 * time.Hour,
+			KeyPurgeInterval: time.Hour,
+		},
+		Auth: AuthConfig{
+			JWKSRefreshInterval: 15 * time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			Store: "memory",
+		},
+		Requests: RequestsConfig{
+			MaxTimeout: time.Minute,
+		},
+		// any origin is allowed without credentials
+		Cors: model.CorsConfig{
+			AllowedOrigins: []string{"*"},
+			MaxAge:         12 * time.Hour,
+		},
+		// the write timeout must be longer than the request deadlines, or the responses of the slow requests are lost
+		Http: HttpConfig{
+			HttpServerConfig: model.HttpServerConfig{
+				ReadTimeout:       30 * time.Second,
+				ReadHeaderTimeout: 10 * time.Second,
+				WriteTimeout:      90 * time.Second,
+				IdleTimeout:       2 * time.Minute,
+				MaxHeaderBytes:    64 << 10,
+				ShutdownTimeout:   30 * time.Second,
+			},
+			Port: 8080,
+		},
+		Tls: model.TlsConfig{
+			ReloadInterval: time.Minute,
+		},
+		Grpc: GrpcConfig{
+			Enabled: false,
+			Port:    9090,
+		},
+		Tracing: TracingConfig{
+			Endpoint:    "http://localhost:4318",
+			SampleRatio: 1,
+			ServiceName: "packaging-server",
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Quotes: QuotesConfig{
+			TTL: 24 * time.Hour,
+		},
+	}
+}
+
+// UsesDatabase is whether the app stores its data in the database, i.e. the pack sizes are not in a file.
+func (config Config) UsesDatabase() bool {
+	return config.Packs.File == ""
+}
+
+// DSN is the connection string of the database.
+func (config DatabaseConfig) DSN() string {
+	if config.URL != "" {
+		return config.URL
+	}
+
+	return fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		dsnValue(config.Host), config.Port, dsnValue(config.Username), dsnValue(config.Password),
+		dsnValue(config.Name), dsnValue(config.SslMode),
+	)
+}
+
+// dsnValue quotes a value of a key/value connection string, so it can have spaces and quotes.
+func dsnValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// ServerConfig is the config of the HTTP server, listening on Address or else on Port.
+func (config HttpConfig) ServerConfig() model.HttpServerConfig {
+	serverConfig := config.HttpServerConfig
+	if serverConfig.Address == "" {
+		serverConfig.Address = fmt.Sprintf(":%d", config.Port)
+	}
+
+	return serverConfig
+}
+
+// Enabled is whether the API requests are authenticated, i.e. some credentials are configured.
+func (config AuthConfig) Enabled() bool {
+	return config.APIKeysFile != "" || config.JWKS != "" || config.ClientCertificatesFile != ""
+}
+
+// ServerAddress is the address of the admin server, empty when the admin routes are disabled or served
+// by the API server.
+func (config AdminConfig) ServerAddress() string {
+	switch {
+	case !config.Enabled || (config.Address == "" && config.Port == 0):
+		return ""
+	case config.Address != "":
+		return config.Address
+	default:
+		return fmt.Sprintf("127.0.0.1:%d", config.Port)
+	}
+}
+
+// Address is the address the gRPC server listens on, empty when it is disabled.
+func (config GrpcConfig) Address() string {
+	if !config.Enabled {
+		return ""
+	}
+
+	return fmt.Sprintf(":%d", config.Port)
+}
+
+// Redacted is a copy of the config without the secrets, to be printed or logged.
+func (config Config) Redacted() Config {
+	if config.Database.Password != "" {
+		config.Database.Password = redacted
+	}
+	if config.Database.URL != "" {
+		config.Database.URL = redactDatabaseURL(config.Database.URL)
+	}
+	if config.Quotes.SigningKey != "" {
+		config.Quotes.SigningKey = redacted
+	}
+
+	return config
+}
+
+// redactDatabaseURL redacts the passwords of a database URL: the one of its user info and its password query
+// parameters (e.g. sslpassword), or the password keywords of a keyword/value DSN. A URL that can not be parsed
+// is redacted as a whole.
+func redactDatabaseURL(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return redacted
+	}
+	if parsed.Scheme == "" {
+		return dsnPasswordPattern.ReplaceAllString(value, "${1}"+redacted)
+	}
+
+	if _, ok := parsed.User.Password(); ok {
+		parsed.User = url.UserPassword(parsed.User.Username(), redacted)
+	}
+	query := parsed.Query()
+	for key := range query {
+		if strings.Contains(strings.ToLower(key), "password") {
+			query.Set(key, redacted)
+			parsed.RawQuery = query.Encode()
+		}
+	}
+
+	return parsed.String()
+}
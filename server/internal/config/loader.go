@@ -0,0 +1,314 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"server/internal/model"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// configFileEnv is the env var of the YAML config file, when the -config flag is not set
+const configFileEnv = "CONFIG_FILE"
+
+// secretFileSuffix is the suffix of the env vars holding the path of a file with the value of a setting,
+// e.g. DB_PASSWORD_FILE for DB_PASSWORD, so the secrets can be mounted as files
+const secretFileSuffix = "_FILE"
+
+// secretKeys are the settings without a flag, since the command line of a process is visible to the other users
+// of the host (e.g. ps, /proc/<pid>/cmdline). They are set in the YAML file or the env vars, e.g. with _FILE.
+var secretKeys = []string{"database.url", "database.password", "quotes.signingKey"}
+
+// setting is a value of the config, set by its YAML key, its env var and the flag named after the key.
+type setting struct {
+	key   string
+	env   string
+	value any
+}
+
+func settings(config *Config) []setting {
+	return []setting{
+		{"database.url", "DATABASE_URL", &config.Database.URL},
+		{"database.host", "DB_HOST", &config.Database.Host},
+		{"database.port", "DB_PORT", &config.Database.Port},
+		{"database.username", "DB_USERNAME", &config.Database.Username},
+		{"database.password", "DB_PASSWORD", &config.Database.Password},
+		{"database.name", "DB_NAME", &config.Database.Name},
+		{"database.sslMode", "DB_SSL_MODE", &config.Database.SslMode},
+		{"database.maxOpenConns", "DB_MAX_OPEN_CONNS", &config.Database.MaxOpenConns},
+		{"database.maxIdleConns", "DB_MAX_IDLE_CONNS", &config.Database.MaxIdleConns},
+		{"database.connMaxLifetime", "DB_CONN_MAX_LIFETIME", &config.Database.ConnMaxLifetime},
+		{"database.connMaxIdleTime", "DB_CONN_MAX_IDLE_TIME", &config.Database.ConnMaxIdleTime},
+		{"database.connectAttempts", "DB_CONNECT_ATTEMPTS", &config.Database.ConnectAttempts},
+		{"database.connectBackoff", "DB_CONNECT_BACKOFF", &config.Database.ConnectBackoff},
+		{"database.connectMaxBackoff", "DB_CONNECT_MAX_BACKOFF", &config.Database.ConnectMaxBackoff},
+		{"database.breakerFailureThreshold", "DB_BREAKER_FAILURE_THRESHOLD", &config.Database.BreakerFailureThreshold},
+		{"database.breakerOpenTimeout", "DB_BREAKER_OPEN_TIMEOUT", &config.Database.BreakerOpenTimeout},
+		{"database.breakerCallTimeout", "DB_BREAKER_CALL_TIMEOUT", &config.Database.BreakerCallTimeout},
+		{"packs.file", "PACKS_FILE", &config.Packs.File},
+		{"packs.fileWritable", "PACKS_FILE_WRITABLE", &config.Packs.FileWritable},
+		{"packs.filePollInterval", "PACKS_FILE_POLL_INTERVAL", &config.Packs.FilePollInterval},
+		{"packs.snapshotFile", "PACKS_SNAPSHOT_FILE", &config.Packs.SnapshotFile},
+		{"packaging.largePackItemsBuffer", "PACKAGING_LARGE_PACK_ITEMS_BUFFER", &config.Packaging.LargePackItemsBuffer},
+		{"jobs.workers", "JOBS_WORKERS", &config.Jobs.Workers},
+		{"jobs.batchSize", "JOBS_BATCH_SIZE", &config.Jobs.BatchSize},
+		{"jobs.maxLines", "JOBS_MAX_LINES", &config.Jobs.MaxLines},
+		{"jobs.maxUploadBytes", "JOBS_MAX_UPLOAD_BYTES", &config.Jobs.MaxUploadBytes},
+		{"jobs.leaseTimeout", "JOBS_LEASE_TIMEOUT", &config.Jobs.LeaseTimeout},
+		{"jobs.pollInterval", "JOBS_POLL_INTERVAL", &config.Jobs.PollInterval},
+		{"jobs.retention", "JOBS_RETENTION", &config.Jobs.Retention},
+		{"jobs.purgeInterval", "JOBS_PURGE_INTERVAL", &config.Jobs.PurgeInterval},
+		{"idempotency.keyRetention", "IDEMPOTENCY_KEY_RETENTION", &config.Idempotency.KeyRetention},
+		{"idempotency.keyPurgeInterval", "IDEMPOTENCY_KEY_PURGE_INTERVAL", &config.Idempotency.KeyPurgeInterval},
+		{"auth.disabled", "AUTH_DISABLED", &config.Auth.Disabled},
+		{"auth.apiKeysFile", "AUTH_API_KEYS_FILE", &config.Auth.APIKeysFile},
+		{"auth.jwks", "AUTH_JWKS", &config.Auth.JWKS},
+		{"auth.jwksRefreshInterval", "AUTH_JWKS_REFRESH_INTERVAL", &config.Auth.JWKSRefreshInterval},
+		{"auth.jwtIssuer", "AUTH_JWT_ISSUER", &config.Auth.JWTIssuer},
+		{"auth.jwtAudience", "AUTH_JWT_AUDIENCE", &config.Auth.JWTAudience},
+		{"auth.jwtRolesClaim", "AUTH_JWT_ROLES_CLAIM", &config.Auth.JWTRolesClaim},
+		{"auth.clientCertificatesFile", "AUTH_CLIENT_CERTIFICATES_FILE", &config.Auth.ClientCertificatesFile},
+		{"rateLimit.limitsFile", "RATE_LIMITS_FILE", &config.RateLimit.LimitsFile},
+		{"rateLimit.store", "RATE_LIMIT_STORE", &config.RateLimit.Store},
+		{"requests.timeout", "REQUEST_TIMEOUT", &config.Requests.Timeout},
+		{"requests.maxTimeout", "REQUEST_MAX_TIMEOUT", &config.Requests.MaxTimeout},
+		{"cors.allowedOrigins", "CORS_ALLOWED_ORIGINS", &config.Cors.AllowedOrigins},
+		{"cors.allowedMethods", "CORS_ALLOWED_METHODS", &config.Cors.AllowedMethods},
+		{"cors.allowedHeaders", "CORS_ALLOWED_HEADERS", &config.Cors.AllowedHeaders},
+		{"cors.allowCredentials", "CORS_ALLOW_CREDENTIALS", &config.Cors.AllowCredentials},
+		{"cors.maxAge", "CORS_MAX_AGE", &config.Cors.MaxAge},
+		{"http.address", "HTTP_ADDRESS", &config.Http.Address},
+		{"http.port", "HTTP_PORT", &config.Http.Port},
+		{"http.readTimeout", "HTTP_READ_TIMEOUT", &config.Http.ReadTimeout},
+		{"http.readHeaderTimeout", "HTTP_READ_HEADER_TIMEOUT", &config.Http.ReadHeaderTimeout},
+		{"http.writeTimeout", "HTTP_WRITE_TIMEOUT", &config.Http.WriteTimeout},
+		{"http.idleTimeout", "HTTP_IDLE_TIMEOUT", &config.Http.IdleTimeout},
+		{"http.maxHeaderBytes", "HTTP_MAX_HEADER_BYTES", &config.Http.MaxHeaderBytes},
+		{"http.shutdownDelay", "SHUTDOWN_DELAY", &config.Http.ShutdownDelay},
+		{"http.shutdownTimeout", "SHUTDOWN_TIMEOUT", &config.Http.ShutdownTimeout},
+		{"http.trustedProxies", "HTTP_TRUSTED_PROXIES", &config.Http.TrustedProxies},
+		{"tls.certFile", "TLS_CERT_FILE", &config.Tls.CertFile},
+		{"tls.keyFile", "TLS_KEY_FILE", &config.Tls.KeyFile},
+		{"tls.clientCAFile", "TLS_CLIENT_CA_FILE", &config.Tls.ClientCAFile},
+		{"tls.clientAuth", "TLS_CLIENT_AUTH", &config.Tls.ClientAuth},
+		{"tls.reloadInterval", "TLS_RELOAD_INTERVAL", &config.Tls.ReloadInterval},
+		{"grpc.enabled", "GRPC_ENABLED", &config.Grpc.Enabled},
+		{"grpc.port", "GRPC_PORT", &config.Grpc.Port},
+		{"tracing.enabled", "TRACING_ENABLED", &config.Tracing.Enabled},
+		{"tracing.endpoint", "TRACING_ENDPOINT", &config.Tracing.Endpoint},
+		{"tracing.sampleRatio", "TRACING_SAMPLE_RATIO", &config.Tracing.SampleRatio},
+		{"tracing.serviceName", "TRACING_SERVICE_NAME", &config.Tracing.ServiceName},
+		{"log.level", "LOG_LEVEL", &config.Log.Level},
+		{"log.packages", "LOG_PACKAGES", &config.Log.Packages},
+		{"admin.enabled", "ADMIN_ENABLED", &config.Admin.Enabled},
+		{"admin.address", "ADMIN_ADDRESS", &config.Admin.Address},
+		{"admin.port", "ADMIN_PORT", &config.Admin.Port},
+		{"quotes.ttl", "QUOTES_TTL", &config.Quotes.TTL},
+		{"quotes.signingKey", "QUOTES_SIGNING_KEY", &config.Quotes.SigningKey},
+	}
+}
+
+// Loader loads the config from the defaults, the YAML file, the env vars and the flags, in this order of precedence.
+type Loader struct {
+	configFile string
+	// flagValues are the values of the flags that are set, by key. They are parsed when loading the config,
+	// so that all the invalid values are reported at once
+	flagValues map[string]string
+}
+
+// NewLoader registers the -config flag and a flag per setting on flags, except for the secrets.
+func NewLoader(flags *flag.FlagSet) *Loader {
+	loader := &Loader{flagValues: make(map[string]string)}
+	flags.StringVar(&loader.configFile, "config", "", fmt.Sprintf("the YAML config file (env %s)", configFileEnv))
+
+	defaults := Default()
+	for _, setting := range settings(&defaults) {
+		if slices.Contains(secretKeys, setting.key) {
+			continue
+		}
+		_, isBool := setting.value.(*bool)
+		value := flagValue{
+			values: loader.flagValues, key: setting.key, defaultValue: format(setting.value), isBool: isBool,
+		}
+		flags.Var(value, setting.key, fmt.Sprintf("env %s", setting.env))
+	}
+
+	return loader
+}
+
+// Load loads the config, reporting all the invalid and missing settings at once.
+func (loader *Loader) Load() (*Config, error) {
+	config := Default()
+	var problems []string
+
+	configFile := loader.configFile
+	if configFile == "" {
+		configFile = os.Getenv(configFileEnv)
+	}
+	if configFile != "" {
+		if err := readConfigFile(configFile, &config); err != nil {
+			problems = append(problems, fmt.Sprintf("config file %s: %v", configFile, err))
+		}
+	}
+
+	for _, setting := range settings(&config) {
+		_, isList := setting.value.(*[]string)
+		value, ok, err := lookupEnv(setting.env, isList)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("env %s: %v", setting.env, err))
+		} else if ok {
+			if err := parse(setting.value, value); err != nil {
+				problems = append(problems, fmt.Sprintf("env %s: %v", setting.env, err))
+			}
+		}
+
+		if value, ok := loader.flagValues[setting.key]; ok {
+			if err := parse(setting.value, value); err != nil {
+				problems = append(problems, fmt.Sprintf("flag -%s: %v", setting.key, err))
+			}
+		}
+	}
+
+	problems = append(problems, validate(config)...)
+	if len(problems) > 0 {
+		return nil, &model.InvalidConfig{Problems: problems}
+	}
+
+	return &config, nil
+}
+
+func readConfigFile(path string, config *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	// a misspelled key would be silently ignored otherwise
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
+
+// lookupEnv reads an env var, or the file of its _FILE env var. An empty env var is not set,
+// except for the lists where it is an empty list.
+func lookupEnv(name string, isList bool) (string, bool, error) {
+	value, ok := os.LookupEnv(name)
+	path := os.Getenv(name + secretFileSuffix)
+	if path == "" {
+		return value, ok && (value != "" || isList), nil
+	}
+
+	if value != "" {
+		return "", false, fmt.Errorf("both %s and %s%s are set", name, name, secretFileSuffix)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
+
+// parse parses the value of a setting into its field. The lists are comma separated.
+func parse(target any, value string) error {
+	switch target := target.(type) {
+	case *string:
+		*target = value
+	case *int:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*target = parsed
+	case *int64:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*target = parsed
+	case *float64:
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*target = parsed
+	case *bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		*target = parsed
+	case *time.Duration:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration, e.g. 30s or 5m", value)
+		}
+		*target = parsed
+	case *[]string:
+		var values []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		*target = values
+	default:
+		return fmt.Errorf("unsupported setting type %T", target)
+	}
+
+	return nil
+}
+
+// format formats the value of a setting the way it is parsed.
+func format(value any) string {
+	switch value := value.(type) {
+	case *string:
+		return *value
+	case *int:
+		return strconv.Itoa(*value)
+	case *int64:
+		return strconv.FormatInt(*value, 10)
+	case *float64:
+		return strconv.FormatFloat(*value, 'g', -1, 64)
+	case *bool:
+		return strconv.FormatBool(*value)
+	case *time.Duration:
+		return value.String()
+	case *[]string:
+		return strings.Join(*value, ",")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// flagValue records the value of a flag, to be parsed when loading the config.
+type flagValue struct {
+	values       map[string]string
+	key          string
+	defaultValue string
+	isBool       bool
+}
+
+func (value flagValue) String() string {
+	return value.defaultValue
+}
+
+func (value flagValue) Set(s string) error {
+	value.values[value.key] = s
+	return nil
+}
+
+func (value flagValue) IsBoolFlag() bool {
+	return value.isBool
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"server/internal/logging"
+	"server/internal/tlsconfig"
+	"slices"
+	"time"
+)
+
+// minQuoteSigningKeyLength is the min length of the HMAC key of the quote tokens, the size of its SHA-256 hash
+const minQuoteSigningKeyLength = 32
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// validate returns all the problems of the config.
+func validate(config Config) []string {
+	var problems []string
+	check := func(ok bool, key string, format string, args ...any) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s %s", nameOf(key), fmt.Sprintf(format, args...)))
+		}
+	}
+	positive := func(value int, key string) {
+		check(value > 0, key, "must be positive, got %d", value)
+	}
+	positiveDuration := func(value time.Duration, key string) {
+		check(value > 0, key, "must be positive, got %s", value)
+	}
+	notNegativeDuration := func(value time.Duration, key string) {
+		check(value >= 0, key, "must not be negative, got %s", value)
+	}
+	port := func(value int, key string) {
+		check(value > 0 && value <= 65535, key, "must be a port between 1 and 65535, got %d", value)
+	}
+
+	if config.UsesDatabase() {
+		database := config.Database
+		if database.URL == "" {
+			required := []struct{ key, value string }{
+				{"database.host", database.Host}, {"database.username", database.Username},
+				{"database.password", database.Password}, {"database.name", database.Name},
+			}
+			for _, setting := range required {
+				check(
+					setting.value != "", setting.key, "is required unless %s or %s is set",
+					nameOf("database.url"), nameOf("packs.file"),
+				)
+			}
+			port(database.Port, "database.port")
+			check(slices.Contains(sslModes, database.SslMode), "database.sslMode", "must be one of %v", sslModes)
+		}
+		check(database.MaxOpenConns >= 0, "database.maxOpenConns", "must not be negative")
+		check(database.MaxIdleConns >= 0, "database.maxIdleConns", "must not be negative")
+		positive(database.ConnectAttempts, "database.connectAttempts")
+		notNegativeDuration(database.ConnectBackoff, "database.connectBackoff")
+		positive(database.BreakerFailureThreshold, "database.breakerFailureThreshold")
+		positiveDuration(database.BreakerOpenTimeout, "database.breakerOpenTimeout")
+		positiveDuration(database.BreakerCallTimeout, "database.breakerCallTimeout")
+	} else {
+		notNegativeDuration(config.Packs.FilePollInterval, "packs.filePollInterval")
+		check(config.RateLimit.Store != "postgres", "rateLimit.store", "postgres needs the database, "+
+			"it can not be used with %s", nameOf("packs.file"))
+	}
+
+	positive(config.Packaging.LargePackItemsBuffer, "packaging.largePackItemsBuffer")
+
+	jobs := config.Jobs
+	positive(jobs.Workers, "jobs.workers")
+	positive(jobs.BatchSize, "jobs.batchSize")
+	positive(jobs.MaxLines, "jobs.maxLines")
+	check(jobs.MaxUploadBytes > 0, "jobs.maxUploadBytes", "must be positive, got %d", jobs.MaxUploadBytes)
+	positiveDuration(jobs.LeaseTimeout, "jobs.leaseTimeout")
+	positiveDuration(jobs.PollInterval, "jobs.pollInterval")
+	positiveDuration(jobs.Retention, "jobs.retention")
+	positiveDuration(jobs.PurgeInterval, "jobs.purgeInterval")
+
+	positiveDuration(config.Idempotency.KeyRetention, "idempotency.keyRetention")
+	positiveDuration(config.Idempotency.KeyPurgeInterval, "idempotency.keyPurgeInterval")
+
+	if config.Auth.JWKS != "" {
+		positiveDuration(config.Auth.JWKSRefreshInterval, "auth.jwksRefreshInterval")
+	}
+	check(
+		!config.Auth.Disabled || !config.Auth.Enabled(), "auth.disabled", "must not be set with %s, %s or %s",
+		nameOf("auth.apiKeysFile"), nameOf("auth.jwks"), nameOf("auth.clientCertificatesFile"),
+	)
+
+	check(
+		slices.Contains([]string{"memory", "postgres"}, config.RateLimit.Store), "rateLimit.store",
+		"must be memory or postgres, got %q", config.RateLimit.Store,
+	)
+
+	notNegativeDuration(config.Requests.Timeout, "requests.timeout")
+	notNegativeDuration(config.Requests.MaxTimeout, "requests.maxTimeout")
+
+	if config.Http.Address == "" {
+		port(config.Http.Port, "http.port")
+	}
+	notNegativeDuration(config.Http.ReadTimeout, "http.readTimeout")
+	notNegativeDuration(config.Http.ReadHeaderTimeout, "http.readHeaderTimeout")
+	notNegativeDuration(config.Http.WriteTimeout, "http.writeTimeout")
+	notNegativeDuration(config.Http.IdleTimeout, "http.idleTimeout")
+	check(config.Http.MaxHeaderBytes >= 0, "http.maxHeaderBytes", "must not be negative")
+	notNegativeDuration(config.Http.ShutdownDelay, "http.shutdownDelay")
+	notNegativeDuration(config.Http.ShutdownTimeout, "http.shutdownTimeout")
+	for _, proxy := range config.Http.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		check(cidrErr == nil || net.ParseIP(proxy) != nil, "http.trustedProxies", "has an invalid IP or CIDR %q", proxy)
+	}
+
+	if config.Tls.CertFile != "" || config.Tls.KeyFile != "" {
+		if err := tlsconfig.ValidateTlsConfig(config.Tls); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if config.Grpc.Enabled {
+		port(config.Grpc.Port, "grpc.port")
+	}
+
+	if config.Tracing.Enabled {
+		endpoint, err := url.Parse(config.Tracing.Endpoint)
+		check(
+			err == nil && (endpoint.Scheme == "http" || endpoint.Scheme == "https") && endpoint.Host != "",
+			"tracing.endpoint", "must be an http or https URL, got %q", config.Tracing.Endpoint,
+		)
+		check(config.Tracing.ServiceName != "", "tracing.serviceName", "is required")
+	}
+	check(
+		config.Tracing.SampleRatio >= 0 && config.Tracing.SampleRatio <= 1, "tracing.sampleRatio",
+		"must be between 0 and 1, got %g", config.Tracing.SampleRatio,
+	)
+
+	if _, err := logging.ParseLevels(config.Log.Level, config.Log.Packages); err != nil {
+		problems = append(problems, fmt.Sprintf("%s or %s: %v", nameOf("log.level"), nameOf("log.packages"), err))
+	}
+
+	positiveDuration(config.Quotes.TTL, "quotes.ttl")
+	if signingKey := config.Quotes.SigningKey; signingKey != "" {
+		check(
+			len(signingKey) >= minQuoteSigningKeyLength, "quotes.signingKey", "must be at least %d bytes long, got %d",
+			minQuoteSigningKeyLength, len(signingKey),
+		)
+	}
+
+	// without its own port, the admin routes must not be public
+	if admin := config.Admin; admin.Enabled && admin.Address == "" && admin.Port != 0 {
+		port(admin.Port, "admin.port")
+		check(
+			config.Http.Address != "" || admin.Port != config.Http.Port, "admin.port", "must not be %s",
+			nameOf("http.port"),
+		)
+	} else if admin.Enabled && admin.Address == "" {
+		check(
+			config.Auth.Enabled(), "admin.enabled", "needs %s or %s, or %s, %s or %s for the admin role",
+			nameOf("admin.address"), nameOf("admin.port"), nameOf("auth.apiKeysFile"), nameOf("auth.jwks"),
+			nameOf("auth.clientCertificatesFile"),
+		)
+	}
+
+	return problems
+}
+
+// nameOf names a setting by its key and its env var, e.g. "http.port (HTTP_PORT)".
+func nameOf(key string) string {
+	var config Config
+	for _, setting := range settings(&config) {
+		if setting.key == key {
+			return fmt.Sprintf("%s (%s)", key, setting.env)
+		}
+	}
+
+	return key
+}
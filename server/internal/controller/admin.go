@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"net/http/pprof"
+	rpprof "runtime/pprof"
+	"server/internal/appcontext"
+	"server/internal/auth"
+	"server/internal/buildinfo"
+)
+
+// SetupAdminRouter creates the router of the admin server, serving only the admin routes.
+// They need the admin role, unless authentication is explicitly disabled, and are rate limited like the API.
+func SetupAdminRouter(appContext *appcontext.AppContext) *gin.Engine {
+	r := newEngine(appContext.HttpServerConfig.TrustedProxies)
+	r.Use(RequestID(), AccessLog(), Recovery())
+	admin := r.Group(
+		"/admin", RateLimitByIP(appContext.RateLimitService),
+		Authorize(appContext.Authenticator, appContext.AuthDisabled, auth.RoleAdmin), RateLimit(appContext.RateLimitService),
+	)
+	registerAdminRoutes(admin, appContext)
+
+	return r
+}
+
+// registerAdminRoutes registers the diagnostics routes: the pprof profiles, the goroutine dump, the build info,
+// the effective config and the packs configuration with its cache.
+func registerAdminRoutes(admin *gin.RouterGroup, appContext *appcontext.AppContext) {
+	// pprof.Index only serves the named profiles under /debug/pprof/, so they are routed here.
+	// The command line is not served, as it can hold secrets
+	profiles := admin.Group("/debug/pprof")
+	{
+		profiles.GET("/", gin.WrapF(pprof.Index))
+		profiles.GET("/profile", gin.WrapF(pprof.Profile))
+		profiles.GET("/symbol", gin.WrapF(pprof.Symbol))
+		profiles.POST("/symbol", gin.WrapF(pprof.Symbol))
+		profiles.GET("/trace", gin.WrapF(pprof.Trace))
+		profiles.GET("/:profile", HandleProfileRequest)
+	}
+
+	admin.GET("/goroutines", HandleGoroutinesRequest)
+	admin.GET("/build-info", HandleBuildInfoRequest)
+	admin.GET("/config", func(c *gin.Context) { HandleConfigRequest(c, appContext) })
+	admin.GET("/cache", func(c *gin.Context) { HandleCacheStatsRequest(c, appContext) })
+	// the packs configuration in use and its version, as served by the API
+	admin.GET("/packs", func(c *gin.Context) { HandleGetPacksRequestV2(c, appContext) })
+}
+
+func HandleProfileRequest(requestContext *gin.Context) {
+	name := requestContext.Param("profile")
+	if rpprof.Lookup(name) == nil {
+		requestContext.JSON(http.StatusNotFound, gin.H{"error": "unknown profile " + name})
+		return
+	}
+
+	pprof.Handler(name).ServeHTTP(requestContext.Writer, requestContext.Request)
+}
+
+// HandleGoroutinesRequest dumps the stacks of all the goroutines, as in a panic.
+func HandleGoroutinesRequest(requestContext *gin.Context) {
+	requestContext.Header("Content-Type", "text/plain; charset=utf-8")
+	requestContext.Status(http.StatusOK)
+	_ = rpprof.Lookup("goroutine").WriteTo(requestContext.Writer, 2)
+}
+
+func HandleBuildInfoRequest(requestContext *gin.Context) {
+	requestContext.JSON(http.StatusOK, buildinfo.Get())
+}
+
+// HandleConfigRequest returns the effective config with the secrets redacted, with the keys of the YAML file.
+func HandleConfigRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	if appContext.Config == nil {
+		requestContext.JSON(http.StatusNotFound, gin.H{"error": "no config"})
+		return
+	}
+
+	// the config only has YAML keys, so it is converted to a map through YAML
+	content, err := yaml.Marshal(appContext.Config.Redacted())
+	var settings map[string]any
+	if err == nil {
+		err = yaml.Unmarshal(content, &settings)
+	}
+	if err != nil {
+		requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode config"})
+		return
+	}
+
+	requestContext.JSON(http.StatusOK, settings)
+}
+
+func HandleCacheStatsRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	requestContext.JSON(http.StatusOK, gin.H{"packs": appContext.PacksService.CacheStats()})
+}
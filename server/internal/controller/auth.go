@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"server/internal/auth"
+	"server/internal/model"
+	"strings"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	principalKey = "principal"
+)
+
+// Authorize lets through only the requests authenticated with an API key (X-API-Key header), a bearer token
+// (Authorization header) or a verified TLS client certificate, whose principal has the role.
+// Without an authenticator, the requests are let through when authentication is explicitly disabled,
+// and otherwise only for the reader role, the admin role being refused as it can not be checked.
+func Authorize(authenticator auth.Authenticator, authDisabled bool, role string) gin.HandlerFunc {
+	return func(requestContext *gin.Context) {
+		if authenticator == nil {
+			if !authDisabled && role == auth.RoleAdmin {
+				requestContext.AbortWithStatusJSON(
+					http.StatusForbidden, gin.H{"error": "the admin role is required, but authentication is not configured"},
+				)
+				return
+			}
+
+			requestContext.Next()
+			return
+		}
+
+		credentials := auth.Credentials{
+			APIKey:            requestContext.GetHeader(apiKeyHeader),
+			ClientCertificate: clientCertificateOf(requestContext.Request),
+		}
+		if authorization := requestContext.GetHeader("Authorization"); authorization != "" {
+			scheme, token, _ := strings.Cut(authorization, " ")
+			if !strings.EqualFold(scheme, "Bearer") || token == "" {
+				abortUnauthorized(requestContext, "unsupported Authorization header, expected a bearer token")
+				return
+			}
+			credentials.BearerToken = strings.TrimSpace(token)
+		}
+
+		principal, err := authenticator.Authenticate(requestContext.Request.Context(), credentials)
+		if err != nil {
+			var invalidCredentialsError *model.InvalidCredentials
+			if errors.As(err, &invalidCredentialsError) {
+				slog.InfoContext(requestContext.Request.Context(), "Rejected credentials", "error", err)
+				abortUnauthorized(requestContext, "invalid credentials")
+				return
+			}
+			slog.ErrorContext(requestContext.Request.Context(), "Error authenticating request", "error", err)
+			requestContext.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to authenticate"})
+			return
+		}
+		if principal == nil {
+			abortUnauthorized(requestContext, "authentication required")
+			return
+		}
+
+		if !principal.HasRole(role) {
+			requestContext.AbortWithStatusJSON(
+				http.StatusForbidden, gin.H{"error": fmt.Sprintf("the %s role is required", role)},
+			)
+			return
+		}
+
+		requestContext.Set(principalKey, principal)
+		requestContext.Next()
+	}
+}
+
+func abortUnauthorized(requestContext *gin.Context, message string) {
+	requestContext.Header("WWW-Authenticate", `Bearer realm="packaging"`)
+	requestContext.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": message})
+}
+
+// clientCertificateOf returns the TLS client certificate of the request, nil when none was sent or it was not
+// verified against the client CAs.
+func clientCertificateOf(request *http.Request) *x509.Certificate {
+	if request.TLS == nil || len(request.TLS.VerifiedChains) == 0 || len(request.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	return request.TLS.VerifiedChains[0][0]
+}
+
+// principalOf returns the authenticated principal of the request, nil when the request was not authenticated.
+func principalOf(requestContext *gin.Context) *auth.Principal {
+	if value, ok := requestContext.Get(principalKey); ok {
+		return value.(*auth.Principal)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"server/internal/appcontext"
+	"server/internal/model"
+	"server/internal/service"
+)
+
+func HandlePackageRequestV2(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	calculation, ok := calculatePackaging(requestContext, appContext)
+	if !ok {
+		return
+	}
+
+	response := service.PackagingResponse(calculation)
+	respond(requestContext, response, func() table { return packagingTableV2(response) })
+}
+
+func HandleGetPacksRequestV2(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	packsConfig, err := appContext.PacksService.GetPacksConfig(requestContext.Request.Context())
+	if err != nil {
+		var unavailableError *model.PacksStorageUnavailable
+		if errors.As(err, &unavailableError) {
+			requestContext.JSON(http.StatusServiceUnavailable, gin.H{"error": unavailableError.Error()})
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			requestContext.JSON(http.StatusGatewayTimeout, gin.H{"error": requestTimedOutMessage})
+		} else {
+			requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get packs"})
+		}
+		return
+	}
+
+	response := model.PacksResponseV2{
+		Packs:   packsConfig.Sizes,
+		Version: packsConfig.Version,
+		Stale:   packsConfig.Stale,
+	}
+	respond(requestContext, response, func() table { return packsTableV2(response) })
+}
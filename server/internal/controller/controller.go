@@ -1,43 +1,124 @@
 package controller
 
 import (
+	"context"
 	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"server/internal/appcontext"
 	"server/internal/model"
+	"server/internal/service"
+	"strconv"
 )
 
+// stalePacksHeader is set on the packaging responses computed from the last known packs configuration
+const stalePacksHeader = "X-Packs-Stale"
+
+const requestTimedOutMessage = "request timed out"
+
 func HandlePackageRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	calculation, ok := calculatePackaging(requestContext, appContext)
+	if !ok {
+		return
+	}
+
+	respond(
+		requestContext, model.ProductPackageResponse(calculation.Packs),
+		func() table { return packagingTable(calculation.Packs) },
+	)
+}
+
+// calculatePackaging does the packaging calculation shared by all API versions.
+// When it fails, the error response is already written and false is returned.
+func calculatePackaging(requestContext *gin.Context, appContext *appcontext.AppContext) (
+	*model.PackagingCalculation, bool,
+) {
+	// the calculation is recorded in the history, so it is not done for a response that can't be written
+	if !acceptable(requestContext) {
+		return nil, false
+	}
+
 	var req model.ProductsPackageRequest
 
 	if err := requestContext.ShouldBindJSON(&req); err != nil {
 		requestContext.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+		return nil, false
 	}
 
-	response, err := appContext.PackingService.PackItems(req.NumberOfItems)
+	calculation, err := appContext.PackingService.Calculate(requestContext.Request.Context(), req.NumberOfItems)
 	if err != nil {
 		var emptyPacksConfigError *model.EmptyPacksConfig
+		var unavailableError *model.PacksStorageUnavailable
 		if errors.As(err, &emptyPacksConfigError) {
 			requestContext.JSON(http.StatusBadRequest, gin.H{"error": emptyPacksConfigError.Error()})
+		} else if errors.As(err, &unavailableError) {
+			requestContext.JSON(http.StatusServiceUnavailable, gin.H{"error": unavailableError.Error()})
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			requestContext.JSON(http.StatusGatewayTimeout, gin.H{"error": requestTimedOutMessage})
 		} else {
 			requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to pack items"})
 		}
+		return nil, false
+	}
+
+	if calculation.Stale {
+		// the packs storage is down and the result is based on the last known packs configuration
+		requestContext.Header("Warning", `110 - "Response is Stale"`)
+		requestContext.Header(stalePacksHeader, "true")
+	}
+
+	service.RecordPackaging(
+		requestContext.Request.Context(), appContext.HistoryService, calculation, callerOf(requestContext),
+	)
+
+	return calculation, true
+}
+
+func HandlePackagingHistoryRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	if appContext.HistoryService == nil {
+		requestContext.JSON(http.StatusNotImplemented, gin.H{"error": "packaging history is not available"})
+		return
+	}
+
+	var req model.PackagingHistoryRequest
+
+	if err := requestContext.ShouldBindQuery(&req); err != nil {
+		requestContext.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := appContext.HistoryService.Find(requestContext.Request.Context(), req)
+	if err != nil {
+		var invalidFilterError *model.InvalidHistoryFilter
+		if errors.As(err, &invalidFilterError) {
+			requestContext.JSON(http.StatusBadRequest, gin.H{"error": invalidFilterError.Error()})
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			requestContext.JSON(http.StatusGatewayTimeout, gin.H{"error": requestTimedOutMessage})
+		} else {
+			requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get packaging history"})
+		}
 		return
 	}
 
-	requestContext.JSON(http.StatusOK, response)
+	requestContext.Header("X-Total-Count", strconv.FormatInt(response.Total, 10))
+	respond(requestContext, response, func() table { return packagingHistoryTable(response) })
 }
 
 func HandleGetPacksRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
-	response, err := appContext.PacksService.GetPacks()
+	response, err := appContext.PacksService.GetPacks(requestContext.Request.Context())
 	if err != nil {
-		requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get packs"})
+		var unavailableError *model.PacksStorageUnavailable
+		if errors.As(err, &unavailableError) {
+			requestContext.JSON(http.StatusServiceUnavailable, gin.H{"error": unavailableError.Error()})
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			requestContext.JSON(http.StatusGatewayTimeout, gin.H{"error": requestTimedOutMessage})
+		} else {
+			requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get packs"})
+		}
 		return
 	}
 
-	requestContext.JSON(http.StatusOK, response)
+	respond(requestContext, response, func() table { return packsTable(response) })
 }
 
 func HandlePacksSyncRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
@@ -48,10 +129,37 @@ func HandlePacksSyncRequest(requestContext *gin.Context, appContext *appcontext.
 		return
 	}
 
-	if err := appContext.PacksService.SyncPacks(req.Packs); err != nil {
-		requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sync packs"})
+	if err := appContext.PacksService.SyncPacks(requestContext.Request.Context(), req.Packs); err != nil {
+		var invalidPacksConfigError *model.InvalidPacksConfig
+		var readOnlyPacksConfigError *model.ReadOnlyPacksConfig
+		var unavailableError *model.PacksStorageUnavailable
+		if errors.As(err, &invalidPacksConfigError) {
+			requestContext.JSON(http.StatusBadRequest, gin.H{"error": invalidPacksConfigError.Error()})
+		} else if errors.As(err, &readOnlyPacksConfigError) {
+			requestContext.JSON(http.StatusConflict, gin.H{"error": readOnlyPacksConfigError.Error()})
+		} else if errors.As(err, &unavailableError) {
+			requestContext.JSON(http.StatusServiceUnavailable, gin.H{"error": unavailableError.Error()})
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			requestContext.JSON(http.StatusGatewayTimeout, gin.H{"error": requestTimedOutMessage})
+		} else {
+			requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sync packs"})
+		}
 		return
 	}
 
 	requestContext.JSON(http.StatusOK, map[string]string{"status": "OK"})
 }
+
+// callerOf identifies who made the request: the authenticated principal, or when authentication is disabled,
+// the X-Caller-ID header when provided and the client IP otherwise.
+func callerOf(requestContext *gin.Context) string {
+	if principal := principalOf(requestContext); principal != nil {
+		return principal.Name
+	}
+
+	if caller := requestContext.GetHeader("X-Caller-ID"); caller != "" {
+		return caller
+	}
+
+	return requestContext.ClientIP()
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"server/internal/model"
+	"slices"
+	"strings"
+)
+
+var (
+	defaultCorsMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	defaultCorsHeaders = []string{
+		"Origin", "Content-Type", "Accept", "Authorization", "X-Caller-ID", apiKeyHeader, requestTimeoutHeader,
+		idempotencyKeyHeader,
+	}
+	corsExposedHeaders = []string{
+		"Content-Length", "Warning", stalePacksHeader, idempotentReplayedHeader,
+		"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After",
+	}
+	corsMethods = []string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+		http.MethodOptions,
+	}
+)
+
+// Cors creates the CORS middleware of the policy, or nil when cross-origin requests are not allowed.
+// Methods and headers default to the ones the API uses. An incoherent policy is rejected with all its problems.
+func Cors(config model.CorsConfig) (gin.HandlerFunc, error) {
+	if err := ValidateCorsConfig(config); err != nil {
+		return nil, err
+	}
+	if len(config.AllowedOrigins) == 0 {
+		return nil, nil
+	}
+
+	methods := config.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCorsMethods
+	}
+	headers := config.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCorsHeaders
+	}
+
+	return cors.New(
+		cors.Config{
+			AllowOrigins:     config.AllowedOrigins,
+			AllowWildcard:    true,
+			AllowMethods:     methods,
+			AllowHeaders:     headers,
+			ExposeHeaders:    corsExposedHeaders,
+			AllowCredentials: config.AllowCredentials,
+			MaxAge:           config.MaxAge,
+		},
+	), nil
+}
+
+// ValidateCorsConfig reports all the problems of the policy at once.
+func ValidateCorsConfig(config model.CorsConfig) error {
+	var problems []string
+
+	for _, origin := range config.AllowedOrigins {
+		if origin == "*" {
+			if len(config.AllowedOrigins) > 1 {
+				problems = append(problems, `origin "*" allows any origin, it can not be combined with other origins`)
+			}
+			if config.AllowCredentials {
+				problems = append(
+					problems, `origin "*" can not be used with credentials, browsers reject such responses`,
+				)
+			}
+			continue
+		}
+
+		scheme, host, ok := strings.Cut(origin, "://")
+		switch {
+		case !ok || (scheme != "http" && scheme != "https"):
+			problems = append(problems, fmt.Sprintf("origin %q must start with http:// or https://", origin))
+		case host == "" || strings.Contains(host, "/"):
+			problems = append(problems, fmt.Sprintf("origin %q must be a scheme and a host, without a path", origin))
+		case strings.Contains(host, "*") && !isSubdomainWildcard(host):
+			problems = append(
+				problems, fmt.Sprintf("origin %q can only have a leading *. wildcard before a domain", origin),
+			)
+		}
+	}
+
+	for _, method := range config.AllowedMethods {
+		if !slices.Contains(corsMethods, method) {
+			problems = append(problems, fmt.Sprintf("method %q is not supported", method))
+		}
+	}
+
+	for _, header := range config.AllowedHeaders {
+		switch {
+		case header == "" || strings.ContainsAny(header, " \t,"):
+			problems = append(problems, fmt.Sprintf("header %q is not a valid header name", header))
+		case header == "*" && config.AllowCredentials:
+			problems = append(problems, `header "*" is not a wildcard when credentials are allowed, list the headers`)
+		}
+	}
+
+	if config.MaxAge < 0 {
+		problems = append(problems, "max age must not be negative")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid CORS configuration: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// isSubdomainWildcard tells whether the host is a single leading *. label before a domain of at least two labels,
+// so that a wildcard can not match a whole TLD or glue onto a name (e.g. *evil.com).
+func isSubdomainWildcard(host string) bool {
+	domain, ok := strings.CutPrefix(host, "*.")
+	domain, _, _ = strings.Cut(domain, ":")
+	return ok && !strings.Contains(domain, "*") && strings.Contains(strings.Trim(domain, "."), ".")
+}
@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"server/internal/appcontext"
+	"server/internal/model"
+)
+
+func HandleLivenessRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	writeHealthReport(requestContext, appContext.HealthService.Live())
+}
+
+func HandleReadinessRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	writeHealthReport(requestContext, appContext.HealthService.Ready(requestContext.Request.Context()))
+}
+
+func HandleStartupRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	writeHealthReport(requestContext, appContext.HealthService.Started(requestContext.Request.Context()))
+}
+
+func writeHealthReport(requestContext *gin.Context, report *model.HealthReport) {
+	// the probes must always see the current state
+	requestContext.Header("Cache-Control", "no-store")
+
+	if report.Status != model.HealthStatusUp {
+		requestContext.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+
+	requestContext.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"server/internal/model"
+	"server/internal/service"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// idempotentHeaders are the response headers stored and replayed together with the response body.
+var idempotentHeaders = []string{"Content-Type", "Warning", stalePacksHeader}
+
+// Idempotency makes the requests with the Idempotency-Key header safe to retry. The response of the first request
+// with a key is stored and replayed for the repeated requests, while reusing the key with a different request body
+// or response format is rejected with 409. Server errors and panics are not stored, so the request can be retried
+// with the same key. The keys are per caller, so callers using the same key do not see each other's responses.
+// Without an idempotencyService, the header is ignored.
+func Idempotency(idempotencyService service.IdempotencyService) gin.HandlerFunc {
+	return func(requestContext *gin.Context) {
+		key := requestContext.GetHeader(idempotencyKeyHeader)
+		if idempotencyService == nil || key == "" {
+			requestContext.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			requestContext.AbortWithStatusJSON(
+				http.StatusBadRequest,
+				gin.H{"error": fmt.Sprintf("%s header must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)},
+			)
+			return
+		}
+
+		body, err := io.ReadAll(requestContext.Request.Body)
+		if err != nil {
+			requestContext.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		requestContext.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := requestContext.Request.Context()
+		scope := idempotencyScope(requestContext)
+		format := requestContext.NegotiateFormat(responseFormats...)
+
+		stored, err := idempotencyService.Begin(ctx, scope, key, requestHash(body, format))
+		if err != nil {
+			var mismatch *model.IdempotencyKeyMismatch
+			var inProgress *model.IdempotencyKeyInProgress
+			switch {
+			case errors.As(err, &mismatch), errors.As(err, &inProgress):
+				requestContext.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			case errors.Is(err, context.DeadlineExceeded):
+				requestContext.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": requestTimedOutMessage})
+			default:
+				requestContext.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return
+		}
+
+		if stored != nil {
+			for name, value := range stored.Headers {
+				requestContext.Header(name, value)
+			}
+			requestContext.Header(idempotentReplayedHeader, "true")
+			requestContext.Status(stored.StatusCode)
+			_, _ = requestContext.Writer.Write(stored.Body)
+			requestContext.Abort()
+			return
+		}
+
+		// the request context can be done by now, but the key still has to be completed or released
+		storeCtx := context.WithoutCancel(ctx)
+		release := func() {
+			if err := idempotencyService.Release(storeCtx, scope, key); err != nil {
+				slog.ErrorContext(ctx, "Error releasing Idempotency-Key", "key", key, "error", err)
+			}
+		}
+
+		writer := &recordingResponseWriter{ResponseWriter: requestContext.Writer}
+		requestContext.Writer = writer
+		func() {
+			// a panic would leave the key in progress until it expires, so it is released before being recovered
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					requestContext.Writer = writer.ResponseWriter
+					release()
+					panic(recovered)
+				}
+			}()
+			requestContext.Next()
+		}()
+		requestContext.Writer = writer.ResponseWriter
+
+		if writer.Status() >= http.StatusInternalServerError {
+			release()
+			return
+		}
+
+		response := model.IdempotentResponse{
+			StatusCode: writer.Status(),
+			Headers:    make(map[string]string),
+			Body:       writer.body.Bytes(),
+		}
+		for _, name := range idempotentHeaders {
+			if value := writer.Header().Get(name); value != "" {
+				response.Headers[name] = value
+			}
+		}
+		if err := idempotencyService.Complete(storeCtx, scope, key, response); err != nil {
+			slog.ErrorContext(ctx, "Error storing response for Idempotency-Key", "key", key, "error", err)
+		}
+	}
+}
+
+// idempotencyScope is the route of the request and its caller, hashed to fit in the scope column.
+func idempotencyScope(requestContext *gin.Context) string {
+	caller := sha256.Sum256([]byte(callerOf(requestContext)))
+	return requestContext.Request.Method + " " + requestContext.FullPath() + " " + hex.EncodeToString(caller[:8])
+}
+
+// requestHash identifies the request body and the format of its response. JSON bodies are hashed in their
+// canonical form, so the same request sent with a different formatting or key order is not a different one.
+func requestHash(body []byte, format string) string {
+	var parsed interface{}
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		if canonical, err := json.Marshal(parsed); err == nil {
+			body = canonical
+		}
+	}
+
+	sum := sha256.Sum256(append([]byte(format+"\n"), body...))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordingResponseWriter keeps a copy of the response body while writing it.
+type recordingResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingResponseWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingResponseWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
@@ -0,0 +1,251 @@
+package controller
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"server/internal/appcontext"
+	"server/internal/model"
+	"strconv"
+	"strings"
+)
+
+// how many problems of an uploaded CSV are reported at most
+const maxCsvProblems = 10
+
+var packagingJobResultsCsvHeader = []string{
+	"order_id", "quantity", "packs", "total_packs", "total_items", "overage", "packs_version", "error",
+}
+
+// HandleSubmitPackagingJobRequest queues a packaging job for the CSV body, with the order_id and quantity columns.
+func HandleSubmitPackagingJobRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	if appContext.PackagingJobsService == nil {
+		requestContext.JSON(http.StatusNotImplemented, gin.H{"error": "packaging jobs are not available"})
+		return
+	}
+
+	items, err := readPackagingJobItems(requestContext.Request.Body)
+	if err != nil {
+		var tooLargeError *http.MaxBytesError
+		if errors.As(err, &tooLargeError) {
+			requestContext.JSON(
+				http.StatusRequestEntityTooLarge,
+				gin.H{"error": fmt.Sprintf("request body is larger than %d bytes", tooLargeError.Limit)},
+			)
+		} else {
+			requestContext.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	job, err := appContext.PackagingJobsService.Submit(
+		requestContext.Request.Context(), items, callerOf(requestContext),
+	)
+	if err != nil {
+		var invalidJobError *model.InvalidPackagingJob
+		if errors.As(err, &invalidJobError) {
+			requestContext.JSON(http.StatusBadRequest, gin.H{"error": invalidJobError.Error()})
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			requestContext.JSON(http.StatusGatewayTimeout, gin.H{"error": requestTimedOutMessage})
+		} else {
+			slog.ErrorContext(requestContext.Request.Context(), "Error submitting packaging job", "error", err)
+			requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to submit packaging job"})
+		}
+		return
+	}
+
+	requestContext.Header("Location", "/api/jobs/"+job.ID)
+	requestContext.JSON(http.StatusAccepted, job)
+}
+
+func HandleGetPackagingJobRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	if appContext.PackagingJobsService == nil {
+		requestContext.JSON(http.StatusNotImplemented, gin.H{"error": "packaging jobs are not available"})
+		return
+	}
+
+	job, err := appContext.PackagingJobsService.Find(
+		requestContext.Request.Context(), requestContext.Param("id"), callerOf(requestContext),
+	)
+	if err != nil {
+		var notFoundError *model.PackagingJobNotFound
+		if errors.As(err, &notFoundError) {
+			requestContext.JSON(http.StatusNotFound, gin.H{"error": notFoundError.Error()})
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			requestContext.JSON(http.StatusGatewayTimeout, gin.H{"error": requestTimedOutMessage})
+		} else {
+			requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get packaging job"})
+		}
+		return
+	}
+
+	requestContext.JSON(http.StatusOK, job)
+}
+
+// HandlePackagingJobResultsRequest streams the results of a completed job, as CSV or NDJSON depending on
+// the Accept header. It is not limited by the request timeout, the results of a large job taking long to download.
+func HandlePackagingJobResultsRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	if appContext.PackagingJobsService == nil {
+		requestContext.JSON(http.StatusNotImplemented, gin.H{"error": "packaging jobs are not available"})
+		return
+	}
+
+	format := requestContext.NegotiateFormat(csvContentType, ndjsonContentType)
+	if format == "" {
+		requestContext.JSON(
+			http.StatusNotAcceptable,
+			gin.H{"error": fmt.Sprintf("results are available as %s or %s", csvContentType, ndjsonContentType)},
+		)
+		return
+	}
+
+	id := requestContext.Param("id")
+	csvWriter := csv.NewWriter(requestContext.Writer)
+	jsonEncoder := json.NewEncoder(requestContext.Writer)
+	started := false
+	err := appContext.PackagingJobsService.Results(
+		requestContext.Request.Context(), id, callerOf(requestContext), func(result model.PackagingJobResult) error {
+			// the response starts with the first result, so the job errors can still be answered with a status
+			if !started {
+				started = true
+				requestContext.Header("Content-Type", format)
+				requestContext.Header(
+					"Content-Disposition", fmt.Sprintf(`attachment; filename="job-%s.%s"`, id, fileExtension(format)),
+				)
+				requestContext.Status(http.StatusOK)
+				if format == csvContentType {
+					if err := csvWriter.Write(packagingJobResultsCsvHeader); err != nil {
+						return err
+					}
+				}
+			}
+
+			if format == ndjsonContentType {
+				return jsonEncoder.Encode(result)
+			}
+			return csvWriter.Write(packagingJobResultCsvRecord(result))
+		},
+	)
+	csvWriter.Flush()
+
+	if err != nil && started {
+		// the response is already on its way, so it can only be cut short
+		slog.ErrorContext(
+			requestContext.Request.Context(), "Error writing the results of packaging job", "job_id", id, "error", err,
+		)
+
+		requestContext.Abort()
+		return
+	}
+	if err != nil {
+		var notFoundError *model.PackagingJobNotFound
+		var notCompletedError *model.PackagingJobNotCompleted
+		if errors.As(err, &notFoundError) {
+			requestContext.JSON(http.StatusNotFound, gin.H{"error": notFoundError.Error()})
+		} else if errors.As(err, &notCompletedError) {
+			requestContext.JSON(http.StatusConflict, gin.H{"error": notCompletedError.Error()})
+		} else {
+			requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get packaging job results"})
+		}
+	}
+}
+
+// readPackagingJobItems reads the orders of a CSV with a header, that has the order_id and quantity columns
+// (in any order, the other columns are ignored). All the problems found are reported, up to maxCsvProblems.
+func readPackagingJobItems(body io.Reader) ([]model.PackagingJobItem, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("CSV is empty, expected a header with the order_id and quantity columns")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	orderIDColumn, quantityColumn := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF"))) {
+		case "order_id":
+			orderIDColumn = i
+		case "quantity":
+			quantityColumn = i
+		}
+	}
+	if orderIDColumn == -1 || quantityColumn == -1 {
+		return nil, fmt.Errorf("CSV header must have the order_id and quantity columns")
+	}
+
+	var items []model.PackagingJobItem
+	var problems []string
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) <= max(orderIDColumn, quantityColumn) {
+			problems = append(problems, fmt.Sprintf("line %d: missing columns", line))
+		} else {
+			orderID := strings.TrimSpace(record[orderIDColumn])
+			quantity, err := strconv.Atoi(strings.TrimSpace(record[quantityColumn]))
+			switch {
+			case orderID == "":
+				problems = append(problems, fmt.Sprintf("line %d: order_id is empty", line))
+			case err != nil || quantity < 1:
+				problems = append(
+					problems,
+					fmt.Sprintf("line %d: quantity %q must be a positive integer", line, record[quantityColumn]),
+				)
+			default:
+				items = append(items, model.PackagingJobItem{OrderID: orderID, NumberOfItems: quantity})
+			}
+		}
+
+		if len(problems) == maxCsvProblems {
+			problems = append(problems, "...")
+			break
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid CSV: %s", strings.Join(problems, "; "))
+	}
+
+	return items, nil
+}
+
+// packagingJobResultCsvRecord formats a result as a CSV record, with the packs as "5000:2;250:1".
+func packagingJobResultCsvRecord(result model.PackagingJobResult) []string {
+	return []string{
+		csvValue(result.OrderID),
+		strconv.Itoa(result.NumberOfItems),
+		formatPacks(result.Lines),
+		strconv.Itoa(result.TotalPacks),
+		strconv.Itoa(result.TotalItems),
+		strconv.Itoa(result.Overage),
+		result.PacksVersion,
+		csvValue(result.Error),
+	}
+}
+
+func fileExtension(contentType string) string {
+	if contentType == ndjsonContentType {
+		return "ndjson"
+	}
+
+	return "csv"
+}
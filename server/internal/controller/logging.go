@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"server/internal/logging"
+	"server/internal/model"
+	"sort"
+	"strings"
+	"time"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// RequestID gives every request an ID, the one of its X-Request-ID header or else a new one, which is sent back
+// in the X-Request-ID header and added to the logs of the request.
+func RequestID() gin.HandlerFunc {
+	return func(requestContext *gin.Context) {
+		requestID := logging.RequestIDOrNew(requestContext.GetHeader(requestIDHeader))
+
+		requestContext.Header(requestIDHeader, requestID)
+		requestContext.Request = requestContext.Request.WithContext(
+			logging.WithRequestID(requestContext.Request.Context(), requestID),
+		)
+		requestContext.Next()
+	}
+}
+
+// AccessLog logs every request once it is handled. The health probes and the metrics scrapes are logged
+// at the debug level, as they would make most of the logs.
+func AccessLog() gin.HandlerFunc {
+	return func(requestContext *gin.Context) {
+		start := time.Now()
+		ctx := requestContext.Request.Context()
+		requestContext.Next()
+
+		status := requestContext.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		} else if !isTraced(requestContext) {
+			level = slog.LevelDebug
+		}
+
+		slog.LogAttrs(
+			ctx, level, "Request handled",
+			slog.String("method", requestContext.Request.Method),
+			slog.String("path", requestContext.Request.URL.Path),
+			slog.String("route", requestContext.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", max(requestContext.Writer.Size(), 0)),
+			slog.String("client_ip", requestContext.ClientIP()),
+		)
+	}
+}
+
+// Recovery answers 500 to the requests whose handler panicked, and logs the panic.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(
+		nil, func(requestContext *gin.Context, err any) {
+			slog.ErrorContext(
+				requestContext.Request.Context(), "Panic handling the request", "error", err, "stack", string(debug.Stack()),
+			)
+			requestContext.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		},
+	)
+}
+
+func HandleGetLogLevelsRequest(requestContext *gin.Context) {
+	requestContext.JSON(http.StatusOK, toLogLevelsResponse(logging.CurrentLevels()))
+}
+
+func HandleSetLogLevelsRequest(requestContext *gin.Context) {
+	var request model.LogLevels
+	if err := requestContext.ShouldBindJSON(&request); err != nil {
+		requestContext.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	var packages []string
+	for name, level := range request.Packages {
+		packages = append(packages, name+"="+level)
+	}
+	sort.Strings(packages)
+	levels, err := logging.ParseLevels(request.Level, packages)
+	if err != nil {
+		requestContext.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logging.SetLevels(levels)
+	slog.InfoContext(requestContext.Request.Context(), "Log levels changed", "level", request.Level, "packages", packages)
+	requestContext.JSON(http.StatusOK, toLogLevelsResponse(levels))
+}
+
+func toLogLevelsResponse(levels logging.Levels) model.LogLevels {
+	response := model.LogLevels{Level: levelName(levels.Default), Packages: make(map[string]string)}
+	for name, level := range levels.Packages {
+		response.Packages[name] = levelName(level)
+	}
+
+	return response
+}
+
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
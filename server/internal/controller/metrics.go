@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"server/internal/metrics"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// unmatchedRoute labels the requests of the unknown routes, so that their paths do not each make a new series
+const unmatchedRoute = "unmatched"
+
+// otherMethod labels the requests of the non-standard methods, which the clients can make up
+const otherMethod = "other"
+
+var standardMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+	http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// RequestMetrics counts the HTTP requests and measures their latency, by route rather than by path
+// so that the IDs in the paths do not make new series.
+func RequestMetrics() gin.HandlerFunc {
+	return func(requestContext *gin.Context) {
+		start := time.Now()
+		requestContext.Next()
+
+		route := requestContext.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(requestContext.Writer.Status())
+		method := requestContext.Request.Method
+		if !slices.Contains(standardMethods, method) {
+			method = otherMethod
+		}
+
+		metrics.HttpRequests.WithLabelValues(method, route, status).Inc()
+		metrics.HttpRequestDuration.WithLabelValues(method, route, status).Observe(metrics.Since(start))
+	}
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// requestTimeoutHeader lets the client set a deadline for its request,
+// as a duration (e.g. "500ms", "2s") or a number of seconds.
+const requestTimeoutHeader = "X-Request-Timeout"
+
+// RequestTimeout sets a deadline on the request context. The deadline comes from the X-Request-Timeout header,
+// or defaultTimeout when the header is not present, and is capped to maxTimeout.
+// A zero defaultTimeout means no deadline for requests without the header, and a zero maxTimeout means no cap.
+func RequestTimeout(defaultTimeout time.Duration, maxTimeout time.Duration) gin.HandlerFunc {
+	return func(requestContext *gin.Context) {
+		timeout := defaultTimeout
+
+		if header := requestContext.GetHeader(requestTimeoutHeader); header != "" {
+			parsed, err := parseRequestTimeout(header, maxTimeout)
+			if err != nil {
+				requestContext.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			timeout = parsed
+		}
+
+		if maxTimeout > 0 && (timeout == 0 || timeout > maxTimeout) {
+			timeout = maxTimeout
+		}
+
+		if timeout == 0 {
+			requestContext.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(requestContext.Request.Context(), timeout)
+		defer cancel()
+
+		requestContext.Request = requestContext.Request.WithContext(ctx)
+		requestContext.Next()
+	}
+}
+
+// parseRequestTimeout parses the header value. A number of seconds above maxTimeout is capped before
+// it is converted, as the conversion of a number too large for a time.Duration is undefined.
+func parseRequestTimeout(value string, maxTimeout time.Duration) (time.Duration, error) {
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.ParseFloat(value, 64)
+		if convErr != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+			return 0, fmt.Errorf("invalid %s header: %s", requestTimeoutHeader, value)
+		}
+
+		if maxTimeout > 0 && seconds > maxTimeout.Seconds() {
+			return maxTimeout, nil
+		}
+		if math.Abs(seconds) >= float64(math.MaxInt64)/float64(time.Second) {
+			return 0, fmt.Errorf("invalid %s header: too large, got %s", requestTimeoutHeader, value)
+		}
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+
+	if timeout <= 0 {
+		return 0, fmt.Errorf("invalid %s header: must be positive, got %s", requestTimeoutHeader, value)
+	}
+
+	return timeout, nil
+}
+
+// MaxBodySize rejects the requests with a body larger than limit with 413. The body is limited while it is read,
+// so the requests without a Content-Length can not go over it either.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(requestContext *gin.Context) {
+		if requestContext.Request.ContentLength > limit {
+			requestContext.AbortWithStatusJSON(
+				http.StatusRequestEntityTooLarge,
+				gin.H{"error": fmt.Sprintf("request body is larger than %d bytes", limit)},
+			)
+			return
+		}
+
+		if requestContext.Request.Body != nil {
+			requestContext.Request.Body = http.MaxBytesReader(requestContext.Writer, requestContext.Request.Body, limit)
+		}
+		requestContext.Next()
+	}
+}
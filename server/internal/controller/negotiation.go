@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"net/http"
+	"server/internal/model"
+	"server/internal/service"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	jsonContentType     = "application/json"
+	msgpackContentType  = "application/msgpack"
+	xMsgpackContentType = "application/x-msgpack"
+	csvContentType      = "text/csv"
+	ndjsonContentType   = "application/x-ndjson"
+
+	// csvFormulaPrefixes are the first characters that get a text cell run as a formula by spreadsheets
+	csvFormulaPrefixes = "=+-@\t\r"
+)
+
+// responseFormats are the formats of the read responses, JSON being the default.
+var responseFormats = []string{
+	jsonContentType, msgpackContentType, xMsgpackContentType, csvContentType, ndjsonContentType,
+}
+
+// table is a response as rows, each written as a CSV line or an NDJSON object.
+// The CSV columns and the NDJSON fields are the same, in the same order.
+type table struct {
+	columns []string
+	rows    [][]any
+}
+
+// respond writes the response in the format of the Accept header. JSON and MessagePack have the body as is,
+// while CSV and NDJSON have the rows of toTable. A format that is not supported gets 406.
+func respond(requestContext *gin.Context, body any, toTable func() table) {
+	requestContext.Header("Vary", "Accept")
+
+	switch format := requestContext.NegotiateFormat(responseFormats...); format {
+	case jsonContentType:
+		requestContext.JSON(http.StatusOK, body)
+	case msgpackContentType, xMsgpackContentType:
+		requestContext.Header("Content-Type", format)
+		requestContext.Render(http.StatusOK, render.MsgPack{Data: body})
+	case csvContentType:
+		writeCsvTable(requestContext, toTable())
+	case ndjsonContentType:
+		writeNdjsonTable(requestContext, toTable())
+	default:
+		notAcceptable(requestContext)
+	}
+}
+
+// acceptable checks that the response can be written in a format of the Accept header, writing 406 when it can't.
+func acceptable(requestContext *gin.Context) bool {
+	if requestContext.NegotiateFormat(responseFormats...) != "" {
+		return true
+	}
+
+	requestContext.Header("Vary", "Accept")
+	notAcceptable(requestContext)
+	return false
+}
+
+func notAcceptable(requestContext *gin.Context) {
+	requestContext.JSON(
+		http.StatusNotAcceptable,
+		gin.H{"error": fmt.Sprintf("the response is available as %s", strings.Join(responseFormats, ", "))},
+	)
+}
+
+func writeCsvTable(requestContext *gin.Context, table table) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	_ = writer.Write(table.columns)
+	for _, row := range table.rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = csvValue(value)
+		}
+		_ = writer.Write(record)
+	}
+	writer.Flush()
+
+	requestContext.Data(http.StatusOK, csvContentType, buffer.Bytes())
+}
+
+func writeNdjsonTable(requestContext *gin.Context, table table) {
+	var buffer bytes.Buffer
+	for _, row := range table.rows {
+		// the object is built field by field, so the fields keep the order of the columns
+		buffer.WriteByte('{')
+		for i, value := range row {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			name, _ := json.Marshal(table.columns[i])
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode the response"})
+				return
+			}
+			buffer.Write(name)
+			buffer.WriteByte(':')
+			buffer.Write(encoded)
+		}
+		buffer.WriteString("}\n")
+	}
+
+	requestContext.Data(http.StatusOK, ndjsonContentType, buffer.Bytes())
+}
+
+// csvValue formats a value of a CSV cell. The packs are formatted as "5000:2;250:1",
+// from the largest pack size to the smallest, and the lists are separated by ";".
+// The texts that a spreadsheet would run as a formula are prefixed with "'".
+func csvValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		if v != "" && strings.ContainsRune(csvFormulaPrefixes, rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case map[int]int:
+		return formatPacks(service.PackagingLines(v))
+	case []int:
+		values := make([]string, len(v))
+		for i, item := range v {
+			values[i] = strconv.Itoa(item)
+		}
+		return strings.Join(values, ";")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func formatPacks(lines []model.PackagingLine) string {
+	packs := make([]string, len(lines))
+	for i, line := range lines {
+		packs[i] = fmt.Sprintf("%d:%d", line.PackSize, line.Quantity)
+	}
+
+	return strings.Join(packs, ";")
+}
+
+func packsTable(sizes []int) table {
+	rows := make([][]any, len(sizes))
+	for i, size := range sizes {
+		rows[i] = []any{size}
+	}
+
+	return table{columns: []string{"packSize"}, rows: rows}
+}
+
+func packsTableV2(response model.PacksResponseV2) table {
+	rows := make([][]any, len(response.Packs))
+	for i, size := range response.Packs {
+		rows[i] = []any{size, response.Version, response.Stale}
+	}
+
+	return table{columns: []string{"packSize", "version", "stale"}, rows: rows}
+}
+
+func packagingTable(packs map[int]int) table {
+	lines := service.PackagingLines(packs)
+	rows := make([][]any, len(lines))
+	for i, line := range lines {
+		rows[i] = []any{line.PackSize, line.Quantity}
+	}
+
+	return table{columns: []string{"packSize", "quantity"}, rows: rows}
+}
+
+func packagingTableV2(response model.ProductPackageResponseV2) table {
+	rows := make([][]any, len(response.Lines))
+	for i, line := range response.Lines {
+		rows[i] = []any{
+			response.NumberOfItems, line.PackSize, line.Quantity, line.Items, response.PacksVersion, response.Stale,
+		}
+	}
+
+	return table{
+		columns: []string{"numberOfItems", "packSize", "quantity", "items", "packsVersion", "stale"},
+		rows:    rows,
+	}
+}
+
+func packagingHistoryTable(response *model.PackagingHistoryResponse) table {
+	rows := make([][]any, len(response.Items))
+	for i, entry := range response.Items {
+		rows[i] = []any{
+			entry.ID, entry.NumberOfItems, entry.Packs, entry.PackSizes, entry.Objective, entry.PacksVersion,
+			entry.Caller, entry.CreatedAt,
+		}
+	}
+
+	return table{
+		columns: []string{
+			"id", "numberOfItems", "packs", "packSizes", "objective", "packsVersion", "caller", "createdAt",
+		},
+		rows: rows,
+	}
+}
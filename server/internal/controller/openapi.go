@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"server/internal/logging"
+)
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8"/>
+  <title>Packaging Service API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css"/>
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+<script>
+  window.onload = () => {
+    window.ui = SwaggerUIBundle({url: "/api/openapi.json", dom_id: "#swagger-ui"});
+  };
+</script>
+</body>
+</html>`
+
+func init() {
+	// the NDJSON and MessagePack responses are documented as strings, so they can be validated
+	openapi3filter.RegisterBodyDecoder(ndjsonContentType, openapi3filter.PlainBodyDecoder)
+	openapi3filter.RegisterBodyDecoder(msgpackContentType, openapi3filter.FileBodyDecoder)
+	openapi3filter.RegisterBodyDecoder(xMsgpackContentType, openapi3filter.FileBodyDecoder)
+}
+
+func HandleOpenAPIRequest(requestContext *gin.Context, spec *openapi3.T) {
+	requestContext.JSON(http.StatusOK, spec)
+}
+
+func HandleSwaggerUIRequest(requestContext *gin.Context) {
+	requestContext.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
+
+// OpenAPIValidator validates the requests against the OpenAPI spec, rejecting the invalid ones with 400.
+// When validateResponses is set, the responses are validated as well, and any drift between the routes and the spec
+// (an undocumented route, status or response body) is turned into a 500 response. This is meant for tests,
+// as the responses need to be buffered.
+func OpenAPIValidator(spec *openapi3.T, validateResponses bool) gin.HandlerFunc {
+	router, err := gorillamux.NewRouter(spec)
+	if err != nil {
+		logging.Fatal("Error creating OpenAPI router", "error", err)
+	}
+
+	options := &openapi3filter.Options{
+		MultiError:         true,
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(requestContext *gin.Context) {
+		route, pathParams, err := router.FindRoute(requestContext.Request)
+		if err != nil {
+			if validateResponses && (errors.Is(err, routers.ErrPathNotFound) || errors.Is(err, routers.ErrMethodNotAllowed)) {
+				requestContext.AbortWithStatusJSON(
+					http.StatusInternalServerError,
+					gin.H{"error": fmt.Sprintf("route is not documented in the OpenAPI spec: %v", err)},
+				)
+				return
+			}
+			requestContext.Next()
+			return
+		}
+
+		requestInput := &openapi3filter.RequestValidationInput{
+			Request:    requestContext.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+		if err := openapi3filter.ValidateRequest(requestContext.Request.Context(), requestInput); err != nil {
+			requestContext.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !validateResponses {
+			requestContext.Next()
+			return
+		}
+
+		writer := &bufferedResponseWriter{ResponseWriter: requestContext.Writer, status: http.StatusOK}
+		requestContext.Writer = writer
+		requestContext.Next()
+		requestContext.Writer = writer.ResponseWriter
+
+		responseInput := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: requestInput,
+			Status:                 writer.status,
+			Header:                 writer.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(writer.body.Bytes())),
+			Options:                &openapi3filter.Options{IncludeResponseStatus: true, MultiError: true},
+		}
+		if err := openapi3filter.ValidateResponse(requestContext.Request.Context(), responseInput); err != nil {
+			writer.Header().Del("Content-Length")
+			requestContext.JSON(
+				http.StatusInternalServerError,
+				gin.H{"error": fmt.Sprintf("response does not match the OpenAPI spec: %v", err)},
+			)
+			return
+		}
+
+		writer.flush()
+	}
+}
+
+// bufferedResponseWriter holds the response until it is validated.
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferedResponseWriter) WriteHeaderNow() {
+}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponseWriter) WriteString(data string) (int, error) {
+	return w.body.WriteString(data)
+}
+
+func (w *bufferedResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedResponseWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedResponseWriter) Written() bool {
+	return w.body.Len() > 0 || w.status != http.StatusOK
+}
+
+func (w *bufferedResponseWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(w.body.Bytes())
+}
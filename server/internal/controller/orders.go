@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"server/internal/appcontext"
+	"server/internal/model"
+)
+
+// HandleCreateOrderRequest quotes an order, assigning it the packs of its quantity.
+func HandleCreateOrderRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	if appContext.OrdersService == nil {
+		requestContext.JSON(http.StatusNotImplemented, gin.H{"error": "orders are not available"})
+		return
+	}
+
+	var req model.OrderRequest
+	if err := requestContext.ShouldBindJSON(&req); err != nil {
+		requestContext.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := appContext.OrdersService.Create(requestContext.Request.Context(), req, callerOf(requestContext))
+	if err != nil {
+		var emptyPacksConfigError *model.EmptyPacksConfig
+		var unavailableError *model.PacksStorageUnavailable
+		if errors.As(err, &emptyPacksConfigError) {
+			requestContext.JSON(http.StatusBadRequest, gin.H{"error": emptyPacksConfigError.Error()})
+		} else if errors.As(err, &unavailableError) {
+			requestContext.JSON(http.StatusServiceUnavailable, gin.H{"error": unavailableError.Error()})
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			requestContext.JSON(http.StatusGatewayTimeout, gin.H{"error": requestTimedOutMessage})
+		} else {
+			slog.ErrorContext(requestContext.Request.Context(), "Error creating order", "error", err)
+			requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create order"})
+		}
+		return
+	}
+
+	requestContext.Header("Location", "/api/orders/"+order.ID)
+	requestContext.JSON(http.StatusCreated, order)
+}
+
+func HandleGetOrderRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	if appContext.OrdersService == nil {
+		requestContext.JSON(http.StatusNotImplemented, gin.H{"error": "orders are not available"})
+		return
+	}
+
+	order, err := appContext.OrdersService.Find(requestContext.Request.Context(), requestContext.Param("id"))
+	if err != nil {
+		var notFoundError *model.OrderNotFound
+		if errors.As(err, &notFoundError) {
+			requestContext.JSON(http.StatusNotFound, gin.H{"error": notFoundError.Error()})
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			requestContext.JSON(http.StatusGatewayTimeout, gin.H{"error": requestTimedOutMessage})
+		} else {
+			requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get order"})
+		}
+		return
+	}
+
+	requestContext.JSON(http.StatusOK, order)
+}
+
+// HandleOrderTransitionRequest moves an order to another status, when it can go there from its current one.
+func HandleOrderTransitionRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	if appContext.OrdersService == nil {
+		requestContext.JSON(http.StatusNotImplemented, gin.H{"error": "orders are not available"})
+		return
+	}
+
+	var req model.OrderTransitionRequest
+	if err := requestContext.ShouldBindJSON(&req); err != nil {
+		requestContext.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := appContext.OrdersService.Transition(
+		requestContext.Request.Context(), requestContext.Param("id"), req.Status,
+	)
+	if err != nil {
+		var notFoundError *model.OrderNotFound
+		var invalidStatusError *model.InvalidOrderStatus
+		var illegalTransitionError *model.IllegalOrderTransition
+		if errors.As(err, &notFoundError) {
+			requestContext.JSON(http.StatusNotFound, gin.H{"error": notFoundError.Error()})
+		} else if errors.As(err, &invalidStatusError) {
+			requestContext.JSON(http.StatusBadRequest, gin.H{"error": invalidStatusError.Error()})
+		} else if errors.As(err, &illegalTransitionError) {
+			requestContext.JSON(http.StatusConflict, gin.H{"error": illegalTransitionError.Error()})
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			requestContext.JSON(http.StatusGatewayTimeout, gin.H{"error": requestTimedOutMessage})
+		} else {
+			slog.ErrorContext(requestContext.Request.Context(), "Error changing order status", "error", err)
+			requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change order status"})
+		}
+		return
+	}
+
+	requestContext.JSON(http.StatusOK, order)
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"server/internal/appcontext"
+	"server/internal/model"
+)
+
+// HandleCreateQuoteRequest quotes the packaging of a quantity, with the pack sizes it was computed with.
+func HandleCreateQuoteRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	if appContext.QuotesService == nil {
+		requestContext.JSON(http.StatusNotImplemented, gin.H{"error": "quotes are not available"})
+		return
+	}
+
+	var req model.QuoteRequest
+	if err := requestContext.ShouldBindJSON(&req); err != nil {
+		requestContext.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := appContext.QuotesService.Create(
+		requestContext.Request.Context(), req.NumberOfItems, callerOf(requestContext),
+	)
+	if err != nil {
+		var emptyPacksConfigError *model.EmptyPacksConfig
+		var unavailableError *model.PacksStorageUnavailable
+		if errors.As(err, &emptyPacksConfigError) {
+			requestContext.JSON(http.StatusBadRequest, gin.H{"error": emptyPacksConfigError.Error()})
+		} else if errors.As(err, &unavailableError) {
+			requestContext.JSON(http.StatusServiceUnavailable, gin.H{"error": unavailableError.Error()})
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			requestContext.JSON(http.StatusGatewayTimeout, gin.H{"error": requestTimedOutMessage})
+		} else {
+			slog.ErrorContext(requestContext.Request.Context(), "Error creating quote", "error", err)
+			requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create quote"})
+		}
+		return
+	}
+
+	requestContext.Header("Location", "/api/quotes/"+quote.ID)
+	requestContext.JSON(http.StatusCreated, quote)
+}
+
+func HandleGetQuoteRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	if appContext.QuotesService == nil {
+		requestContext.JSON(http.StatusNotImplemented, gin.H{"error": "quotes are not available"})
+		return
+	}
+
+	quote, err := appContext.QuotesService.Find(requestContext.Request.Context(), requestContext.Param("id"))
+	if err != nil {
+		var notFoundError *model.QuoteNotFound
+		var expiredError *model.QuoteExpired
+		if errors.As(err, &notFoundError) {
+			requestContext.JSON(http.StatusNotFound, gin.H{"error": notFoundError.Error()})
+		} else if errors.As(err, &expiredError) {
+			requestContext.JSON(http.StatusGone, gin.H{"error": expiredError.Error()})
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			requestContext.JSON(http.StatusGatewayTimeout, gin.H{"error": requestTimedOutMessage})
+		} else {
+			requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get quote"})
+		}
+		return
+	}
+
+	requestContext.JSON(http.StatusOK, quote)
+}
+
+// HandleVerifyQuoteTokenRequest returns what a quote token vouches for, without reading the quote.
+func HandleVerifyQuoteTokenRequest(requestContext *gin.Context, appContext *appcontext.AppContext) {
+	if appContext.QuotesService == nil {
+		requestContext.JSON(http.StatusNotImplemented, gin.H{"error": "quotes are not available"})
+		return
+	}
+
+	var req model.QuoteTokenRequest
+	if err := requestContext.ShouldBindJSON(&req); err != nil {
+		requestContext.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := appContext.QuotesService.Verify(req.Token)
+	if err != nil {
+		var disabledError *model.QuoteTokensDisabled
+		var invalidTokenError *model.InvalidQuoteToken
+		var expiredError *model.QuoteExpired
+		if errors.As(err, &disabledError) {
+			requestContext.JSON(http.StatusNotImplemented, gin.H{"error": disabledError.Error()})
+		} else if errors.As(err, &invalidTokenError) {
+			requestContext.JSON(http.StatusBadRequest, gin.H{"error": invalidTokenError.Error()})
+		} else if errors.As(err, &expiredError) {
+			requestContext.JSON(http.StatusGone, gin.H{"error": expiredError.Error()})
+		} else {
+			requestContext.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify quote token"})
+		}
+		return
+	}
+
+	requestContext.JSON(http.StatusOK, claims)
+}
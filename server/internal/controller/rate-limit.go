@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"math"
+	"net/http"
+	"server/internal/model"
+	"server/internal/service"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit limits the requests of each client to the route, identifying the client by its principal when
+// authenticated, or its IP otherwise. The requests over the limit are rejected with 429, and
+// all the responses get the RateLimit-* headers. When the limits can not be checked, the requests are let through.
+func RateLimit(rateLimitService service.RateLimitService) gin.HandlerFunc {
+	return func(requestContext *gin.Context) {
+		if rateLimitService == nil {
+			requestContext.Next()
+			return
+		}
+
+		route := requestContext.Request.Method + " " + requestContext.FullPath()
+		decision, err := rateLimitService.Take(requestContext.Request.Context(), route, rateLimitClientOf(requestContext))
+		enforceRateLimit(requestContext, decision, err, rateLimitService.LimitOf(route))
+	}
+}
+
+// RateLimitByIP limits the requests of each client IP to all the routes together. It runs before the authentication,
+// so that the requests with rejected credentials are limited too, RateLimit limiting the principals after it.
+func RateLimitByIP(rateLimitService service.RateLimitService) gin.HandlerFunc {
+	return func(requestContext *gin.Context) {
+		if rateLimitService == nil {
+			requestContext.Next()
+			return
+		}
+
+		decision, err := rateLimitService.TakeForIP(requestContext.Request.Context(), requestContext.ClientIP())
+		enforceRateLimit(requestContext, decision, err, rateLimitService.IPLimit())
+	}
+}
+
+// enforceRateLimit rejects the request when the decision does not allow it, and lets it through otherwise,
+// or when there is no decision.
+func enforceRateLimit(
+	requestContext *gin.Context, decision *model.RateLimitDecision, err error, limit *model.RateLimit,
+) {
+	if err != nil {
+		slog.ErrorContext(
+			requestContext.Request.Context(), "Error checking rate limit, letting the request through", "error", err,
+		)
+
+		requestContext.Next()
+		return
+	}
+	if decision == nil {
+		requestContext.Next()
+		return
+	}
+
+	if limit != nil {
+		requestContext.Header("RateLimit-Policy", rateLimitPolicy(limit.Burst, limit.RequestsPerSecond, limit.DailyQuota))
+	}
+	requestContext.Header("RateLimit-Limit", strconv.Itoa(decision.Limit))
+	requestContext.Header("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	requestContext.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+
+	if !decision.Allowed {
+		message := "rate limit exceeded"
+		if decision.QuotaExceeded {
+			message = "daily quota exceeded"
+		}
+		requestContext.Header("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+		requestContext.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": message})
+		return
+	}
+
+	requestContext.Next()
+}
+
+func rateLimitClientOf(requestContext *gin.Context) string {
+	// an API key that is not authenticated does not identify anyone, so any value would get its own limits
+	if principal := principalOf(requestContext); principal != nil {
+		return "principal:" + principal.Name
+	}
+
+	return "ip:" + requestContext.ClientIP()
+}
+
+// rateLimitPolicy describes the limits as "<requests>;w=<window seconds>" items.
+func rateLimitPolicy(burst int, requestsPerSecond float64, dailyQuota int) string {
+	window := max(1, int(math.Ceil(float64(burst)/requestsPerSecond)))
+	policies := []string{fmt.Sprintf("%d;w=%d", burst, window)}
+	if dailyQuota > 0 {
+		policies = append(policies, fmt.Sprintf("%d;w=%d", dailyQuota, int((24*time.Hour).Seconds())))
+	}
+	return strings.Join(policies, ", ")
+}
+
+func ceilSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
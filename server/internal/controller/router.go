@@ -1,33 +1,158 @@
 package controller
 
 import (
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"server/internal/appcontext"
-	"time"
+	"server/internal/auth"
+	"server/internal/config"
+	"server/internal/logging"
+	"server/internal/metrics"
+	"server/internal/openapi"
 )
 
 func SetupRouter(appContext *appcontext.AppContext) *gin.Engine {
-	r := gin.Default()
-
+	r := newEngine(appContext.HttpServerConfig.TrustedProxies)
+	serviceName := config.Default().Tracing.ServiceName
+	if appContext.Config != nil {
+		serviceName = appContext.Config.Tracing.ServiceName
+	}
 	r.Use(
-		cors.New(
-			cors.Config{
-				AllowOrigins:     []string{"*"},
-				AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-				AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-				ExposeHeaders:    []string{"Content-Length"},
-				AllowCredentials: true,
-				MaxAge:           12 * time.Hour,
-			},
-		),
+		RequestID(), AccessLog(), Recovery(), otelgin.Middleware(serviceName, otelgin.WithGinFilter(isTraced)),
+		RequestMetrics(),
 	)
 
+	corsMiddleware, err := Cors(appContext.CorsConfig)
+	if err != nil {
+		logging.Fatal("Error in CORS configuration", "error", err)
+	}
+	if corsMiddleware != nil {
+		r.Use(corsMiddleware)
+	}
+
+	spec, err := openapi.Load()
+	if err != nil {
+		logging.Fatal("Error loading OpenAPI spec", "error", err)
+	}
+
+	// in test mode the responses are validated too, so any drift from the spec fails the tests
+	openAPIValidator := OpenAPIValidator(spec, gin.Mode() == gin.TestMode)
+
+	if appContext.HealthService != nil {
+		r.GET("/healthz", func(c *gin.Context) { HandleLivenessRequest(c, appContext) })
+		r.GET("/readyz", func(c *gin.Context) { HandleReadinessRequest(c, appContext) })
+		r.GET("/startupz", func(c *gin.Context) { HandleStartupRequest(c, appContext) })
+	}
+
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	r.GET("/api/openapi.json", func(c *gin.Context) { HandleOpenAPIRequest(c, spec) })
+	r.GET("/api/docs", HandleSwaggerUIRequest)
+
+	idempotency := Idempotency(appContext.IdempotencyService)
+	reader := Authorize(appContext.Authenticator, appContext.AuthDisabled, auth.RoleReader)
+	admin := Authorize(appContext.Authenticator, appContext.AuthDisabled, auth.RoleAdmin)
+	rateLimit := RateLimit(appContext.RateLimitService)
+	// the IPs are limited before the authentication, so the rejected credentials can not be tried without limit
+	ipRateLimit := RateLimitByIP(appContext.RateLimitService)
+
 	api := r.Group("/api")
+	api.Use(ipRateLimit, openAPIValidator, RequestTimeout(appContext.RequestTimeout, appContext.MaxRequestTimeout))
+	{
+		api.POST(
+			"/package", reader, rateLimit, idempotency,
+			func(c *gin.Context) { HandlePackageRequest(c, appContext) },
+		)
+		api.GET(
+			"/package/history", reader, rateLimit,
+			func(c *gin.Context) { HandlePackagingHistoryRequest(c, appContext) },
+		)
+		api.GET("/packs", reader, rateLimit, func(c *gin.Context) { HandleGetPacksRequest(c, appContext) })
+		api.POST(
+			"/packs", admin, rateLimit, idempotency,
+			func(c *gin.Context) { HandlePacksSyncRequest(c, appContext) },
+		)
+		api.POST(
+			"/orders", admin, rateLimit, idempotency,
+			func(c *gin.Context) { HandleCreateOrderRequest(c, appContext) },
+		)
+		api.GET("/orders/:id", reader, rateLimit, func(c *gin.Context) { HandleGetOrderRequest(c, appContext) })
+		api.POST(
+			"/orders/:id/transitions", admin, rateLimit, idempotency,
+			func(c *gin.Context) { HandleOrderTransitionRequest(c, appContext) },
+		)
+		api.POST(
+			"/quotes", admin, rateLimit, idempotency,
+			func(c *gin.Context) { HandleCreateQuoteRequest(c, appContext) },
+		)
+		api.GET("/quotes/:id", reader, rateLimit, func(c *gin.Context) { HandleGetQuoteRequest(c, appContext) })
+		api.POST(
+			"/quotes/verify", reader, rateLimit,
+			func(c *gin.Context) { HandleVerifyQuoteTokenRequest(c, appContext) },
+		)
+		api.GET("/log-levels", admin, rateLimit, HandleGetLogLevelsRequest)
+		api.PUT("/log-levels", admin, rateLimit, HandleSetLogLevelsRequest)
+	}
+
+	// without their own server, the admin routes need the admin role
+	if appContext.AdminEnabled && appContext.AdminAddress == "" {
+		registerAdminRoutes(r.Group("/admin", ipRateLimit, admin, rateLimit), appContext)
+	}
+
+	// the job uploads are large, so their size is limited before they are read by the validator,
+	// and the results take long to download, so they are not limited by the request timeout
+	jobs := r.Group("/api/jobs")
+	jobs.Use(ipRateLimit, MaxBodySize(appContext.MaxJobUploadBytes), openAPIValidator)
+	jobsTimeout := RequestTimeout(appContext.RequestTimeout, appContext.MaxRequestTimeout)
 	{
-		api.POST("/package", func(c *gin.Context) { HandlePackageRequest(c, appContext) })
-		api.GET("/packs", func(c *gin.Context) { HandleGetPacksRequest(c, appContext) })
-		api.POST("/packs", func(c *gin.Context) { HandlePacksSyncRequest(c, appContext) })
+		jobs.POST(
+			"", jobsTimeout, admin, rateLimit,
+			func(c *gin.Context) { HandleSubmitPackagingJobRequest(c, appContext) },
+		)
+		jobs.GET(
+			"/:id", jobsTimeout, reader, rateLimit,
+			func(c *gin.Context) { HandleGetPackagingJobRequest(c, appContext) },
+		)
+		jobs.GET(
+			"/:id/results", reader, rateLimit,
+			func(c *gin.Context) { HandlePackagingJobResultsRequest(c, appContext) },
+		)
+	}
+
+	apiV2 := r.Group("/api/v2")
+	apiV2.Use(ipRateLimit, openAPIValidator, RequestTimeout(appContext.RequestTimeout, appContext.MaxRequestTimeout))
+	{
+		apiV2.POST(
+			"/package", reader, rateLimit, idempotency,
+			func(c *gin.Context) { HandlePackageRequestV2(c, appContext) },
+		)
+		apiV2.GET("/packs", reader, rateLimit, func(c *gin.Context) { HandleGetPacksRequestV2(c, appContext) })
+		apiV2.POST(
+			"/packs", admin, rateLimit, idempotency,
+			func(c *gin.Context) { HandlePacksSyncRequest(c, appContext) },
+		)
+	}
+
+	return r
+}
+
+// isTraced is whether the request is traced, i.e. not a health probe nor a metrics scrape,
+// which would make most of the traces.
+func isTraced(requestContext *gin.Context) bool {
+	switch requestContext.FullPath() {
+	case "/healthz", "/readyz", "/startupz", "/metrics":
+		return false
+	default:
+		return true
+	}
+}
+
+// newEngine creates a router that takes the client IP from the X-Forwarded-For header only when the request comes
+// from one of the trusted proxies, so the IP rate limits can not be bypassed by setting the header.
+func newEngine(trustedProxies []string) *gin.Engine {
+	r := gin.New()
+	// without any proxy, gin trusts them all
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		logging.Fatal("Error in trusted proxies", "error", err)
 	}
 
 	return r
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net/http"
+	"server/internal/model"
+)
+
+// NewHttpServer creates the HTTP server serving the handler, over TLS when tlsConfig is not nil.
+func NewHttpServer(config model.HttpServerConfig, tlsConfig *tls.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              config.Address,
+		Handler:           handler,
+		TLSConfig:         tlsConfig,
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+	}
+}
+
+// ShutdownHttpServer stops accepting connections and waits for the in-flight requests to complete.
+// The connections still active when ctx is done are closed.
+func ShutdownHttpServer(ctx context.Context, server *http.Server) error {
+	err := server.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		slog.Warn("In-flight requests did not complete in time, closing their connections")
+		return errors.Join(err, server.Close())
+	}
+
+	return err
+}
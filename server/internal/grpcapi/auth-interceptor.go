@@ -0,0 +1,99 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"server/internal/auth"
+	"server/internal/grpcapi/pb"
+	"server/internal/model"
+	"strings"
+)
+
+// methodRoles are the roles needed to call the methods. The methods not listed here (health, reflection) are public.
+var methodRoles = map[string]string{
+	pb.PackagingService_ListPacks_FullMethodName: auth.RoleReader,
+	pb.PackagingService_PackItems_FullMethodName: auth.RoleReader,
+	pb.PackagingService_SyncPacks_FullMethodName: auth.RoleAdmin,
+}
+
+type principalContextKey struct{}
+
+// authInterceptor authenticates the calls with the x-api-key or the authorization (bearer token) metadata,
+// or the verified TLS client certificate, the same way the REST API does. Without an authenticator, the methods
+// needing the admin role are refused, unless authentication is explicitly disabled.
+func authInterceptor(authenticator auth.Authenticator, authDisabled bool) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		role, ok := methodRoles[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+		if authenticator == nil {
+			if !authDisabled && role == auth.RoleAdmin {
+				return nil, status.Error(
+					codes.PermissionDenied, "the admin role is required, but authentication is not configured",
+				)
+			}
+			return handler(ctx, req)
+		}
+
+		credentials := auth.Credentials{ClientCertificate: clientCertificateOf(ctx)}
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("x-api-key"); len(values) > 0 {
+				credentials.APIKey = values[0]
+			}
+			if values := md.Get("authorization"); len(values) > 0 {
+				scheme, token, _ := strings.Cut(values[0], " ")
+				if !strings.EqualFold(scheme, "Bearer") || token == "" {
+					return nil, status.Error(codes.Unauthenticated, "unsupported authorization, expected a bearer token")
+				}
+				credentials.BearerToken = strings.TrimSpace(token)
+			}
+		}
+
+		principal, err := authenticator.Authenticate(ctx, credentials)
+		if err != nil {
+			var invalidCredentialsError *model.InvalidCredentials
+			if errors.As(err, &invalidCredentialsError) {
+				slog.InfoContext(ctx, "Rejected credentials", "error", err)
+				return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+			}
+			slog.ErrorContext(ctx, "Error authenticating call", "error", err)
+			return nil, status.Error(codes.Internal, "failed to authenticate")
+		}
+		if principal == nil {
+			return nil, status.Error(codes.Unauthenticated, "authentication required")
+		}
+
+		if !principal.HasRole(role) {
+			return nil, status.Error(codes.PermissionDenied, fmt.Sprintf("the %s role is required", role))
+		}
+
+		return handler(context.WithValue(ctx, principalContextKey{}, principal), req)
+	}
+}
+
+// clientCertificateOf returns the TLS client certificate of the call, nil when none was sent or it was not
+// verified against the client CAs.
+func clientCertificateOf(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	return tlsInfo.State.VerifiedChains[0][0]
+}
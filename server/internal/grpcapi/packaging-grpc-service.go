@@ -0,0 +1,171 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"server/internal/appcontext"
+	"server/internal/auth"
+	"server/internal/grpcapi/pb"
+	"server/internal/model"
+	"server/internal/service"
+)
+
+// PackagingGrpcService exposes the packs and packaging services over gRPC,
+// the same way the REST controller does over HTTP.
+type PackagingGrpcService struct {
+	pb.UnimplementedPackagingServiceServer
+	appContext *appcontext.AppContext
+}
+
+// NewGrpcServer creates a gRPC server with the packaging service, and the health and reflection services.
+// It uses the same TLS config as the HTTP server.
+func NewGrpcServer(appContext *appcontext.AppContext) *grpc.Server {
+	var options []grpc.ServerOption
+	if appContext.TlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(appContext.TlsConfig)))
+	}
+	interceptors := []grpc.UnaryServerInterceptor{requestIDInterceptor(), recoveryInterceptor()}
+	if appContext.RateLimitService != nil {
+		interceptors = append(interceptors, ipRateLimitInterceptor(appContext.RateLimitService))
+	}
+	interceptors = append(interceptors, authInterceptor(appContext.Authenticator, appContext.AuthDisabled))
+	if appContext.RateLimitService != nil {
+		interceptors = append(interceptors, rateLimitInterceptor(appContext.RateLimitService))
+	}
+	options = append(options, grpc.ChainUnaryInterceptor(interceptors...))
+
+	grpcServer := grpc.NewServer(options...)
+
+	pb.RegisterPackagingServiceServer(grpcServer, &PackagingGrpcService{appContext: appContext})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(pb.PackagingService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+
+	reflection.Register(grpcServer)
+
+	return grpcServer
+}
+
+func (s *PackagingGrpcService) ListPacks(ctx context.Context, _ *pb.ListPacksRequest) (*pb.ListPacksResponse, error) {
+	packsConfig, err := s.appContext.PacksService.GetPacksConfig(ctx)
+	if err != nil {
+		return nil, toStatusError(err, "failed to get packs")
+	}
+
+	return &pb.ListPacksResponse{
+		Packs:   toInt64s(packsConfig.Sizes),
+		Version: packsConfig.Version,
+		Stale:   packsConfig.Stale,
+	}, nil
+}
+
+func (s *PackagingGrpcService) SyncPacks(ctx context.Context, req *pb.PacksSyncRequest) (*pb.PacksSyncResponse, error) {
+	packs := make([]int, len(req.GetPacks()))
+	for i, p := range req.GetPacks() {
+		packs[i] = int(p)
+	}
+
+	if err := s.appContext.PacksService.SyncPacks(ctx, packs); err != nil {
+		return nil, toStatusError(err, "failed to sync packs")
+	}
+
+	return &pb.PacksSyncResponse{}, nil
+}
+
+func (s *PackagingGrpcService) PackItems(ctx context.Context, req *pb.ProductsPackageRequest) (
+	*pb.ProductsPackageResponse, error,
+) {
+	if req.GetNumberOfItems() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "number_of_items must be positive")
+	}
+
+	calculation, err := s.appContext.PackingService.Calculate(ctx, int(req.GetNumberOfItems()))
+	if err != nil {
+		return nil, toStatusError(err, "failed to pack items")
+	}
+
+	service.RecordPackaging(ctx, s.appContext.HistoryService, calculation, callerOf(ctx))
+
+	response := &pb.ProductsPackageResponse{
+		NumberOfItems: int64(calculation.NumberOfItems),
+		PacksVersion:  calculation.PacksVersion,
+		Stale:         calculation.Stale,
+	}
+	for _, line := range service.PackagingLines(calculation.Packs) {
+		response.Lines = append(
+			response.Lines, &pb.PackagingLine{
+				PackSize: int64(line.PackSize),
+				Quantity: int64(line.Quantity),
+				Items:    int64(line.Items),
+			},
+		)
+		response.TotalPacks += int64(line.Quantity)
+		response.TotalItems += int64(line.Items)
+	}
+	response.Overage = response.TotalItems - response.NumberOfItems
+
+	return response, nil
+}
+
+func toStatusError(err error, internalMessage string) error {
+	var emptyPacksConfigError *model.EmptyPacksConfig
+	var invalidPacksConfigError *model.InvalidPacksConfig
+	var readOnlyPacksConfigError *model.ReadOnlyPacksConfig
+	var unavailableError *model.PacksStorageUnavailable
+
+	switch {
+	case errors.As(err, &emptyPacksConfigError):
+		return status.Error(codes.FailedPrecondition, emptyPacksConfigError.Error())
+	case errors.As(err, &invalidPacksConfigError):
+		return status.Error(codes.InvalidArgument, invalidPacksConfigError.Error())
+	case errors.As(err, &readOnlyPacksConfigError):
+		return status.Error(codes.FailedPrecondition, readOnlyPacksConfigError.Error())
+	case errors.As(err, &unavailableError):
+		return status.Error(codes.Unavailable, unavailableError.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "request timed out")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "request canceled")
+	default:
+		return status.Error(codes.Internal, internalMessage)
+	}
+}
+
+// callerOf identifies who made the call: the authenticated principal, or when authentication is disabled,
+// the x-caller-id metadata when provided and the peer address otherwise.
+func callerOf(ctx context.Context) string {
+	if principal, ok := ctx.Value(principalContextKey{}).(*auth.Principal); ok {
+		return principal.Name
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-caller-id"); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+
+	return ""
+}
+
+func toInt64s(values []int) []int64 {
+	res := make([]int64, len(values))
+	for i, v := range values {
+		res[i] = int64(v)
+	}
+	return res
+}
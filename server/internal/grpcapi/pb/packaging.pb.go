@@ -0,0 +1,489 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: packaging.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListPacksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPacksRequest) Reset() {
+	*x = ListPacksRequest{}
+	mi := &file_packaging_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPacksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPacksRequest) ProtoMessage() {}
+
+func (x *ListPacksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_packaging_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPacksRequest.ProtoReflect.Descriptor instead.
+func (*ListPacksRequest) Descriptor() ([]byte, []int) {
+	return file_packaging_proto_rawDescGZIP(), []int{0}
+}
+
+type ListPacksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Packs         []int64                `protobuf:"varint,1,rep,packed,name=packs,proto3" json:"packs,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Stale         bool                   `protobuf:"varint,3,opt,name=stale,proto3" json:"stale,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPacksResponse) Reset() {
+	*x = ListPacksResponse{}
+	mi := &file_packaging_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPacksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPacksResponse) ProtoMessage() {}
+
+func (x *ListPacksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_packaging_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPacksResponse.ProtoReflect.Descriptor instead.
+func (*ListPacksResponse) Descriptor() ([]byte, []int) {
+	return file_packaging_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListPacksResponse) GetPacks() []int64 {
+	if x != nil {
+		return x.Packs
+	}
+	return nil
+}
+
+func (x *ListPacksResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ListPacksResponse) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+type PacksSyncRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Packs         []int64                `protobuf:"varint,1,rep,packed,name=packs,proto3" json:"packs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PacksSyncRequest) Reset() {
+	*x = PacksSyncRequest{}
+	mi := &file_packaging_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PacksSyncRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PacksSyncRequest) ProtoMessage() {}
+
+func (x *PacksSyncRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_packaging_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PacksSyncRequest.ProtoReflect.Descriptor instead.
+func (*PacksSyncRequest) Descriptor() ([]byte, []int) {
+	return file_packaging_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PacksSyncRequest) GetPacks() []int64 {
+	if x != nil {
+		return x.Packs
+	}
+	return nil
+}
+
+type PacksSyncResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PacksSyncResponse) Reset() {
+	*x = PacksSyncResponse{}
+	mi := &file_packaging_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PacksSyncResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PacksSyncResponse) ProtoMessage() {}
+
+func (x *PacksSyncResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_packaging_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PacksSyncResponse.ProtoReflect.Descriptor instead.
+func (*PacksSyncResponse) Descriptor() ([]byte, []int) {
+	return file_packaging_proto_rawDescGZIP(), []int{3}
+}
+
+type ProductsPackageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NumberOfItems int64                  `protobuf:"varint,1,opt,name=number_of_items,json=numberOfItems,proto3" json:"number_of_items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProductsPackageRequest) Reset() {
+	*x = ProductsPackageRequest{}
+	mi := &file_packaging_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductsPackageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductsPackageRequest) ProtoMessage() {}
+
+func (x *ProductsPackageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_packaging_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductsPackageRequest.ProtoReflect.Descriptor instead.
+func (*ProductsPackageRequest) Descriptor() ([]byte, []int) {
+	return file_packaging_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ProductsPackageRequest) GetNumberOfItems() int64 {
+	if x != nil {
+		return x.NumberOfItems
+	}
+	return 0
+}
+
+type PackagingLine struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PackSize      int64                  `protobuf:"varint,1,opt,name=pack_size,json=packSize,proto3" json:"pack_size,omitempty"`
+	Quantity      int64                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Items         int64                  `protobuf:"varint,3,opt,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PackagingLine) Reset() {
+	*x = PackagingLine{}
+	mi := &file_packaging_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PackagingLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PackagingLine) ProtoMessage() {}
+
+func (x *PackagingLine) ProtoReflect() protoreflect.Message {
+	mi := &file_packaging_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PackagingLine.ProtoReflect.Descriptor instead.
+func (*PackagingLine) Descriptor() ([]byte, []int) {
+	return file_packaging_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PackagingLine) GetPackSize() int64 {
+	if x != nil {
+		return x.PackSize
+	}
+	return 0
+}
+
+func (x *PackagingLine) GetQuantity() int64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *PackagingLine) GetItems() int64 {
+	if x != nil {
+		return x.Items
+	}
+	return 0
+}
+
+type ProductsPackageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NumberOfItems int64                  `protobuf:"varint,1,opt,name=number_of_items,json=numberOfItems,proto3" json:"number_of_items,omitempty"`
+	Lines         []*PackagingLine       `protobuf:"bytes,2,rep,name=lines,proto3" json:"lines,omitempty"`
+	TotalPacks    int64                  `protobuf:"varint,3,opt,name=total_packs,json=totalPacks,proto3" json:"total_packs,omitempty"`
+	TotalItems    int64                  `protobuf:"varint,4,opt,name=total_items,json=totalItems,proto3" json:"total_items,omitempty"`
+	Overage       int64                  `protobuf:"varint,5,opt,name=overage,proto3" json:"overage,omitempty"`
+	PacksVersion  string                 `protobuf:"bytes,6,opt,name=packs_version,json=packsVersion,proto3" json:"packs_version,omitempty"`
+	Stale         bool                   `protobuf:"varint,7,opt,name=stale,proto3" json:"stale,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProductsPackageResponse) Reset() {
+	*x = ProductsPackageResponse{}
+	mi := &file_packaging_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductsPackageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductsPackageResponse) ProtoMessage() {}
+
+func (x *ProductsPackageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_packaging_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductsPackageResponse.ProtoReflect.Descriptor instead.
+func (*ProductsPackageResponse) Descriptor() ([]byte, []int) {
+	return file_packaging_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ProductsPackageResponse) GetNumberOfItems() int64 {
+	if x != nil {
+		return x.NumberOfItems
+	}
+	return 0
+}
+
+func (x *ProductsPackageResponse) GetLines() []*PackagingLine {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+func (x *ProductsPackageResponse) GetTotalPacks() int64 {
+	if x != nil {
+		return x.TotalPacks
+	}
+	return 0
+}
+
+func (x *ProductsPackageResponse) GetTotalItems() int64 {
+	if x != nil {
+		return x.TotalItems
+	}
+	return 0
+}
+
+func (x *ProductsPackageResponse) GetOverage() int64 {
+	if x != nil {
+		return x.Overage
+	}
+	return 0
+}
+
+func (x *ProductsPackageResponse) GetPacksVersion() string {
+	if x != nil {
+		return x.PacksVersion
+	}
+	return ""
+}
+
+func (x *ProductsPackageResponse) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+var File_packaging_proto protoreflect.FileDescriptor
+
+const file_packaging_proto_rawDesc = "" +
+	"\n" +
+	"\x0fpackaging.proto\x12\fpackaging.v1\"\x12\n" +
+	"\x10ListPacksRequest\"Y\n" +
+	"\x11ListPacksResponse\x12\x14\n" +
+	"\x05packs\x18\x01 \x03(\x03R\x05packs\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12\x14\n" +
+	"\x05stale\x18\x03 \x01(\bR\x05stale\"(\n" +
+	"\x10PacksSyncRequest\x12\x14\n" +
+	"\x05packs\x18\x01 \x03(\x03R\x05packs\"\x13\n" +
+	"\x11PacksSyncResponse\"@\n" +
+	"\x16ProductsPackageRequest\x12&\n" +
+	"\x0fnumber_of_items\x18\x01 \x01(\x03R\rnumberOfItems\"^\n" +
+	"\rPackagingLine\x12\x1b\n" +
+	"\tpack_size\x18\x01 \x01(\x03R\bpackSize\x12\x1a\n" +
+	"\bquantity\x18\x02 \x01(\x03R\bquantity\x12\x14\n" +
+	"\x05items\x18\x03 \x01(\x03R\x05items\"\x8b\x02\n" +
+	"\x17ProductsPackageResponse\x12&\n" +
+	"\x0fnumber_of_items\x18\x01 \x01(\x03R\rnumberOfItems\x121\n" +
+	"\x05lines\x18\x02 \x03(\v2\x1b.packaging.v1.PackagingLineR\x05lines\x12\x1f\n" +
+	"\vtotal_packs\x18\x03 \x01(\x03R\n" +
+	"totalPacks\x12\x1f\n" +
+	"\vtotal_items\x18\x04 \x01(\x03R\n" +
+	"totalItems\x12\x18\n" +
+	"\aoverage\x18\x05 \x01(\x03R\aoverage\x12#\n" +
+	"\rpacks_version\x18\x06 \x01(\tR\fpacksVersion\x12\x14\n" +
+	"\x05stale\x18\a \x01(\bR\x05stale2\x88\x02\n" +
+	"\x10PackagingService\x12L\n" +
+	"\tListPacks\x12\x1e.packaging.v1.ListPacksRequest\x1a\x1f.packaging.v1.ListPacksResponse\x12L\n" +
+	"\tSyncPacks\x12\x1e.packaging.v1.PacksSyncRequest\x1a\x1f.packaging.v1.PacksSyncResponse\x12X\n" +
+	"\tPackItems\x12$.packaging.v1.ProductsPackageRequest\x1a%.packaging.v1.ProductsPackageResponseB\x1cZ\x1aserver/internal/grpcapi/pbb\x06proto3"
+
+var (
+	file_packaging_proto_rawDescOnce sync.Once
+	file_packaging_proto_rawDescData []byte
+)
+
+func file_packaging_proto_rawDescGZIP() []byte {
+	file_packaging_proto_rawDescOnce.Do(func() {
+		file_packaging_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_packaging_proto_rawDesc), len(file_packaging_proto_rawDesc)))
+	})
+	return file_packaging_proto_rawDescData
+}
+
+var file_packaging_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_packaging_proto_goTypes = []any{
+	(*ListPacksRequest)(nil),        // 0: packaging.v1.ListPacksRequest
+	(*ListPacksResponse)(nil),       // 1: packaging.v1.ListPacksResponse
+	(*PacksSyncRequest)(nil),        // 2: packaging.v1.PacksSyncRequest
+	(*PacksSyncResponse)(nil),       // 3: packaging.v1.PacksSyncResponse
+	(*ProductsPackageRequest)(nil),  // 4: packaging.v1.ProductsPackageRequest
+	(*PackagingLine)(nil),           // 5: packaging.v1.PackagingLine
+	(*ProductsPackageResponse)(nil), // 6: packaging.v1.ProductsPackageResponse
+}
+var file_packaging_proto_depIdxs = []int32{
+	5, // 0: packaging.v1.ProductsPackageResponse.lines:type_name -> packaging.v1.PackagingLine
+	0, // 1: packaging.v1.PackagingService.ListPacks:input_type -> packaging.v1.ListPacksRequest
+	2, // 2: packaging.v1.PackagingService.SyncPacks:input_type -> packaging.v1.PacksSyncRequest
+	4, // 3: packaging.v1.PackagingService.PackItems:input_type -> packaging.v1.ProductsPackageRequest
+	1, // 4: packaging.v1.PackagingService.ListPacks:output_type -> packaging.v1.ListPacksResponse
+	3, // 5: packaging.v1.PackagingService.SyncPacks:output_type -> packaging.v1.PacksSyncResponse
+	6, // 6: packaging.v1.PackagingService.PackItems:output_type -> packaging.v1.ProductsPackageResponse
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_packaging_proto_init() }
+func file_packaging_proto_init() {
+	if File_packaging_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_packaging_proto_rawDesc), len(file_packaging_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_packaging_proto_goTypes,
+		DependencyIndexes: file_packaging_proto_depIdxs,
+		MessageInfos:      file_packaging_proto_msgTypes,
+	}.Build()
+	File_packaging_proto = out.File
+	file_packaging_proto_goTypes = nil
+	file_packaging_proto_depIdxs = nil
+}
@@ -0,0 +1,197 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: packaging.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PackagingService_ListPacks_FullMethodName = "/packaging.v1.PackagingService/ListPacks"
+	PackagingService_SyncPacks_FullMethodName = "/packaging.v1.PackagingService/SyncPacks"
+	PackagingService_PackItems_FullMethodName = "/packaging.v1.PackagingService/PackItems"
+)
+
+// PackagingServiceClient is the client API for PackagingService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PackagingServiceClient interface {
+	ListPacks(ctx context.Context, in *ListPacksRequest, opts ...grpc.CallOption) (*ListPacksResponse, error)
+	SyncPacks(ctx context.Context, in *PacksSyncRequest, opts ...grpc.CallOption) (*PacksSyncResponse, error)
+	PackItems(ctx context.Context, in *ProductsPackageRequest, opts ...grpc.CallOption) (*ProductsPackageResponse, error)
+}
+
+type packagingServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPackagingServiceClient(cc grpc.ClientConnInterface) PackagingServiceClient {
+	return &packagingServiceClient{cc}
+}
+
+func (c *packagingServiceClient) ListPacks(ctx context.Context, in *ListPacksRequest, opts ...grpc.CallOption) (*ListPacksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPacksResponse)
+	err := c.cc.Invoke(ctx, PackagingService_ListPacks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packagingServiceClient) SyncPacks(ctx context.Context, in *PacksSyncRequest, opts ...grpc.CallOption) (*PacksSyncResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PacksSyncResponse)
+	err := c.cc.Invoke(ctx, PackagingService_SyncPacks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *packagingServiceClient) PackItems(ctx context.Context, in *ProductsPackageRequest, opts ...grpc.CallOption) (*ProductsPackageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProductsPackageResponse)
+	err := c.cc.Invoke(ctx, PackagingService_PackItems_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PackagingServiceServer is the server API for PackagingService service.
+// All implementations must embed UnimplementedPackagingServiceServer
+// for forward compatibility.
+type PackagingServiceServer interface {
+	ListPacks(context.Context, *ListPacksRequest) (*ListPacksResponse, error)
+	SyncPacks(context.Context, *PacksSyncRequest) (*PacksSyncResponse, error)
+	PackItems(context.Context, *ProductsPackageRequest) (*ProductsPackageResponse, error)
+	mustEmbedUnimplementedPackagingServiceServer()
+}
+
+// UnimplementedPackagingServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPackagingServiceServer struct{}
+
+func (UnimplementedPackagingServiceServer) ListPacks(context.Context, *ListPacksRequest) (*ListPacksResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListPacks not implemented")
+}
+func (UnimplementedPackagingServiceServer) SyncPacks(context.Context, *PacksSyncRequest) (*PacksSyncResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SyncPacks not implemented")
+}
+func (UnimplementedPackagingServiceServer) PackItems(context.Context, *ProductsPackageRequest) (*ProductsPackageResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method PackItems not implemented")
+}
+func (UnimplementedPackagingServiceServer) mustEmbedUnimplementedPackagingServiceServer() {}
+func (UnimplementedPackagingServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafePackagingServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PackagingServiceServer will
+// result in compilation errors.
+type UnsafePackagingServiceServer interface {
+	mustEmbedUnimplementedPackagingServiceServer()
+}
+
+func RegisterPackagingServiceServer(s grpc.ServiceRegistrar, srv PackagingServiceServer) {
+	// If the following call panics, it indicates UnimplementedPackagingServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PackagingService_ServiceDesc, srv)
+}
+
+func _PackagingService_ListPacks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPacksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackagingServiceServer).ListPacks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PackagingService_ListPacks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackagingServiceServer).ListPacks(ctx, req.(*ListPacksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackagingService_SyncPacks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PacksSyncRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackagingServiceServer).SyncPacks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PackagingService_SyncPacks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackagingServiceServer).SyncPacks(ctx, req.(*PacksSyncRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PackagingService_PackItems_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProductsPackageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PackagingServiceServer).PackItems(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PackagingService_PackItems_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PackagingServiceServer).PackItems(ctx, req.(*ProductsPackageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PackagingService_ServiceDesc is the grpc.ServiceDesc for PackagingService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PackagingService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "packaging.v1.PackagingService",
+	HandlerType: (*PackagingServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListPacks",
+			Handler:    _PackagingService_ListPacks_Handler,
+		},
+		{
+			MethodName: "SyncPacks",
+			Handler:    _PackagingService_SyncPacks_Handler,
+		},
+		{
+			MethodName: "PackItems",
+			Handler:    _PackagingService_PackItems_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "packaging.proto",
+}
@@ -0,0 +1,90 @@
+package grpcapi
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"net"
+	"server/internal/auth"
+	"server/internal/model"
+	"server/internal/service"
+)
+
+// rateLimitRoutePrefix makes the route of a method, e.g. "GRPC /packaging.v1.PackagingService/PackItems",
+// so it can be given its own limit in the rate limits file like the REST routes.
+const rateLimitRoutePrefix = "GRPC "
+
+// ipRateLimitInterceptor limits the calls of each client IP, like the RateLimitByIP middleware of the REST API.
+// It runs before the authentication, so that the calls with rejected credentials are limited too.
+func ipRateLimitInterceptor(rateLimitService service.RateLimitService) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if _, ok := methodRoles[info.FullMethod]; !ok {
+			return handler(ctx, req)
+		}
+
+		decision, err := rateLimitService.TakeForIP(ctx, peerIPOf(ctx))
+		if err := rateLimitError(ctx, decision, err); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitInterceptor limits the calls of each client to the method, identifying the client by its principal
+// when authenticated, or its IP otherwise, like the RateLimit middleware of the REST API.
+func rateLimitInterceptor(rateLimitService service.RateLimitService) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if _, ok := methodRoles[info.FullMethod]; !ok {
+			return handler(ctx, req)
+		}
+
+		client := "ip:" + peerIPOf(ctx)
+		if principal, ok := ctx.Value(principalContextKey{}).(*auth.Principal); ok {
+			client = "principal:" + principal.Name
+		}
+		decision, err := rateLimitService.Take(ctx, rateLimitRoutePrefix+info.FullMethod, client)
+		if err := rateLimitError(ctx, decision, err); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitError returns the ResourceExhausted error of a call over the limit, nil when the call is allowed,
+// not limited, or the limits can not be checked.
+func rateLimitError(ctx context.Context, decision *model.RateLimitDecision, err error) error {
+	if err != nil {
+		slog.ErrorContext(ctx, "Error checking rate limit, letting the call through", "error", err)
+		return nil
+	}
+	if decision == nil || decision.Allowed {
+		return nil
+	}
+
+	if decision.QuotaExceeded {
+		return status.Error(codes.ResourceExhausted, "daily quota exceeded")
+	}
+	return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+}
+
+func peerIPOf(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
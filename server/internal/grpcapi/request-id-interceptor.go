@@ -0,0 +1,29 @@
+package grpcapi
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"server/internal/logging"
+)
+
+const requestIDMetadata = "x-request-id"
+
+// requestIDInterceptor gives every call an ID, the one of its x-request-id metadata or else a new one,
+// which is sent back in the x-request-id header and added to the logs of the call, like the REST API does.
+func requestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		var requestID string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(requestIDMetadata); len(values) > 0 {
+				requestID = values[0]
+			}
+		}
+		requestID = logging.RequestIDOrNew(requestID)
+
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, requestID))
+		return handler(logging.WithRequestID(ctx, requestID), req)
+	}
+}
@@ -0,0 +1,170 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// Levels are the min levels of the logs, by default and by package. A package is named after the last element
+// of its path, e.g. "service" for server/internal/service, and "main" for the main package.
+type Levels struct {
+	Default  slog.Level
+	Packages map[string]slog.Level
+}
+
+// maxRequestIDLength bounds the request IDs of the clients, which end up in every log line of their requests
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+var levels atomic.Pointer[Levels]
+
+func init() {
+	levels.Store(&Levels{Default: slog.LevelInfo})
+}
+
+// Setup logs as JSON to out with the levels, including the stdlib log output.
+func Setup(out io.Writer, initial Levels) {
+	SetLevels(initial)
+	// the levels are applied by the handler, so the JSON handler takes all the records
+	jsonHandler := slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.Level(math.MinInt)})
+	slog.SetDefault(slog.New(NewHandler(jsonHandler)))
+}
+
+// NewHandler filters the records of next by the levels, and adds the request ID of their context.
+func NewHandler(next slog.Handler) slog.Handler {
+	return &contextHandler{next: next}
+}
+
+// SetLevels changes the levels at runtime.
+func SetLevels(newLevels Levels) {
+	newLevels.Packages = maps.Clone(newLevels.Packages)
+	levels.Store(&newLevels)
+}
+
+// CurrentLevels returns the levels in use.
+func CurrentLevels() Levels {
+	current := *levels.Load()
+	current.Packages = maps.Clone(current.Packages)
+	return current
+}
+
+// ParseLevels parses a level, e.g. "info", and the package levels, e.g. "service=debug".
+func ParseLevels(level string, packages []string) (Levels, error) {
+	parsed := Levels{Packages: make(map[string]slog.Level)}
+	if err := parsed.Default.UnmarshalText([]byte(level)); err != nil {
+		return Levels{}, fmt.Errorf("invalid log level %q, must be debug, info, warn or error", level)
+	}
+
+	for _, packageLevel := range packages {
+		name, value, found := strings.Cut(packageLevel, "=")
+		var level slog.Level
+		if !found || name == "" || level.UnmarshalText([]byte(value)) != nil {
+			return Levels{}, fmt.Errorf("invalid package log level %q, must be like service=debug", packageLevel)
+		}
+		parsed.Packages[name] = level
+	}
+
+	return parsed, nil
+}
+
+// WithRequestID returns a context whose logs have the request ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDOrNew returns the request ID of a client when it can be used, i.e. it is printable ASCII without spaces,
+// or else a new one.
+func RequestIDOrNew(requestID string) string {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return uuid.NewString()
+	}
+	for _, char := range requestID {
+		if char <= ' ' || char > '~' {
+			return uuid.NewString()
+		}
+	}
+
+	return requestID
+}
+
+// RequestID returns the request ID of the context, empty when there is none.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// Fatal logs the error and exits, for the errors the app can not start with.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+type contextHandler struct {
+	next slog.Handler
+}
+
+func (handler *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	current := levels.Load()
+	if level >= current.Default {
+		return handler.next.Enabled(ctx, level)
+	}
+	// a package may log below the default level
+	for _, packageLevel := range current.Packages {
+		if level >= packageLevel {
+			return handler.next.Enabled(ctx, level)
+		}
+	}
+
+	return false
+}
+
+func (handler *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	current := levels.Load()
+	if len(current.Packages) > 0 {
+		minLevel, found := current.Packages[packageOf(record.PC)]
+		if !found {
+			minLevel = current.Default
+		}
+		if record.Level < minLevel {
+			return nil
+		}
+	}
+
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+
+	return handler.next.Handle(ctx, record)
+}
+
+func (handler *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{next: handler.next.WithAttrs(attrs)}
+}
+
+func (handler *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: handler.next.WithGroup(name)}
+}
+
+// packageOf is the name of the package of the function at pc, e.g. "service"
+// for server/internal/service.(*PacksServiceImpl).SyncPacks.
+func packageOf(pc uintptr) string {
+	if pc == 0 {
+		return ""
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	function := frame.Function
+	function = function[strings.LastIndex(function, "/")+1:]
+	name, _, _ := strings.Cut(function, ".")
+	return name
+}
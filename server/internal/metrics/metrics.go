@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"time"
+)
+
+// Registry holds the metrics of the app, along with the Go runtime and the process metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HttpRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by method, route and status.",
+		}, []string{"method", "route", "status"},
+	)
+	HttpRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Latency of the HTTP requests by method, route and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"},
+	)
+
+	PackagingSolveDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "packaging_solve_duration_seconds",
+			Help:    "Latency of the packaging calculations, without loading the pack sizes.",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+		}, []string{"outcome"},
+	)
+	// PackagingTableSize is the number of entries of the DP table, i.e. what the memory and the time of a solve grow with
+	PackagingTableSize = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "packaging_dp_table_size",
+			Help:    "Entries of the DP table of the packaging calculations.",
+			Buckets: prometheus.ExponentialBuckets(100, 10, 7),
+		},
+	)
+	// PackagingSolves tells how many solves packed most of the items in the largest pack right away
+	PackagingSolves = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "packaging_solves_total",
+			Help: "Packaging calculations, by whether the bulk of the items went to the largest pack before the DP.",
+		}, []string{"bulk_shortcut"},
+	)
+	PackagingOverage = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "packaging_overage_items",
+			Help:    "Items shipped beyond the ordered ones by the packaging calculations.",
+			Buckets: []float64{0, 1, 10, 50, 100, 250, 500, 1000, 5000},
+		},
+	)
+
+	PacksSyncs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "packs_syncs_total",
+			Help: "Syncs of the pack sizes by outcome.",
+		}, []string{"outcome"},
+	)
+
+	DbQueryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Latency of the DB queries of the packs repository, each retry counted, by operation and outcome.",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 4, 9),
+		}, []string{"operation", "outcome"},
+	)
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HttpRequests, HttpRequestDuration,
+		PackagingSolveDuration, PackagingTableSize, PackagingSolves, PackagingOverage,
+		PacksSyncs,
+		DbQueryDuration,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Outcome is the outcome label of an operation that failed with err.
+func Outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+
+	return "success"
+}
+
+// Since is the number of seconds elapsed since start, as observed by the histograms.
+func Since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
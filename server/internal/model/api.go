@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type PacksSyncRequest struct {
 	Packs []int `json:"packs" binding:"required"`
 }
@@ -9,3 +11,153 @@ type ProductsPackageRequest struct {
 }
 
 type ProductPackageResponse map[int]int
+
+type PackagingHistoryRequest struct {
+	From        time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To          time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	MinQuantity int       `form:"minQuantity" binding:"omitempty,min=1"`
+	MaxQuantity int       `form:"maxQuantity" binding:"omitempty,min=1"`
+	Caller      string    `form:"caller"`
+	Page        int       `form:"page" binding:"omitempty,min=1"`
+	PageSize    int       `form:"pageSize" binding:"omitempty,min=1,max=100"`
+}
+
+type PackagingHistoryEntry struct {
+	ID            uint64      `json:"id"`
+	NumberOfItems int         `json:"numberOfItems"`
+	Packs         map[int]int `json:"packs"`
+	PackSizes     []int       `json:"packSizes"`
+	Objective     string      `json:"objective"`
+	PacksVersion  string      `json:"packsVersion"`
+	Caller        string      `json:"caller"`
+	CreatedAt     time.Time   `json:"createdAt"`
+}
+
+type PackagingHistoryResponse struct {
+	Items    []PackagingHistoryEntry `json:"items"`
+	Page     int                     `json:"page"`
+	PageSize int                     `json:"pageSize"`
+	Total    int64                   `json:"total"`
+}
+
+type PackagingLine struct {
+	PackSize int `json:"packSize"`
+	Quantity int `json:"quantity"`
+	Items    int `json:"items"`
+}
+
+type ProductPackageResponseV2 struct {
+	NumberOfItems int             `json:"numberOfItems"`
+	Lines         []PackagingLine `json:"lines"`
+	TotalPacks    int             `json:"totalPacks"`
+	TotalItems    int             `json:"totalItems"`
+	Overage       int             `json:"overage"`
+	PacksVersion  string          `json:"packsVersion"`
+	Stale         bool            `json:"stale"`
+}
+
+type PacksResponseV2 struct {
+	Packs   []int  `json:"packs"`
+	Version string `json:"version"`
+	Stale   bool   `json:"stale"`
+}
+
+type PackagingJobResponse struct {
+	ID             string     `json:"id"`
+	Status         string     `json:"status"`
+	TotalLines     int        `json:"totalLines"`
+	ProcessedLines int        `json:"processedLines"`
+	FailedLines    int        `json:"failedLines"`
+	PacksVersion   string     `json:"packsVersion,omitempty"`
+	Progress       float64    `json:"progress"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	StartedAt      *time.Time `json:"startedAt,omitempty"`
+	CompletedAt    *time.Time `json:"completedAt,omitempty"`
+}
+
+// PackagingJobResult is the result of a line of a packaging job, as returned in the NDJSON results.
+type PackagingJobResult struct {
+	OrderID       string          `json:"orderId"`
+	NumberOfItems int             `json:"numberOfItems"`
+	Lines         []PackagingLine `json:"lines"`
+	TotalPacks    int             `json:"totalPacks"`
+	TotalItems    int             `json:"totalItems"`
+	Overage       int             `json:"overage"`
+	PacksVersion  string          `json:"packsVersion"`
+	Error         string          `json:"error,omitempty"`
+}
+
+// LogLevels are the min levels of the logs, by default and by package, e.g. {"service": "debug"}.
+type LogLevels struct {
+	Level    string            `json:"level" binding:"required"`
+	Packages map[string]string `json:"packages"`
+}
+
+// BuildInfo identifies the build of the app.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	GoVersion string `json:"goVersion"`
+}
+
+type OrderRequest struct {
+	CustomerReference string `json:"customerReference" binding:"required,max=255"`
+	Quantity          int    `json:"quantity" binding:"required,min=1"`
+}
+
+type OrderTransitionRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+type OrderResponse struct {
+	ID                string          `json:"id"`
+	CustomerReference string          `json:"customerReference"`
+	Quantity          int             `json:"quantity"`
+	Status            string          `json:"status"`
+	Lines             []PackagingLine `json:"lines"`
+	TotalPacks        int             `json:"totalPacks"`
+	TotalItems        int             `json:"totalItems"`
+	Overage           int             `json:"overage"`
+	PacksVersion      string          `json:"packsVersion"`
+	CreatedAt         time.Time       `json:"createdAt"`
+	UpdatedAt         time.Time       `json:"updatedAt"`
+	QuotedAt          *time.Time      `json:"quotedAt,omitempty"`
+	ConfirmedAt       *time.Time      `json:"confirmedAt,omitempty"`
+	PackedAt          *time.Time      `json:"packedAt,omitempty"`
+	ShippedAt         *time.Time      `json:"shippedAt,omitempty"`
+	CancelledAt       *time.Time      `json:"cancelledAt,omitempty"`
+}
+
+type QuoteRequest struct {
+	NumberOfItems int `json:"numberOfItems" binding:"required,min=1"`
+}
+
+// QuoteResponse is a quote, the same every time it is returned. Token is only set when the quote tokens are enabled.
+type QuoteResponse struct {
+	ID            string          `json:"id"`
+	NumberOfItems int             `json:"numberOfItems"`
+	Lines         []PackagingLine `json:"lines"`
+	TotalPacks    int             `json:"totalPacks"`
+	TotalItems    int             `json:"totalItems"`
+	Overage       int             `json:"overage"`
+	PackSizes     []int           `json:"packSizes"`
+	PacksVersion  string          `json:"packsVersion"`
+	Objective     string          `json:"objective"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	ExpiresAt     time.Time       `json:"expiresAt"`
+	Token         string          `json:"token,omitempty"`
+}
+
+type QuoteTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// QuoteClaims are what a quote token vouches for.
+type QuoteClaims struct {
+	ID            string      `json:"id"`
+	NumberOfItems int         `json:"numberOfItems"`
+	Packs         map[int]int `json:"packs"`
+	PacksVersion  string      `json:"packsVersion"`
+	ExpiresAt     time.Time   `json:"expiresAt"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// CorsConfig is the CORS policy of the API. Without AllowedOrigins, cross-origin requests are not allowed.
+// An origin can be "*" for any origin, or have a single "*" wildcard, e.g. "https://*.example.com".
+type CorsConfig struct {
+	AllowedOrigins   []string      `yaml:"allowedOrigins"`
+	AllowedMethods   []string      `yaml:"allowedMethods"`
+	AllowedHeaders   []string      `yaml:"allowedHeaders"`
+	AllowCredentials bool          `yaml:"allowCredentials"`
+	MaxAge           time.Duration `yaml:"maxAge"`
+}
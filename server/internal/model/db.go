@@ -1,5 +1,109 @@
 package model
 
+import "time"
+
 type Pack struct {
 	Size int `gorm:"primaryKey;autoIncrement:false"`
 }
+
+type PackagingResult struct {
+	ID            uint64      `gorm:"primaryKey"`
+	NumberOfItems int         `gorm:"not null"`
+	Packs         map[int]int `gorm:"serializer:json;not null"`
+	PackSizes     []int       `gorm:"serializer:json;not null"`
+	Objective     string      `gorm:"not null"`
+	PacksVersion  string      `gorm:"not null"`
+	Caller        string      `gorm:"not null"`
+	CreatedAt     time.Time   `gorm:"not null"`
+}
+
+// IdempotencyRecord is the outcome of the first request sent with an Idempotency-Key,
+// replayed for the repeated requests with the same key. It is not Completed while that request is in progress.
+type IdempotencyRecord struct {
+	Scope              string `gorm:"primaryKey"`
+	Key                string `gorm:"primaryKey"`
+	RequestHash        string `gorm:"not null"`
+	Completed          bool   `gorm:"not null"`
+	IdempotentResponse `gorm:"embedded"`
+	CreatedAt          time.Time `gorm:"not null"`
+	ExpiresAt          time.Time `gorm:"not null"`
+}
+
+type IdempotentResponse struct {
+	StatusCode int               `gorm:"not null"`
+	Headers    map[string]string `gorm:"serializer:json;not null"`
+	Body       []byte
+}
+
+// RateLimitBucket is the state of a client's token bucket and daily quota for a route.
+type RateLimitBucket struct {
+	Key       string    `gorm:"primaryKey"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null;autoUpdateTime:false"`
+	QuotaDay  string    `gorm:"not null"`
+	QuotaUsed int       `gorm:"not null"`
+}
+
+// PackagingJob is a bulk packaging job, whose lines are processed in the background by the job workers.
+// A running job is held by a worker for as long as its HeartbeatAt is renewed.
+// Its PackSizes and PacksVersion are the packs configuration all its lines are packed with, nil until it is
+// first processed.
+type PackagingJob struct {
+	ID             string    `gorm:"primaryKey"`
+	Status         string    `gorm:"not null"`
+	TotalLines     int       `gorm:"not null"`
+	ProcessedLines int       `gorm:"not null"`
+	FailedLines    int       `gorm:"not null"`
+	Error          string    `gorm:"not null"`
+	PackSizes      []int     `gorm:"serializer:json"`
+	PacksVersion   string    `gorm:"not null"`
+	Caller         string    `gorm:"not null"`
+	CreatedAt      time.Time `gorm:"not null"`
+	StartedAt      *time.Time
+	CompletedAt    *time.Time
+	HeartbeatAt    *time.Time
+}
+
+// PackagingJobLine is an order of a packaging job, and its packs once Processed.
+type PackagingJobLine struct {
+	JobID         string      `gorm:"primaryKey"`
+	LineNumber    int         `gorm:"primaryKey;autoIncrement:false"`
+	OrderID       string      `gorm:"not null"`
+	NumberOfItems int         `gorm:"not null"`
+	Processed     bool        `gorm:"not null"`
+	Packs         map[int]int `gorm:"serializer:json"`
+	PacksVersion  string      `gorm:"not null"`
+	Error         string      `gorm:"not null"`
+}
+
+// Order is an order of a customer, with the packs assigned to it. It is quoted when created, then goes through
+// the other statuses, the time it entered each of them being recorded.
+type Order struct {
+	ID                string      `gorm:"primaryKey"`
+	CustomerReference string      `gorm:"not null"`
+	NumberOfItems     int         `gorm:"not null"`
+	Packs             map[int]int `gorm:"serializer:json;not null"`
+	PacksVersion      string      `gorm:"not null"`
+	Status            string      `gorm:"not null"`
+	Caller            string      `gorm:"not null"`
+	CreatedAt         time.Time   `gorm:"not null"`
+	UpdatedAt         time.Time   `gorm:"not null;autoUpdateTime:false"`
+	QuotedAt          *time.Time
+	ConfirmedAt       *time.Time
+	PackedAt          *time.Time
+	ShippedAt         *time.Time
+	CancelledAt       *time.Time
+}
+
+// Quote is a packaging calculation kept until it expires, with the pack sizes configuration it was computed with.
+type Quote struct {
+	ID            string      `gorm:"primaryKey"`
+	NumberOfItems int         `gorm:"not null"`
+	Packs         map[int]int `gorm:"serializer:json;not null"`
+	PackSizes     []int       `gorm:"serializer:json;not null"`
+	PacksVersion  string      `gorm:"not null"`
+	Objective     string      `gorm:"not null"`
+	Caller        string      `gorm:"not null"`
+	CreatedAt     time.Time   `gorm:"not null"`
+	ExpiresAt     time.Time   `gorm:"not null"`
+}
@@ -1,8 +1,174 @@
 package model
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 type EmptyPacksConfig struct {
 }
 
 func (e *EmptyPacksConfig) Error() string {
 	return "no packs configured"
 }
+
+type InvalidPacksConfig struct {
+	Reason string
+}
+
+func (e *InvalidPacksConfig) Error() string {
+	return fmt.Sprintf("invalid packs configuration: %s", e.Reason)
+}
+
+type ReadOnlyPacksConfig struct {
+}
+
+func (e *ReadOnlyPacksConfig) Error() string {
+	return "packs configuration is read-only"
+}
+
+type PacksStorageUnavailable struct {
+	Cause error
+}
+
+func (e *PacksStorageUnavailable) Error() string {
+	return "packs storage is unavailable"
+}
+
+func (e *PacksStorageUnavailable) Unwrap() error {
+	return e.Cause
+}
+
+type IdempotencyKeyMismatch struct {
+}
+
+func (e *IdempotencyKeyMismatch) Error() string {
+	return "Idempotency-Key was already used with a different request"
+}
+
+type IdempotencyKeyInProgress struct {
+}
+
+func (e *IdempotencyKeyInProgress) Error() string {
+	return "a request with the same Idempotency-Key is still in progress"
+}
+
+type InvalidCredentials struct {
+	Reason string
+}
+
+func (e *InvalidCredentials) Error() string {
+	return fmt.Sprintf("invalid credentials: %s", e.Reason)
+}
+
+type PackagingJobNotFound struct {
+	ID string
+}
+
+func (e *PackagingJobNotFound) Error() string {
+	return fmt.Sprintf("packaging job %s not found", e.ID)
+}
+
+type PackagingJobNotCompleted struct {
+	ID     string
+	Status string
+}
+
+func (e *PackagingJobNotCompleted) Error() string {
+	return fmt.Sprintf("packaging job %s is %s, its results are available once completed", e.ID, e.Status)
+}
+
+type InvalidPackagingJob struct {
+	Reason string
+}
+
+func (e *InvalidPackagingJob) Error() string {
+	return fmt.Sprintf("invalid packaging job: %s", e.Reason)
+}
+
+type InvalidHistoryFilter struct {
+	Reason string
+}
+
+func (e *InvalidHistoryFilter) Error() string {
+	return fmt.Sprintf("invalid history filter: %s", e.Reason)
+}
+
+// ServerError is an error response of the server, to a CLI command run over HTTP.
+type ServerError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("the server answered %d: %s", e.StatusCode, e.Message)
+}
+
+// InvalidConfig reports all the problems of the configuration at once.
+type InvalidConfig struct {
+	Problems []string
+}
+
+func (e *InvalidConfig) Error() string {
+	return fmt.Sprintf("invalid configuration:\n  %s", strings.Join(e.Problems, "\n  "))
+}
+
+type OrderNotFound struct {
+	ID string
+}
+
+func (e *OrderNotFound) Error() string {
+	return fmt.Sprintf("order %s not found", e.ID)
+}
+
+type InvalidOrderStatus struct {
+	Status string
+}
+
+func (e *InvalidOrderStatus) Error() string {
+	return fmt.Sprintf("invalid order status %q, must be one of %v", e.Status, OrderStatuses)
+}
+
+// IllegalOrderTransition is the change of an order to a status it can not go to from its current one.
+type IllegalOrderTransition struct {
+	ID   string
+	From string
+	To   string
+}
+
+func (e *IllegalOrderTransition) Error() string {
+	return fmt.Sprintf("order %s can not go from %s to %s", e.ID, e.From, e.To)
+}
+
+type QuoteNotFound struct {
+	ID string
+}
+
+func (e *QuoteNotFound) Error() string {
+	return fmt.Sprintf("quote %s not found", e.ID)
+}
+
+type QuoteExpired struct {
+	ID        string
+	ExpiresAt time.Time
+}
+
+func (e *QuoteExpired) Error() string {
+	return fmt.Sprintf("quote %s expired at %s", e.ID, e.ExpiresAt.Format(time.RFC3339))
+}
+
+type InvalidQuoteToken struct {
+	Reason string
+}
+
+func (e *InvalidQuoteToken) Error() string {
+	return fmt.Sprintf("invalid quote token: %s", e.Reason)
+}
+
+type QuoteTokensDisabled struct {
+}
+
+func (e *QuoteTokensDisabled) Error() string {
+	return "quote tokens are not enabled"
+}
@@ -0,0 +1,17 @@
+package model
+
+// PacksFile is the structure of the file used by the file-backed packs repository.
+// The same structure is used for both YAML and JSON files.
+type PacksFile struct {
+	Packs []int `json:"packs" yaml:"packs"`
+}
+
+// RateLimitsFile is the structure of the rate limits configuration file.
+// Routes are keyed by the method and the path of the route, e.g. "POST /api/package",
+// and the routes not listed get the Default limit, or are not limited when there is none.
+// IP is the limit of each client IP on all the routes together, checked before the authentication.
+type RateLimitsFile struct {
+	Default *RateLimit           `json:"default" yaml:"default"`
+	Routes  map[string]RateLimit `json:"routes" yaml:"routes"`
+	IP      *RateLimit           `json:"ip" yaml:"ip"`
+}
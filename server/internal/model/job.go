@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
+
+// PackagingJobItem is an order to pack in a packaging job.
+type PackagingJobItem struct {
+	OrderID       string
+	NumberOfItems int
+}
+
+// PackagingJobsConfig configures how the packaging jobs are processed.
+type PackagingJobsConfig struct {
+	// Workers is the max number of jobs processed at once
+	Workers int `yaml:"workers"`
+	// BatchSize is the number of lines processed between two saves of the progress
+	BatchSize int `yaml:"batchSize"`
+	// MaxLines is the max number of lines of a job
+	MaxLines int `yaml:"maxLines"`
+	// LeaseTimeout is how long a running job can go without progress before it is considered abandoned
+	// (e.g. by a crashed replica) and taken over by another worker
+	LeaseTimeout time.Duration `yaml:"leaseTimeout"`
+	// PollInterval is how often the idle workers look for jobs submitted to the other replicas
+	PollInterval time.Duration `yaml:"pollInterval"`
+	// Retention is how long the finished jobs and their results are kept
+	Retention time.Duration `yaml:"retention"`
+}
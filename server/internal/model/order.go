@@ -0,0 +1,14 @@
+package model
+
+const (
+	OrderStatusQuoted    = "quoted"
+	OrderStatusConfirmed = "confirmed"
+	OrderStatusPacked    = "packed"
+	OrderStatusShipped   = "shipped"
+	OrderStatusCancelled = "cancelled"
+)
+
+// OrderStatuses are all the statuses of an order, in the order it goes through them.
+var OrderStatuses = []string{
+	OrderStatusQuoted, OrderStatusConfirmed, OrderStatusPacked, OrderStatusShipped, OrderStatusCancelled,
+}
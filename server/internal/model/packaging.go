@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// DefaultLargePackItemsBuffer is how many of the largest packs are left to the DP calculation of a large number of
+// items, the others being packed in the largest pack right away
+const DefaultLargePackItemsBuffer = 50
+
+// PacksConfig is the pack sizes configuration in use, together with a version that identifies it.
+// Stale is set when the packs storage is unavailable and the last known configuration is used instead.
+type PacksConfig struct {
+	Sizes   []int
+	Version string
+	Stale   bool
+}
+
+// PackagingCalculation is the outcome of a packaging calculation,
+// with everything needed to reconstruct how the result was computed.
+type PackagingCalculation struct {
+	NumberOfItems int
+	Packs         map[int]int
+	PackSizes     []int
+	PacksVersion  string
+	Objective     string
+	Stale         bool
+}
+
+type PackagingHistoryFilter struct {
+	From        time.Time
+	To          time.Time
+	MinQuantity int
+	MaxQuantity int
+	Caller      string
+	Offset      int
+	Limit       int
+}
+
+// PacksCacheStats are the statistics of the last known packs configuration, kept in memory
+// to be served while the packs storage is unavailable.
+type PacksCacheStats struct {
+	// Cached is whether a packs configuration is cached, the other fields being empty otherwise
+	Cached   bool      `json:"cached"`
+	Sizes    []int     `json:"sizes"`
+	Version  string    `json:"version"`
+	StoredAt time.Time `json:"storedAt"`
+	// Updates is how many times the cached configuration changed
+	Updates int64 `json:"updates"`
+	// Hits is how many times the cached configuration was served while the storage was unavailable
+	Hits int64 `json:"hits"`
+	// Misses is how many times the storage was unavailable without a cached configuration to serve
+	Misses int64 `json:"misses"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// RateLimit is a token bucket holding up to Burst requests, refilled with RequestsPerSecond.
+// When DailyQuota is set, a client can make at most that many requests per UTC day.
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond" yaml:"requestsPerSecond"`
+	Burst             int     `json:"burst" yaml:"burst"`
+	DailyQuota        int     `json:"dailyQuota" yaml:"dailyQuota"`
+}
+
+// RateLimitDecision is the outcome of taking a request from a client's bucket. Limit, Remaining and Reset
+// describe the limit closest to being exhausted, the token bucket or the daily quota.
+type RateLimitDecision struct {
+	Allowed       bool
+	QuotaExceeded bool
+	Limit         int
+	Remaining     int
+	Reset         time.Duration
+	RetryAfter    time.Duration
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// HttpServerConfig configures the HTTP server. A zero timeout means no timeout.
+type HttpServerConfig struct {
+	Address           string        `yaml:"address"`
+	ReadTimeout       time.Duration `yaml:"readTimeout"`
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout"`
+	WriteTimeout      time.Duration `yaml:"writeTimeout"`
+	IdleTimeout       time.Duration `yaml:"idleTimeout"`
+	MaxHeaderBytes    int           `yaml:"maxHeaderBytes"`
+	// ShutdownDelay is how long the server keeps serving after being asked to shut down, while reporting
+	// not ready, so that the load balancer stops routing new requests to it before it stops accepting them
+	ShutdownDelay time.Duration `yaml:"shutdownDelay"`
+	// ShutdownTimeout is how long the in-flight requests have to complete on shutdown
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	// TrustedProxies are the IPs or CIDRs of the proxies whose X-Forwarded-For header gives the client IP.
+	// Without any, the client IP is the address of the connection.
+	TrustedProxies []string `yaml:"trustedProxies"`
+}
+
+const (
+	ClientAuthNone     = "none"
+	ClientAuthOptional = "optional"
+	ClientAuthRequire  = "require"
+)
+
+// TlsConfig configures the TLS of the servers. TLS is disabled without a CertFile.
+type TlsConfig struct {
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	// ClientCAFile holds the CAs the client certificates are verified with, for mutual TLS
+	ClientCAFile string `yaml:"clientCAFile"`
+	// ClientAuth is whether the clients must send a certificate: none, optional or require.
+	// It defaults to require when ClientCAFile is set, and to none otherwise
+	ClientAuth string `yaml:"clientAuth"`
+	// ReloadInterval is how often the files are checked for changes
+	ReloadInterval time.Duration `yaml:"reloadInterval"`
+}
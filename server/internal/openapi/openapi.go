@@ -0,0 +1,27 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Spec is the OpenAPI specification of the REST API, as YAML.
+//
+//go:embed openapi.yaml
+var Spec []byte
+
+// Load parses and validates the embedded OpenAPI specification.
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
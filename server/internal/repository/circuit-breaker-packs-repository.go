@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"server/internal/model"
+	"sync"
+	"time"
+)
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreakerPacksRepositoryImpl protects the packs storage from being called while it is failing.
+// After FailureThreshold consecutive transient errors the circuit opens and the calls fail fast with
+// model.PacksStorageUnavailable. After OpenTimeout, a single trial call is let through;
+// when it succeeds the circuit closes again, otherwise it stays open for another OpenTimeout.
+// A call taking longer than CallTimeout counts as a failure, so a hung storage opens the circuit too.
+type CircuitBreakerPacksRepositoryImpl struct {
+	repository       PacksRepository
+	failureThreshold int
+	openTimeout      time.Duration
+	callTimeout      time.Duration
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+func NewCircuitBreakerPacksRepository(
+	repository PacksRepository, failureThreshold int, openTimeout time.Duration, callTimeout time.Duration,
+) PacksRepository {
+	return &CircuitBreakerPacksRepositoryImpl{
+		repository:       repository,
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		callTimeout:      callTimeout,
+	}
+}
+
+func (repo *CircuitBreakerPacksRepositoryImpl) FindAll(ctx context.Context) ([]model.Pack, error) {
+	var packs []model.Pack
+	err := repo.call(
+		ctx, func(callCtx context.Context) error {
+			var err error
+			packs, err = repo.repository.FindAll(callCtx)
+			return err
+		},
+	)
+	return packs, err
+}
+
+func (repo *CircuitBreakerPacksRepositoryImpl) SyncPacks(ctx context.Context, packs []int) error {
+	return repo.call(ctx, func(callCtx context.Context) error { return repo.repository.SyncPacks(callCtx, packs) })
+}
+
+func (repo *CircuitBreakerPacksRepositoryImpl) call(
+	ctx context.Context, operation func(callCtx context.Context) error,
+) error {
+	if !repo.allow() {
+		return &model.PacksStorageUnavailable{}
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, repo.callTimeout)
+	defer cancel()
+
+	err := operation(callCtx)
+	// the call timing out while the caller could still wait means the storage is hung
+	if err != nil && callCtx.Err() != nil && ctx.Err() == nil {
+		err = &model.PacksStorageUnavailable{Cause: err}
+	}
+	repo.record(ctx, err)
+
+	return err
+}
+
+func (repo *CircuitBreakerPacksRepositoryImpl) allow() bool {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	switch repo.state {
+	case circuitOpen:
+		if time.Since(repo.openedAt) < repo.openTimeout {
+			return false
+		}
+		repo.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		// only the trial call is let through while half-open
+		return false
+	default:
+		return true
+	}
+}
+
+func (repo *CircuitBreakerPacksRepositoryImpl) record(ctx context.Context, err error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var unavailableError *model.PacksStorageUnavailable
+	if errors.As(err, &unavailableError) {
+		repo.recordFailure(ctx, err)
+		return
+	}
+
+	// a call cancelled or timed out by its caller says nothing about the storage health,
+	// so a trial call ending this way lets the next call be the trial instead
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		if repo.state == circuitHalfOpen {
+			repo.state = circuitOpen
+		}
+		return
+	}
+
+	// errors that are not caused by the storage being down (e.g. invalid data) do not count as failures
+	if !IsTransientError(err) {
+		if repo.state != circuitClosed {
+			slog.InfoContext(ctx, "Packs storage recovered, closing circuit")
+		}
+		repo.state = circuitClosed
+		repo.failures = 0
+		return
+	}
+
+	repo.recordFailure(ctx, err)
+}
+
+func (repo *CircuitBreakerPacksRepositoryImpl) recordFailure(ctx context.Context, err error) {
+	repo.failures++
+	if repo.state == circuitHalfOpen || repo.failures >= repo.failureThreshold {
+		if repo.state != circuitOpen {
+			slog.ErrorContext(
+				ctx, "Packs storage is failing, opening circuit", "open_timeout", repo.openTimeout.String(), "error", err,
+			)
+		}
+		repo.state = circuitOpen
+		repo.openedAt = time.Now()
+	}
+}
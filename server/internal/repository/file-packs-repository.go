@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"server/internal/model"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FilePacksRepositoryImpl is a PacksRepository that reads the packs configuration from a local YAML or JSON file.
+// The file is polled for changes, and a new configuration is swapped in only when it is valid,
+// so an invalid edit of the file keeps the last good configuration in use.
+type FilePacksRepositoryImpl struct {
+	path     string
+	writable bool
+
+	packs atomic.Pointer[[]model.Pack]
+
+	// guards the file writes and the last seen file state
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewFilePacksRepository(path string, writable bool, pollInterval time.Duration) (*FilePacksRepositoryImpl, error) {
+	repo := &FilePacksRepositoryImpl{
+		path:     path,
+		writable: writable,
+		stop:     make(chan struct{}),
+	}
+
+	if err := repo.reload(); err != nil {
+		return nil, err
+	}
+
+	if pollInterval > 0 {
+		go repo.watch(pollInterval)
+	}
+
+	return repo, nil
+}
+
+func (repo *FilePacksRepositoryImpl) FindAll(_ context.Context) ([]model.Pack, error) {
+	current := *repo.packs.Load()
+	packs := make([]model.Pack, len(current))
+	copy(packs, current)
+	return packs, nil
+}
+
+func (repo *FilePacksRepositoryImpl) SyncPacks(_ context.Context, packs []int) error {
+	if !repo.writable {
+		return &model.ReadOnlyPacksConfig{}
+	}
+
+	newPacks, err := ValidPacks(packs)
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	sizes := make([]int, len(newPacks))
+	for i, p := range newPacks {
+		sizes[i] = p.Size
+	}
+
+	content, err := EncodePacksFile(repo.path, model.PacksFile{Packs: sizes})
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomically(repo.path, content); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(repo.path)
+	if err != nil {
+		return err
+	}
+
+	repo.modTime = info.ModTime()
+	repo.size = info.Size()
+	repo.packs.Store(&newPacks)
+
+	return nil
+}
+
+// Close stops watching the file for changes.
+func (repo *FilePacksRepositoryImpl) Close() {
+	repo.stopOnce.Do(func() { close(repo.stop) })
+}
+
+func (repo *FilePacksRepositoryImpl) watch(pollInterval time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-repo.stop:
+			return
+		case <-ticker.C:
+			if err := repo.reloadIfChanged(); err != nil {
+				slog.Error(
+					"Error reloading packs file, keeping last good configuration", "path", repo.path, "error", err,
+				)
+			}
+		}
+	}
+}
+
+func (repo *FilePacksRepositoryImpl) reloadIfChanged() error {
+	info, err := os.Stat(repo.path)
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	changed := !info.ModTime().Equal(repo.modTime) || info.Size() != repo.size
+	repo.mu.Unlock()
+
+	if !changed {
+		return nil
+	}
+
+	return repo.reload()
+}
+
+func (repo *FilePacksRepositoryImpl) reload() error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	info, err := os.Stat(repo.path)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(repo.path)
+	if err != nil {
+		return err
+	}
+
+	// remember the file state even when invalid, so the same broken file is not reported on every poll
+	repo.modTime = info.ModTime()
+	repo.size = info.Size()
+
+	file, err := DecodePacksFile(content)
+	if err != nil {
+		return err
+	}
+	// an empty or half written file would make every packaging fail, while the last good configuration still works
+	if len(file.Packs) == 0 && repo.packs.Load() != nil {
+		return &model.InvalidPacksConfig{Reason: "no pack sizes"}
+	}
+
+	packs, err := ValidPacks(file.Packs)
+	if err != nil {
+		return err
+	}
+
+	repo.packs.Store(&packs)
+	slog.Info("Loaded packs file", "path", repo.path, "packs", file.Packs)
+
+	return nil
+}
+
+// ValidPacks returns the packs of the sizes, from the smallest to the largest, or model.InvalidPacksConfig
+// when a size is not positive or is repeated.
+func ValidPacks(sizes []int) ([]model.Pack, error) {
+	seen := make(map[int]bool, len(sizes))
+	packs := make([]model.Pack, 0, len(sizes))
+	for _, s := range sizes {
+		if s <= 0 {
+			return nil, &model.InvalidPacksConfig{Reason: fmt.Sprintf("pack size must be positive, got %d", s)}
+		}
+		if seen[s] {
+			return nil, &model.InvalidPacksConfig{Reason: fmt.Sprintf("duplicate pack size %d", s)}
+		}
+		seen[s] = true
+		packs = append(packs, model.Pack{Size: s})
+	}
+
+	sort.Slice(packs, func(i, j int) bool { return packs[i].Size < packs[j].Size })
+
+	return packs, nil
+}
+
+// DecodePacksFile parses a packs file, either YAML or JSON as JSON is valid YAML.
+func DecodePacksFile(content []byte) (model.PacksFile, error) {
+	var file model.PacksFile
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	// a misspelled key would be silently ignored otherwise
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return file, &model.InvalidPacksConfig{Reason: err.Error()}
+	}
+
+	return file, nil
+}
+
+// EncodePacksFile encodes a packs file as JSON when the path has the .json extension, and as YAML otherwise.
+func EncodePacksFile(path string, file model.PacksFile) ([]byte, error) {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return json.MarshalIndent(file, "", "  ")
+	}
+
+	return yaml.Marshal(file)
+}
+
+// writeFileAtomically writes to a temp file in the same folder and renames it,
+// so readers of the file never see a partially written content.
+func writeFileAtomically(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+)
+
+// SchemaVersion is the version of the DB schema (db-init.sql and migrations) the app needs.
+// It is increased together with every change of the schema.
+const SchemaVersion = 5
+
+type HealthRepository interface {
+	Ping(ctx context.Context) error
+	// SchemaVersion returns the version of the DB schema, recorded in the schema_version table.
+	SchemaVersion(ctx context.Context) (int, error)
+}
+
+type HealthRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewHealthRepository(db *gorm.DB) HealthRepository {
+	return &HealthRepositoryImpl{db: db}
+}
+
+func (repo *HealthRepositoryImpl) Ping(ctx context.Context) error {
+	sqlDB, err := repo.db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
+}
+
+func (repo *HealthRepositoryImpl) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := repo.db.WithContext(ctx).Raw("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version).Error
+	return version, err
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"server/internal/model"
+	"time"
+)
+
+type IdempotencyRepository interface {
+	// Reserve stores the record unless a record with the same scope and key that is not expired exists,
+	// and reports whether it was stored.
+	Reserve(ctx context.Context, record *model.IdempotencyRecord) (bool, error)
+	// Find returns the record with the scope and key, or nil when there is none.
+	Find(ctx context.Context, scope string, key string) (*model.IdempotencyRecord, error)
+	Complete(ctx context.Context, scope string, key string, response model.IdempotentResponse) error
+	Delete(ctx context.Context, scope string, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type IdempotencyRepositoryImpl struct {
+	db    *gorm.DB
+	retry RetryPolicy
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &IdempotencyRepositoryImpl{db: db, retry: DefaultRetryPolicy}
+}
+
+func (repo *IdempotencyRepositoryImpl) Reserve(ctx context.Context, record *model.IdempotencyRecord) (bool, error) {
+	var reserved bool
+	err := repo.retry.Do(
+		ctx, func() error {
+			return repo.db.WithContext(ctx).Transaction(
+				func(tx *gorm.DB) error {
+					if err := tx.
+						Where("scope = ? AND key = ? AND expires_at <= ?", record.Scope, record.Key, record.CreatedAt).
+						Delete(&model.IdempotencyRecord{}).Error; err != nil {
+						return err
+					}
+
+					result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+					if result.Error != nil {
+						return result.Error
+					}
+
+					reserved = result.RowsAffected == 1
+					return nil
+				},
+			)
+		},
+	)
+	return reserved, err
+}
+
+func (repo *IdempotencyRepositoryImpl) Find(ctx context.Context, scope string, key string) (
+	*model.IdempotencyRecord, error,
+) {
+	var record model.IdempotencyRecord
+	err := repo.retry.Do(
+		ctx, func() error {
+			return repo.db.WithContext(ctx).Where("scope = ? AND key = ?", scope, key).Take(&record).Error
+		},
+	)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (repo *IdempotencyRepositoryImpl) Complete(
+	ctx context.Context, scope string, key string, response model.IdempotentResponse,
+) error {
+	return repo.retry.Do(
+		ctx, func() error {
+			return repo.db.WithContext(ctx).Model(&model.IdempotencyRecord{}).
+				Where("scope = ? AND key = ?", scope, key).
+				Select("completed", "status_code", "headers", "body").
+				Updates(&model.IdempotencyRecord{Completed: true, IdempotentResponse: response}).Error
+		},
+	)
+}
+
+func (repo *IdempotencyRepositoryImpl) Delete(ctx context.Context, scope string, key string) error {
+	return repo.retry.Do(
+		ctx, func() error {
+			return repo.db.WithContext(ctx).
+				Where("scope = ? AND key = ?", scope, key).
+				Delete(&model.IdempotencyRecord{}).Error
+		},
+	)
+}
+
+func (repo *IdempotencyRepositoryImpl) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := repo.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&model.IdempotencyRecord{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"server/internal/model"
+	"sync"
+	"time"
+)
+
+type idempotencyRecordKey struct {
+	scope string
+	key   string
+}
+
+// InMemoryIdempotencyRepositoryImpl keeps the idempotency records in memory,
+// for deployments without a database. The records are lost on restart.
+type InMemoryIdempotencyRepositoryImpl struct {
+	mu      sync.Mutex
+	records map[idempotencyRecordKey]model.IdempotencyRecord
+}
+
+func NewInMemoryIdempotencyRepository() IdempotencyRepository {
+	return &InMemoryIdempotencyRepositoryImpl{records: make(map[idempotencyRecordKey]model.IdempotencyRecord)}
+}
+
+func (repo *InMemoryIdempotencyRepositoryImpl) Reserve(_ context.Context, record *model.IdempotencyRecord) (
+	bool, error,
+) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	recordKey := idempotencyRecordKey{scope: record.Scope, key: record.Key}
+	if existing, ok := repo.records[recordKey]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+		return false, nil
+	}
+
+	repo.records[recordKey] = *record
+	return true, nil
+}
+
+func (repo *InMemoryIdempotencyRepositoryImpl) Find(_ context.Context, scope string, key string) (
+	*model.IdempotencyRecord, error,
+) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	record, ok := repo.records[idempotencyRecordKey{scope: scope, key: key}]
+	if !ok {
+		return nil, nil
+	}
+
+	return &record, nil
+}
+
+func (repo *InMemoryIdempotencyRepositoryImpl) Complete(
+	_ context.Context, scope string, key string, response model.IdempotentResponse,
+) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	recordKey := idempotencyRecordKey{scope: scope, key: key}
+	record, ok := repo.records[recordKey]
+	if !ok {
+		return nil
+	}
+
+	record.Completed = true
+	record.IdempotentResponse = response
+	repo.records[recordKey] = record
+
+	return nil
+}
+
+func (repo *InMemoryIdempotencyRepositoryImpl) Delete(_ context.Context, scope string, key string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.records, idempotencyRecordKey{scope: scope, key: key})
+	return nil
+}
+
+func (repo *InMemoryIdempotencyRepositoryImpl) DeleteExpired(_ context.Context, now time.Time) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var deleted int64
+	for recordKey, record := range repo.records {
+		if !record.ExpiresAt.After(now) {
+			delete(repo.records, recordKey)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
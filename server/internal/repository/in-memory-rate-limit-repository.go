@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"server/internal/model"
+	"sync"
+	"time"
+)
+
+// InMemoryRateLimitRepositoryImpl keeps the buckets in memory, so each replica limits the clients on its own.
+type InMemoryRateLimitRepositoryImpl struct {
+	mu      sync.Mutex
+	buckets map[string]*model.RateLimitBucket
+}
+
+func NewInMemoryRateLimitRepository() RateLimitRepository {
+	return &InMemoryRateLimitRepositoryImpl{buckets: make(map[string]*model.RateLimitBucket)}
+}
+
+func (repo *InMemoryRateLimitRepositoryImpl) Take(
+	_ context.Context, key string, limit model.RateLimit, now time.Time,
+) (model.RateLimitDecision, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	bucket, ok := repo.buckets[key]
+	if !ok {
+		bucket = &model.RateLimitBucket{Key: key, Tokens: float64(limit.Burst), UpdatedAt: now}
+		repo.buckets[key] = bucket
+	}
+
+	return takeToken(bucket, limit, now), nil
+}
+
+func (repo *InMemoryRateLimitRepositoryImpl) DeleteIdle(_ context.Context, before time.Time) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var deleted int64
+	for key, bucket := range repo.buckets {
+		if bucket.UpdatedAt.Before(before) {
+			delete(repo.buckets, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
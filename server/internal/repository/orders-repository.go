@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"server/internal/model"
+	"time"
+)
+
+// orderStatusColumns are the columns with the time an order entered each status
+var orderStatusColumns = map[string]string{
+	model.OrderStatusQuoted:    "quoted_at",
+	model.OrderStatusConfirmed: "confirmed_at",
+	model.OrderStatusPacked:    "packed_at",
+	model.OrderStatusShipped:   "shipped_at",
+	model.OrderStatusCancelled: "cancelled_at",
+}
+
+type OrdersRepository interface {
+	Create(ctx context.Context, order *model.Order) error
+	// Find returns the order with the ID, or nil when there is none.
+	Find(ctx context.Context, id string) (*model.Order, error)
+	// UpdateStatus moves the order from a status to another one, recording when. It returns false when the order
+	// is not in the from status anymore, e.g. because of a concurrent update.
+	UpdateStatus(ctx context.Context, id string, from string, to string, at time.Time) (bool, error)
+}
+
+type OrdersRepositoryImpl struct {
+	db    *gorm.DB
+	retry RetryPolicy
+}
+
+func NewOrdersRepository(db *gorm.DB) OrdersRepository {
+	return &OrdersRepositoryImpl{db: db, retry: DefaultRetryPolicy}
+}
+
+func (repo *OrdersRepositoryImpl) Create(ctx context.Context, order *model.Order) error {
+	return repo.db.WithContext(ctx).Create(order).Error
+}
+
+func (repo *OrdersRepositoryImpl) Find(ctx context.Context, id string) (*model.Order, error) {
+	var order model.Order
+	err := repo.retry.Do(
+		ctx, func() error {
+			return repo.db.WithContext(ctx).Where("id = ?", id).Take(&order).Error
+		},
+	)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// UpdateStatus is not retried, as a retry of an update that went through would not find the order
+// in the from status and report a concurrent update.
+func (repo *OrdersRepositoryImpl) UpdateStatus(
+	ctx context.Context, id string, from string, to string, at time.Time,
+) (bool, error) {
+	column, ok := orderStatusColumns[to]
+	if !ok {
+		return false, fmt.Errorf("unknown order status %q", to)
+	}
+
+	result := repo.db.WithContext(ctx).
+		Model(&model.Order{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(map[string]any{"status": to, column: at, "updated_at": at})
+
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"server/internal/model"
+	"time"
+)
+
+// how many lines are inserted with a single statement
+const jobLinesInsertBatchSize = 1000
+
+type PackagingJobsRepository interface {
+	Create(ctx context.Context, job *model.PackagingJob, lines []model.PackagingJobLine) error
+	// Find returns the job with the ID, or nil when there is none.
+	Find(ctx context.Context, id string) (*model.PackagingJob, error)
+	// Claim marks the oldest queued job, or running job whose heartbeat is before staleBefore, as running,
+	// and returns it. It returns nil when there is no such job.
+	Claim(ctx context.Context, now time.Time, staleBefore time.Time) (*model.PackagingJob, error)
+	// Release puts a running job back in the queue, so it is claimed again.
+	Release(ctx context.Context, id string) error
+	// SavePacksConfig saves the packs configuration the job is packed with, unless it already has one,
+	// and returns the job with its packs configuration.
+	SavePacksConfig(ctx context.Context, id string, sizes []int, version string) (*model.PackagingJob, error)
+	// FindLines returns up to limit lines of the job after the line number, ordered by line number.
+	// With unprocessedOnly, only the lines not processed yet are returned.
+	FindLines(ctx context.Context, id string, afterLine int, limit int, unprocessedOnly bool) (
+		[]model.PackagingJobLine, error,
+	)
+	// SaveLines saves the processed lines, counts them in the progress of the job and renews its heartbeat.
+	// The lines that were already processed (e.g. by a worker that took over the job) are not counted again.
+	SaveLines(ctx context.Context, id string, lines []model.PackagingJobLine, now time.Time) error
+	// Finish marks the job as completed or failed.
+	Finish(ctx context.Context, id string, status string, message string, now time.Time) error
+	// DeleteFinished deletes the jobs finished before the time, with their lines.
+	DeleteFinished(ctx context.Context, before time.Time) (int64, error)
+}
+
+type PackagingJobsRepositoryImpl struct {
+	db    *gorm.DB
+	retry RetryPolicy
+}
+
+func NewPackagingJobsRepository(db *gorm.DB) PackagingJobsRepository {
+	return &PackagingJobsRepositoryImpl{db: db, retry: DefaultRetryPolicy}
+}
+
+func (repo *PackagingJobsRepositoryImpl) Create(
+	ctx context.Context, job *model.PackagingJob, lines []model.PackagingJobLine,
+) error {
+	return repo.db.WithContext(ctx).Transaction(
+		func(tx *gorm.DB) error {
+			if err := tx.Create(job).Error; err != nil {
+				return err
+			}
+
+			return tx.CreateInBatches(lines, jobLinesInsertBatchSize).Error
+		},
+	)
+}
+
+func (repo *PackagingJobsRepositoryImpl) Find(ctx context.Context, id string) (*model.PackagingJob, error) {
+	var job model.PackagingJob
+	err := repo.retry.Do(
+		ctx, func() error {
+			return repo.db.WithContext(ctx).Where("id = ?", id).Take(&job).Error
+		},
+	)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (repo *PackagingJobsRepositoryImpl) Claim(ctx context.Context, now time.Time, staleBefore time.Time) (
+	*model.PackagingJob, error,
+) {
+	// SKIP LOCKED lets the workers of all the replicas claim different jobs at the same time
+	var jobs []model.PackagingJob
+	err := repo.db.WithContext(ctx).Raw(
+		`UPDATE packaging_jobs
+		SET status = ?, started_at = COALESCE(started_at, ?), heartbeat_at = ?
+		WHERE id = (
+			SELECT id FROM packaging_jobs
+			WHERE status = ? OR (status = ? AND heartbeat_at < ?)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		model.JobStatusRunning, now, now, model.JobStatusQueued, model.JobStatusRunning, staleBefore,
+	).Scan(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+
+	return &jobs[0], nil
+}
+
+func (repo *PackagingJobsRepositoryImpl) Release(ctx context.Context, id string) error {
+	return repo.retry.Do(
+		ctx, func() error {
+			return repo.db.WithContext(ctx).
+				Model(&model.PackagingJob{}).
+				Where("id = ? AND status = ?", id, model.JobStatusRunning).
+				Updates(map[string]any{"status": model.JobStatusQueued, "heartbeat_at": nil}).Error
+		},
+	)
+}
+
+func (repo *PackagingJobsRepositoryImpl) SavePacksConfig(
+	ctx context.Context, id string, sizes []int, version string,
+) (*model.PackagingJob, error) {
+	err := repo.retry.Do(
+		ctx, func() error {
+			return repo.db.WithContext(ctx).
+				Model(&model.PackagingJob{ID: id}).
+				Where("pack_sizes IS NULL").
+				Select("PackSizes", "PacksVersion").
+				Updates(&model.PackagingJob{PackSizes: sizes, PacksVersion: version}).Error
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := repo.Find(ctx, id)
+	if err == nil && job == nil {
+		return nil, fmt.Errorf("packaging job %s not found", id)
+	}
+
+	return job, err
+}
+
+func (repo *PackagingJobsRepositoryImpl) FindLines(
+	ctx context.Context, id string, afterLine int, limit int, unprocessedOnly bool,
+) ([]model.PackagingJobLine, error) {
+	var lines []model.PackagingJobLine
+	err := repo.retry.Do(
+		ctx, func() error {
+			query := repo.db.WithContext(ctx).Where("job_id = ? AND line_number > ?", id, afterLine)
+			if unprocessedOnly {
+				query = query.Where("processed = ?", false)
+			}
+			return query.Order("line_number").Limit(limit).Find(&lines).Error
+		},
+	)
+
+	return lines, err
+}
+
+func (repo *PackagingJobsRepositoryImpl) SaveLines(
+	ctx context.Context, id string, lines []model.PackagingJobLine, now time.Time,
+) error {
+	return repo.retry.Do(
+		ctx, func() error {
+			return repo.db.WithContext(ctx).Transaction(
+				func(tx *gorm.DB) error {
+					processed, failed := 0, 0
+					for _, line := range lines {
+						line.Processed = true
+						result := tx.Model(&line).
+							Where("processed = ?", false).
+							Select("Processed", "Packs", "PacksVersion", "Error").
+							Updates(&line)
+						if result.Error != nil {
+							return result.Error
+						}
+						if result.RowsAffected == 1 {
+							processed++
+							if line.Error != "" {
+								failed++
+							}
+						}
+					}
+
+					return tx.Model(&model.PackagingJob{}).
+						Where("id = ?", id).
+						Updates(
+							map[string]any{
+								"processed_lines": gorm.Expr("processed_lines + ?", processed),
+								"failed_lines":    gorm.Expr("failed_lines + ?", failed),
+								"heartbeat_at":    now,
+							},
+						).Error
+				},
+			)
+		},
+	)
+}
+
+func (repo *PackagingJobsRepositoryImpl) Finish(
+	ctx context.Context, id string, status string, message string, now time.Time,
+) error {
+	return repo.retry.Do(
+		ctx, func() error {
+			return repo.db.WithContext(ctx).
+				Model(&model.PackagingJob{}).
+				Where("id = ?", id).
+				Updates(map[string]any{"status": status, "error": message, "completed_at": now}).Error
+		},
+	)
+}
+
+func (repo *PackagingJobsRepositoryImpl) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	// the lines are deleted with their job, by the foreign key
+	result := repo.db.WithContext(ctx).
+		Where("status IN ? AND completed_at < ?", []string{model.JobStatusCompleted, model.JobStatusFailed}, before).
+		Delete(&model.PackagingJob{})
+
+	return result.RowsAffected, result.Error
+}
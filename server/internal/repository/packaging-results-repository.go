@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"server/internal/model"
+)
+
+type PackagingResultsRepository interface {
+	Save(ctx context.Context, result *model.PackagingResult) error
+	Find(ctx context.Context, filter model.PackagingHistoryFilter) ([]model.PackagingResult, int64, error)
+}
+
+type PackagingResultsRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPackagingResultsRepository(db *gorm.DB) PackagingResultsRepository {
+	return &PackagingResultsRepositoryImpl{db: db}
+}
+
+func (repo *PackagingResultsRepositoryImpl) Save(ctx context.Context, result *model.PackagingResult) error {
+	return repo.db.WithContext(ctx).Create(result).Error
+}
+
+func (repo *PackagingResultsRepositoryImpl) Find(ctx context.Context, filter model.PackagingHistoryFilter) (
+	[]model.PackagingResult, int64, error,
+) {
+	query := repo.db.WithContext(ctx).Model(&model.PackagingResult{})
+
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at <= ?", filter.To)
+	}
+	if filter.MinQuantity > 0 {
+		query = query.Where("number_of_items >= ?", filter.MinQuantity)
+	}
+	if filter.MaxQuantity > 0 {
+		query = query.Where("number_of_items <= ?", filter.MaxQuantity)
+	}
+	if filter.Caller != "" {
+		query = query.Where("caller = ?", filter.Caller)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var results []model.PackagingResult
+	err := query.
+		Order("created_at desc, id desc").
+		Offset(filter.Offset).
+		Limit(filter.Limit).
+		Find(&results).Error
+
+	return results, total, err
+}
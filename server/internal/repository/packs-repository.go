@@ -1,34 +1,61 @@
 package repository
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"server/internal/metrics"
 	"server/internal/model"
+	"time"
 )
 
 type PacksRepository interface {
-	FindAll() ([]model.Pack, error)
-	SyncPacks(packs []int) error
+	FindAll(ctx context.Context) ([]model.Pack, error)
+	SyncPacks(ctx context.Context, packs []int) error
 }
 
 type PacksRepositoryImpl struct {
-	db *gorm.DB
+	db    *gorm.DB
+	retry RetryPolicy
 }
 
-func (repo *PacksRepositoryImpl) FindAll() ([]model.Pack, error) {
+func (repo *PacksRepositoryImpl) FindAll(ctx context.Context) ([]model.Pack, error) {
 	var packs []model.Pack
-	result := repo.db.Model(&model.Pack{}).
-		Order("size asc").
-		Find(&packs)
-	return packs, result.Error
+	err := repo.retry.Do(
+		ctx, observeQuery(
+			"find_all", func() error {
+				packs = nil
+				return repo.db.WithContext(ctx).Model(&model.Pack{}).
+					Order("size asc").
+					Find(&packs).Error
+			},
+		),
+	)
+	return packs, err
 }
 
 func NewPacksRepository(db *gorm.DB) PacksRepository {
-	return &PacksRepositoryImpl{db: db}
+	return &PacksRepositoryImpl{db: db, retry: DefaultRetryPolicy}
+}
+
+// SyncPacks replaces the stored packs with the given ones.
+// The sync always converges to the same state, so the whole transaction is safe to retry.
+func (repo *PacksRepositoryImpl) SyncPacks(ctx context.Context, packs []int) error {
+	return repo.retry.Do(ctx, observeQuery("sync", func() error { return repo.syncPacks(ctx, packs) }))
+}
+
+// observeQuery measures the latency of every attempt of a query.
+func observeQuery(operation string, query func() error) func() error {
+	return func() error {
+		start := time.Now()
+		err := query()
+		metrics.DbQueryDuration.WithLabelValues(operation, metrics.Outcome(err)).Observe(metrics.Since(start))
+		return err
+	}
 }
 
-func (repo *PacksRepositoryImpl) SyncPacks(packs []int) error {
-	return repo.db.Transaction(
+func (repo *PacksRepositoryImpl) syncPacks(ctx context.Context, packs []int) error {
+	return repo.db.WithContext(ctx).Transaction(
 		func(tx *gorm.DB) error {
 			if len(packs) == 0 {
 				return tx.Where("1 = 1").Delete(&model.Pack{}).Error
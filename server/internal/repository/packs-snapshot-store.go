@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"gopkg.in/yaml.v3"
+	"os"
+	"server/internal/model"
+)
+
+// PacksSnapshotStore keeps a copy of the last known packs configuration,
+// to be used when the packs storage is unavailable.
+type PacksSnapshotStore interface {
+	Save(sizes []int) error
+	Load() ([]int, error)
+}
+
+type FilePacksSnapshotStoreImpl struct {
+	path string
+}
+
+func NewFilePacksSnapshotStore(path string) PacksSnapshotStore {
+	return &FilePacksSnapshotStoreImpl{path: path}
+}
+
+func (store *FilePacksSnapshotStoreImpl) Save(sizes []int) error {
+	content, err := EncodePacksFile(store.path, model.PacksFile{Packs: sizes})
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomically(store.path, content)
+}
+
+func (store *FilePacksSnapshotStoreImpl) Load() ([]int, error) {
+	content, err := os.ReadFile(store.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file model.PacksFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+
+	return file.Packs, nil
+}
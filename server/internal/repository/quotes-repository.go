@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"server/internal/model"
+	"time"
+)
+
+type QuotesRepository interface {
+	Create(ctx context.Context, quote *model.Quote) error
+	// Find returns the quote with the ID, or nil when there is none.
+	Find(ctx context.Context, id string) (*model.Quote, error)
+	// DeleteExpired deletes the quotes that expired before the time.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type QuotesRepositoryImpl struct {
+	db    *gorm.DB
+	retry RetryPolicy
+}
+
+func NewQuotesRepository(db *gorm.DB) QuotesRepository {
+	return &QuotesRepositoryImpl{db: db, retry: DefaultRetryPolicy}
+}
+
+func (repo *QuotesRepositoryImpl) Create(ctx context.Context, quote *model.Quote) error {
+	return repo.db.WithContext(ctx).Create(quote).Error
+}
+
+func (repo *QuotesRepositoryImpl) Find(ctx context.Context, id string) (*model.Quote, error) {
+	var quote model.Quote
+	err := repo.retry.Do(
+		ctx, func() error {
+			return repo.db.WithContext(ctx).Where("id = ?", id).Take(&quote).Error
+		},
+	)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &quote, nil
+}
+
+func (repo *QuotesRepositoryImpl) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := repo.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&model.Quote{})
+
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math"
+	"server/internal/model"
+	"time"
+)
+
+type RateLimitRepository interface {
+	// Take takes a request from the bucket with the key, creating the bucket when it does not exist yet.
+	Take(ctx context.Context, key string, limit model.RateLimit, now time.Time) (model.RateLimitDecision, error)
+	// DeleteIdle deletes the buckets not used since before. These are full again, so deleting them changes nothing.
+	DeleteIdle(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RateLimitRepositoryImpl keeps the buckets in the DB, so the limits are shared by all the replicas.
+type RateLimitRepositoryImpl struct {
+	db    *gorm.DB
+	retry RetryPolicy
+}
+
+func NewRateLimitRepository(db *gorm.DB) RateLimitRepository {
+	return &RateLimitRepositoryImpl{db: db, retry: DefaultRetryPolicy}
+}
+
+func (repo *RateLimitRepositoryImpl) Take(
+	ctx context.Context, key string, limit model.RateLimit, now time.Time,
+) (model.RateLimitDecision, error) {
+	var decision model.RateLimitDecision
+	err := repo.retry.Do(
+		ctx, func() error {
+			return repo.db.WithContext(ctx).Transaction(
+				func(tx *gorm.DB) error {
+					if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(
+						&model.RateLimitBucket{Key: key, Tokens: float64(limit.Burst), UpdatedAt: now},
+					).Error; err != nil {
+						return err
+					}
+
+					var bucket model.RateLimitBucket
+					if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+						Where("key = ?", key).
+						Take(&bucket).Error; err != nil {
+						return err
+					}
+
+					decision = takeToken(&bucket, limit, now)
+					return tx.Save(&bucket).Error
+				},
+			)
+		},
+	)
+	return decision, err
+}
+
+func (repo *RateLimitRepositoryImpl) DeleteIdle(ctx context.Context, before time.Time) (int64, error) {
+	result := repo.db.WithContext(ctx).Where("updated_at < ?", before).Delete(&model.RateLimitBucket{})
+	return result.RowsAffected, result.Error
+}
+
+// takeToken refills the bucket for the time passed since it was last used, and takes a request from it
+// unless it is empty or the daily quota is used up.
+func takeToken(bucket *model.RateLimitBucket, limit model.RateLimit, now time.Time) model.RateLimitDecision {
+	burst := float64(limit.Burst)
+	// the clocks of the replicas can be slightly off, so the bucket never goes back in time
+	if elapsed := now.Sub(bucket.UpdatedAt); elapsed > 0 {
+		bucket.Tokens = math.Min(burst, bucket.Tokens+elapsed.Seconds()*limit.RequestsPerSecond)
+		bucket.UpdatedAt = now
+	}
+
+	utcNow := now.UTC()
+	if day := utcNow.Format(time.DateOnly); bucket.QuotaDay != day {
+		bucket.QuotaDay = day
+		bucket.QuotaUsed = 0
+	}
+	untilNextDay := time.Date(utcNow.Year(), utcNow.Month(), utcNow.Day()+1, 0, 0, 0, 0, time.UTC).Sub(utcNow)
+
+	var decision model.RateLimitDecision
+	switch {
+	case limit.DailyQuota > 0 && bucket.QuotaUsed >= limit.DailyQuota:
+		decision.QuotaExceeded = true
+		decision.RetryAfter = untilNextDay
+	case bucket.Tokens < 1:
+		decision.RetryAfter = secondsToDuration((1 - bucket.Tokens) / limit.RequestsPerSecond)
+	default:
+		decision.Allowed = true
+		bucket.Tokens--
+		bucket.QuotaUsed++
+	}
+
+	decision.Limit = limit.Burst
+	decision.Remaining = int(math.Floor(bucket.Tokens))
+	decision.Reset = secondsToDuration((burst - bucket.Tokens) / limit.RequestsPerSecond)
+	if quotaRemaining := limit.DailyQuota - bucket.QuotaUsed; limit.DailyQuota > 0 && quotaRemaining < decision.Remaining {
+		decision.Limit = limit.DailyQuota
+		decision.Remaining = quotaRemaining
+		decision.Reset = untilNextDay
+	}
+
+	return decision
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy retries an operation that failed with a transient database error,
+// doubling the backoff between the attempts.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, Backoff: 50 * time.Millisecond}
+
+// Do runs the operation until it succeeds, fails with a non-transient error, the attempts are used up
+// or the context is done. The operation must be safe to repeat.
+func (policy RetryPolicy) Do(ctx context.Context, operation func() error) error {
+	backoff := policy.Backoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = operation()
+		if err == nil || attempt >= policy.MaxAttempts || !IsTransientError(err) {
+			return err
+		}
+
+		slog.WarnContext(
+			ctx, "Transient DB error, retrying", "attempt", attempt, "max_attempts", policy.MaxAttempts,
+			"backoff", backoff.String(), "error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// IsTransientError reports whether the error is likely to go away when the operation is retried:
+// serialization failures and deadlocks, and errors caused by a dropped or refused connection.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// serialization_failure, deadlock_detected, admin_shutdown and the connection_exception class
+		return pgErr.Code == "40001" ||
+			pgErr.Code == "40P01" ||
+			pgErr.Code == "57P01" ||
+			strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	if pgconn.SafeToRetry(err) || pgconn.Timeout(err) {
+		return true
+	}
+
+	if errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
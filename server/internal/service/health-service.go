@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"server/internal/model"
+	"server/internal/repository"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// healthCheckTimeout bounds each check, so a hanging dependency fails the probe instead of blocking it.
+const healthCheckTimeout = 2 * time.Second
+
+type HealthService interface {
+	// Live reports whether the app is running. It does not check the dependencies,
+	// so a failing dependency does not get the app restarted.
+	Live() *model.HealthReport
+	// Ready reports whether the app can serve requests: not shutting down, the DB reachable
+	// with the expected schema version, and the packs configuration loaded.
+	Ready(ctx context.Context) *model.HealthReport
+	// Started reports whether the app has completed its startup.
+	Started(ctx context.Context) *model.HealthReport
+	MarkStarted()
+	// MarkShuttingDown makes the app not ready, so no new requests are routed to it while it shuts down.
+	MarkShuttingDown()
+}
+
+type HealthServiceImpl struct {
+	repository   repository.HealthRepository
+	packsService PacksService
+
+	started      atomic.Bool
+	shuttingDown atomic.Bool
+}
+
+// NewHealthService creates a HealthService. Without a repository (no DB), the DB checks are left out.
+func NewHealthService(repository repository.HealthRepository, packsService PacksService) HealthService {
+	return &HealthServiceImpl{
+		repository:   repository,
+		packsService: packsService,
+	}
+}
+
+func (service *HealthServiceImpl) Live() *model.HealthReport {
+	return &model.HealthReport{Status: model.HealthStatusUp}
+}
+
+func (service *HealthServiceImpl) Ready(ctx context.Context) *model.HealthReport {
+	checks := map[string]model.HealthCheck{}
+
+	if service.shuttingDown.Load() {
+		checks["shutdown"] = model.HealthCheck{Status: model.HealthStatusDown, Message: "shutting down"}
+	} else {
+		checks["shutdown"] = model.HealthCheck{Status: model.HealthStatusUp}
+	}
+
+	if service.repository != nil {
+		checks["database"] = service.checkDatabase(ctx)
+		checks["schema"] = service.checkSchema(ctx)
+	}
+	checks["packs"] = service.checkPacks(ctx)
+
+	return toHealthReport(checks)
+}
+
+func (service *HealthServiceImpl) Started(ctx context.Context) *model.HealthReport {
+	checks := map[string]model.HealthCheck{}
+
+	if service.started.Load() {
+		checks["startup"] = model.HealthCheck{Status: model.HealthStatusUp}
+	} else {
+		checks["startup"] = model.HealthCheck{Status: model.HealthStatusDown, Message: "starting"}
+	}
+
+	if service.repository != nil {
+		checks["database"] = service.checkDatabase(ctx)
+		checks["schema"] = service.checkSchema(ctx)
+	}
+
+	return toHealthReport(checks)
+}
+
+func (service *HealthServiceImpl) MarkStarted() {
+	service.started.Store(true)
+}
+
+func (service *HealthServiceImpl) MarkShuttingDown() {
+	service.shuttingDown.Store(true)
+}
+
+func (service *HealthServiceImpl) checkDatabase(ctx context.Context) model.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := service.repository.Ping(ctx); err != nil {
+		return model.HealthCheck{Status: model.HealthStatusDown, Message: err.Error()}
+	}
+
+	return model.HealthCheck{
+		Status:  model.HealthStatusUp,
+		Details: map[string]string{"latency": time.Since(start).String()},
+	}
+}
+
+func (service *HealthServiceImpl) checkSchema(ctx context.Context) model.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	version, err := service.repository.SchemaVersion(ctx)
+	if err != nil {
+		return model.HealthCheck{Status: model.HealthStatusDown, Message: err.Error()}
+	}
+
+	details := map[string]string{
+		"version":  strconv.Itoa(version),
+		"expected": strconv.Itoa(repository.SchemaVersion),
+	}
+	if version < repository.SchemaVersion {
+		return model.HealthCheck{
+			Status:  model.HealthStatusDown,
+			Message: fmt.Sprintf("schema version %d is older than %d", version, repository.SchemaVersion),
+			Details: details,
+		}
+	}
+
+	return model.HealthCheck{Status: model.HealthStatusUp, Details: details}
+}
+
+func (service *HealthServiceImpl) checkPacks(ctx context.Context) model.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	packsConfig, err := service.packsService.GetPacksConfig(ctx)
+	if err != nil {
+		return model.HealthCheck{Status: model.HealthStatusDown, Message: err.Error()}
+	}
+
+	check := model.HealthCheck{
+		Status: model.HealthStatusUp,
+		Details: map[string]string{
+			"version": packsConfig.Version,
+			"packs":   strconv.Itoa(len(packsConfig.Sizes)),
+		},
+	}
+	switch {
+	case packsConfig.Stale:
+		check.Message = "using the last known packs configuration"
+	case len(packsConfig.Sizes) == 0:
+		check.Message = "no packs configured"
+	}
+
+	return check
+}
+
+func toHealthReport(checks map[string]model.HealthCheck) *model.HealthReport {
+	report := &model.HealthReport{Status: model.HealthStatusUp, Checks: checks}
+	for _, check := range checks {
+		if check.Status != model.HealthStatusUp {
+			report.Status = model.HealthStatusDown
+		}
+	}
+
+	return report
+}
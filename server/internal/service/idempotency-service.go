@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"server/internal/model"
+	"server/internal/repository"
+	"time"
+)
+
+type IdempotencyService interface {
+	// Begin reserves the key for a new request. It returns the stored response when the key was already used
+	// for the same request, model.IdempotencyKeyMismatch when it was used for a different one,
+	// and model.IdempotencyKeyInProgress when the request using it has not completed yet.
+	Begin(ctx context.Context, scope string, key string, requestHash string) (*model.IdempotentResponse, error)
+	// Complete stores the response of the request the key was reserved for.
+	Complete(ctx context.Context, scope string, key string, response model.IdempotentResponse) error
+	// Release frees the key, so the request can be retried with it.
+	Release(ctx context.Context, scope string, key string) error
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+type IdempotencyServiceImpl struct {
+	repository repository.IdempotencyRepository
+	retention  time.Duration
+}
+
+func NewIdempotencyService(repository repository.IdempotencyRepository, retention time.Duration) IdempotencyService {
+	return &IdempotencyServiceImpl{
+		repository: repository,
+		retention:  retention,
+	}
+}
+
+func (service *IdempotencyServiceImpl) Begin(
+	ctx context.Context, scope string, key string, requestHash string,
+) (*model.IdempotentResponse, error) {
+	now := time.Now().UTC()
+	reserved, err := service.repository.Reserve(
+		ctx, &model.IdempotencyRecord{
+			Scope:       scope,
+			Key:         key,
+			RequestHash: requestHash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(service.retention),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	existing, err := service.repository.Find(ctx, scope, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// the existing record was released in the meantime, the client can retry
+	if existing == nil {
+		return nil, &model.IdempotencyKeyInProgress{}
+	}
+
+	if existing.RequestHash != requestHash {
+		return nil, &model.IdempotencyKeyMismatch{}
+	}
+	if !existing.Completed {
+		return nil, &model.IdempotencyKeyInProgress{}
+	}
+
+	return &existing.IdempotentResponse, nil
+}
+
+func (service *IdempotencyServiceImpl) Complete(
+	ctx context.Context, scope string, key string, response model.IdempotentResponse,
+) error {
+	return service.repository.Complete(ctx, scope, key, response)
+}
+
+func (service *IdempotencyServiceImpl) Release(ctx context.Context, scope string, key string) error {
+	return service.repository.Delete(ctx, scope, key)
+}
+
+func (service *IdempotencyServiceImpl) PurgeExpired(ctx context.Context) (int64, error) {
+	return service.repository.DeleteExpired(ctx, time.Now().UTC())
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"log/slog"
+	"server/internal/model"
+	"server/internal/repository"
+	"slices"
+	"time"
+)
+
+// orderTransitions are the statuses an order can go to from each status, shipped and cancelled being final.
+var orderTransitions = map[string][]string{
+	model.OrderStatusQuoted:    {model.OrderStatusConfirmed, model.OrderStatusCancelled},
+	model.OrderStatusConfirmed: {model.OrderStatusPacked, model.OrderStatusCancelled},
+	model.OrderStatusPacked:    {model.OrderStatusShipped, model.OrderStatusCancelled},
+}
+
+type OrdersService interface {
+	// Create quotes an order, with the packs of its quantity assigned by the packaging service.
+	// It returns model.PacksStorageUnavailable rather than lock the order with the last known packs configuration.
+	Create(ctx context.Context, request model.OrderRequest, caller string) (*model.OrderResponse, error)
+	// Find returns the order, or model.OrderNotFound.
+	Find(ctx context.Context, id string) (*model.OrderResponse, error)
+	// Transition moves the order to the status. It returns model.OrderNotFound, model.InvalidOrderStatus,
+	// or model.IllegalOrderTransition when the order can not go to the status from its current one.
+	Transition(ctx context.Context, id string, status string) (*model.OrderResponse, error)
+}
+
+type OrdersServiceImpl struct {
+	repository       repository.OrdersRepository
+	packagingService PackagingService
+	historyService   PackagingHistoryService
+}
+
+// NewOrdersService creates an OrdersService recording the packaging of the orders in the history, unless it is nil.
+func NewOrdersService(
+	repository repository.OrdersRepository, packagingService PackagingService, historyService PackagingHistoryService,
+) OrdersService {
+	return &OrdersServiceImpl{
+		repository:       repository,
+		packagingService: packagingService,
+		historyService:   historyService,
+	}
+}
+
+func (service *OrdersServiceImpl) Create(
+	ctx context.Context, request model.OrderRequest, caller string,
+) (*model.OrderResponse, error) {
+	calculation, err := service.packagingService.Calculate(ctx, request.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	if calculation.Stale {
+		return nil, &model.PacksStorageUnavailable{}
+	}
+	RecordPackaging(ctx, service.historyService, calculation, caller)
+
+	now := time.Now().UTC()
+	order := &model.Order{
+		ID:                uuid.NewString(),
+		CustomerReference: request.CustomerReference,
+		NumberOfItems:     request.Quantity,
+		Packs:             calculation.Packs,
+		PacksVersion:      calculation.PacksVersion,
+		Status:            model.OrderStatusQuoted,
+		Caller:            caller,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		QuotedAt:          &now,
+	}
+	if err := service.repository.Create(ctx, order); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(
+		ctx, "Order quoted", "order_id", order.ID, "customer_reference", order.CustomerReference,
+		"quantity", order.NumberOfItems,
+	)
+
+	return toOrderResponse(order), nil
+}
+
+func (service *OrdersServiceImpl) Find(ctx context.Context, id string) (*model.OrderResponse, error) {
+	order, err := service.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toOrderResponse(order), nil
+}
+
+func (service *OrdersServiceImpl) Transition(ctx context.Context, id string, status string) (
+	*model.OrderResponse, error,
+) {
+	if !slices.Contains(model.OrderStatuses, status) {
+		return nil, &model.InvalidOrderStatus{Status: status}
+	}
+
+	// when the order was changed concurrently, the transition is checked again from its new status.
+	// This ends, as an order goes through a few statuses at most
+	for {
+		order, err := service.find(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(orderTransitions[order.Status], status) {
+			return nil, &model.IllegalOrderTransition{ID: id, From: order.Status, To: status}
+		}
+
+		now := time.Now().UTC()
+		updated, err := service.repository.UpdateStatus(ctx, id, order.Status, status, now)
+		if err != nil {
+			return nil, err
+		}
+		if updated {
+			slog.InfoContext(ctx, "Order status changed", "order_id", id, "from", order.Status, "to", status)
+			order.Status = status
+			order.UpdatedAt = now
+			setOrderStatusTime(order, status, now)
+
+			return toOrderResponse(order), nil
+		}
+	}
+}
+
+func (service *OrdersServiceImpl) find(ctx context.Context, id string) (*model.Order, error) {
+	order, err := service.repository.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, &model.OrderNotFound{ID: id}
+	}
+
+	return order, nil
+}
+
+func setOrderStatusTime(order *model.Order, status string, at time.Time) {
+	switch status {
+	case model.OrderStatusQuoted:
+		order.QuotedAt = &at
+	case model.OrderStatusConfirmed:
+		order.ConfirmedAt = &at
+	case model.OrderStatusPacked:
+		order.PackedAt = &at
+	case model.OrderStatusShipped:
+		order.ShippedAt = &at
+	case model.OrderStatusCancelled:
+		order.CancelledAt = &at
+	}
+}
+
+func toOrderResponse(order *model.Order) *model.OrderResponse {
+	lines := PackagingLines(order.Packs)
+
+	response := &model.OrderResponse{
+		ID:                order.ID,
+		CustomerReference: order.CustomerReference,
+		Quantity:          order.NumberOfItems,
+		Status:            order.Status,
+		Lines:             lines,
+		PacksVersion:      order.PacksVersion,
+		CreatedAt:         order.CreatedAt,
+		UpdatedAt:         order.UpdatedAt,
+		QuotedAt:          order.QuotedAt,
+		ConfirmedAt:       order.ConfirmedAt,
+		PackedAt:          order.PackedAt,
+		ShippedAt:         order.ShippedAt,
+		CancelledAt:       order.CancelledAt,
+	}
+	for _, line := range lines {
+		response.TotalPacks += line.Quantity
+		response.TotalItems += line.Items
+	}
+	response.Overage = response.TotalItems - order.NumberOfItems
+
+	return response
+}
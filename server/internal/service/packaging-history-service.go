@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"server/internal/model"
+	"server/internal/repository"
+	"time"
+)
+
+const defaultHistoryPageSize = 20
+
+type PackagingHistoryService interface {
+	Record(ctx context.Context, calculation *model.PackagingCalculation, caller string) error
+	// Find returns a page of the recorded calculations matching the filters of the request.
+	// It returns model.InvalidHistoryFilter when the filters can not match any calculation.
+	Find(ctx context.Context, request model.PackagingHistoryRequest) (*model.PackagingHistoryResponse, error)
+}
+
+type PackagingHistoryServiceImpl struct {
+	repository repository.PackagingResultsRepository
+}
+
+func NewPackagingHistoryService(repository repository.PackagingResultsRepository) PackagingHistoryService {
+	return &PackagingHistoryServiceImpl{
+		repository: repository,
+	}
+}
+
+func (service PackagingHistoryServiceImpl) Record(
+	ctx context.Context, calculation *model.PackagingCalculation, caller string,
+) error {
+	return service.repository.Save(
+		ctx, &model.PackagingResult{
+			NumberOfItems: calculation.NumberOfItems,
+			Packs:         calculation.Packs,
+			PackSizes:     calculation.PackSizes,
+			Objective:     calculation.Objective,
+			PacksVersion:  calculation.PacksVersion,
+			Caller:        caller,
+			CreatedAt:     time.Now().UTC(),
+		},
+	)
+}
+
+// RecordPackaging records the calculation in the history, unless there is none. The calculation is already done,
+// so a failure to record it is only logged, and the caller going away does not prevent recording it.
+func RecordPackaging(
+	ctx context.Context, history PackagingHistoryService, calculation *model.PackagingCalculation, caller string,
+) {
+	if history == nil {
+		return
+	}
+
+	if err := history.Record(context.WithoutCancel(ctx), calculation, caller); err != nil {
+		slog.ErrorContext(ctx, "Error recording packaging result", "error", err)
+	}
+}
+
+func (service PackagingHistoryServiceImpl) Find(ctx context.Context, request model.PackagingHistoryRequest) (
+	*model.PackagingHistoryResponse, error,
+) {
+	if !request.From.IsZero() && !request.To.IsZero() && request.From.After(request.To) {
+		return nil, &model.InvalidHistoryFilter{Reason: "from must not be after to"}
+	}
+	if request.MaxQuantity != 0 && request.MinQuantity > request.MaxQuantity {
+		return nil, &model.InvalidHistoryFilter{Reason: "minQuantity must not be greater than maxQuantity"}
+	}
+
+	page := request.Page
+	if page == 0 {
+		page = 1
+	}
+	pageSize := request.PageSize
+	if pageSize == 0 {
+		pageSize = defaultHistoryPageSize
+	}
+
+	results, total, err := service.repository.Find(
+		ctx, model.PackagingHistoryFilter{
+			From:        request.From,
+			To:          request.To,
+			MinQuantity: request.MinQuantity,
+			MaxQuantity: request.MaxQuantity,
+			Caller:      request.Caller,
+			Offset:      (page - 1) * pageSize,
+			Limit:       pageSize,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.PackagingHistoryEntry, len(results))
+	for i, r := range results {
+		items[i] = model.PackagingHistoryEntry{
+			ID:            r.ID,
+			NumberOfItems: r.NumberOfItems,
+			Packs:         r.Packs,
+			PackSizes:     r.PackSizes,
+			Objective:     r.Objective,
+			PacksVersion:  r.PacksVersion,
+			Caller:        r.Caller,
+			CreatedAt:     r.CreatedAt,
+		}
+	}
+
+	return &model.PackagingHistoryResponse{
+		Items:    items,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	}, nil
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log/slog"
+	"server/internal/model"
+	"server/internal/repository"
+	"sync"
+	"time"
+)
+
+// how many lines of the results are read at once
+const jobResultsPageSize = 1000
+
+// how long a worker stopped by a shutdown has to put its job back in the queue
+const jobReleaseTimeout = 5 * time.Second
+
+type PackagingJobsService interface {
+	// Submit queues a job packing the items, processed in the background by the workers.
+	Submit(ctx context.Context, items []model.PackagingJobItem, caller string) (*model.PackagingJobResponse, error)
+	// Find returns the status and progress of the job, or model.PackagingJobNotFound when there is none
+	// submitted by the caller.
+	Find(ctx context.Context, id string, caller string) (*model.PackagingJobResponse, error)
+	// Results passes the results of the completed job to consume, in the order of the submitted items.
+	// It returns model.PackagingJobNotFound when there is no job submitted by the caller,
+	// or model.PackagingJobNotCompleted while the job is not completed.
+	Results(ctx context.Context, id string, caller string, consume func(result model.PackagingJobResult) error) error
+	// Run processes the jobs with the workers, until ctx is done. The jobs being processed are then put back
+	// in the queue, to be resumed where they were left.
+	Run(ctx context.Context)
+	PurgeFinished(ctx context.Context) (int64, error)
+}
+
+type PackagingJobsServiceImpl struct {
+	repository       repository.PackagingJobsRepository
+	packsService     PacksService
+	packagingService PackagingService
+	historyService   PackagingHistoryService
+	config           model.PackagingJobsConfig
+
+	// submitted wakes up an idle worker when a job is submitted
+	submitted chan struct{}
+}
+
+// NewPackagingJobsService creates a PackagingJobsService recording the packaging of every line in the history,
+// unless it is nil.
+func NewPackagingJobsService(
+	repository repository.PackagingJobsRepository, packsService PacksService, packagingService PackagingService,
+	historyService PackagingHistoryService, config model.PackagingJobsConfig,
+) PackagingJobsService {
+	return &PackagingJobsServiceImpl{
+		repository:       repository,
+		packsService:     packsService,
+		packagingService: packagingService,
+		historyService:   historyService,
+		config:           config,
+		submitted:        make(chan struct{}, 1),
+	}
+}
+
+func (service *PackagingJobsServiceImpl) Submit(
+	ctx context.Context, items []model.PackagingJobItem, caller string,
+) (*model.PackagingJobResponse, error) {
+	if len(items) == 0 {
+		return nil, &model.InvalidPackagingJob{Reason: "no lines to pack"}
+	}
+	if len(items) > service.config.MaxLines {
+		return nil, &model.InvalidPackagingJob{
+			Reason: fmt.Sprintf("%d lines, more than the max of %d", len(items), service.config.MaxLines),
+		}
+	}
+
+	job := &model.PackagingJob{
+		ID:         uuid.NewString(),
+		Status:     model.JobStatusQueued,
+		TotalLines: len(items),
+		Caller:     caller,
+		CreatedAt:  time.Now().UTC(),
+	}
+	lines := make([]model.PackagingJobLine, len(items))
+	for i, item := range items {
+		lines[i] = model.PackagingJobLine{
+			JobID:         job.ID,
+			LineNumber:    i + 1,
+			OrderID:       item.OrderID,
+			NumberOfItems: item.NumberOfItems,
+		}
+	}
+
+	if err := service.repository.Create(ctx, job, lines); err != nil {
+		return nil, err
+	}
+
+	select {
+	case service.submitted <- struct{}{}:
+	default:
+	}
+
+	return toPackagingJobResponse(job), nil
+}
+
+func (service *PackagingJobsServiceImpl) Find(
+	ctx context.Context, id string, caller string,
+) (*model.PackagingJobResponse, error) {
+	job, err := service.findOwnJob(ctx, id, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	return toPackagingJobResponse(job), nil
+}
+
+func (service *PackagingJobsServiceImpl) Results(
+	ctx context.Context, id string, caller string, consume func(result model.PackagingJobResult) error,
+) error {
+	job, err := service.findOwnJob(ctx, id, caller)
+	if err != nil {
+		return err
+	}
+	if job.Status != model.JobStatusCompleted {
+		return &model.PackagingJobNotCompleted{ID: id, Status: job.Status}
+	}
+
+	afterLine := 0
+	for {
+		lines, err := service.repository.FindLines(ctx, id, afterLine, jobResultsPageSize, false)
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			if err := consume(toPackagingJobResult(line)); err != nil {
+				return err
+			}
+		}
+		if len(lines) < jobResultsPageSize {
+			return nil
+		}
+		afterLine = lines[len(lines)-1].LineNumber
+	}
+}
+
+func (service *PackagingJobsServiceImpl) Run(ctx context.Context) {
+	var workers sync.WaitGroup
+	for range service.config.Workers {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			service.work(ctx)
+		}()
+	}
+	workers.Wait()
+}
+
+func (service *PackagingJobsServiceImpl) PurgeFinished(ctx context.Context) (int64, error) {
+	return service.repository.DeleteFinished(ctx, time.Now().UTC().Add(-service.config.Retention))
+}
+
+// work processes the jobs one after the other. When there is no job to process, it waits for one to be submitted,
+// or polls for the ones submitted to the other replicas and the ones abandoned by a crashed worker.
+func (service *PackagingJobsServiceImpl) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now().UTC()
+		job, err := service.repository.Claim(ctx, now, now.Add(-service.config.LeaseTimeout))
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Error claiming packaging job", "error", err)
+		}
+		if job != nil {
+			service.process(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-service.submitted:
+		case <-time.After(service.config.PollInterval):
+		}
+	}
+}
+
+// findOwnJob returns the job, or model.PackagingJobNotFound when there is none submitted by the caller,
+// so that the callers can not tell the jobs of the others from the missing ones.
+func (service *PackagingJobsServiceImpl) findOwnJob(
+	ctx context.Context, id string, caller string,
+) (*model.PackagingJob, error) {
+	job, err := service.repository.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.Caller != caller {
+		return nil, &model.PackagingJobNotFound{ID: id}
+	}
+
+	return job, nil
+}
+
+// process packs the lines of the job not processed yet, saving the progress after every batch of lines.
+// All the lines are packed with the packs configuration of the job, taken when it is first processed,
+// so a job resumed after a change of the pack sizes is not packed with two configurations.
+// When the packs configuration can not be read, the job is left as is, and retried once its lease expires.
+func (service *PackagingJobsServiceImpl) process(ctx context.Context, job *model.PackagingJob) {
+	slog.InfoContext(
+		ctx, "Processing packaging job", "job_id", job.ID, "processed_lines", job.ProcessedLines,
+		"total_lines", job.TotalLines,
+	)
+
+	packsConfig, err := service.packsConfigOf(ctx, job)
+	if err != nil {
+		var emptyPacksConfigError *model.EmptyPacksConfig
+		if errors.As(err, &emptyPacksConfigError) {
+			service.finish(ctx, job, model.JobStatusFailed, emptyPacksConfigError.Error())
+		} else {
+			service.abandon(ctx, job, err)
+		}
+		return
+	}
+
+	afterLine := 0
+	for {
+		lines, err := service.repository.FindLines(ctx, job.ID, afterLine, service.config.BatchSize, true)
+		if err != nil {
+			service.abandon(ctx, job, err)
+			return
+		}
+		if len(lines) == 0 {
+			service.finish(ctx, job, model.JobStatusCompleted, "")
+			return
+		}
+
+		for i := range lines {
+			calculation, err := service.packagingService.Solve(ctx, lines[i].NumberOfItems, packsConfig)
+			if err != nil {
+				if ctx.Err() != nil {
+					service.abandon(ctx, job, err)
+					return
+				}
+				lines[i].Error = err.Error()
+				continue
+			}
+			RecordPackaging(ctx, service.historyService, calculation, job.Caller)
+
+			lines[i].Packs = calculation.Packs
+			lines[i].PacksVersion = calculation.PacksVersion
+		}
+
+		if err := service.repository.SaveLines(ctx, job.ID, lines, time.Now().UTC()); err != nil {
+			service.abandon(ctx, job, err)
+			return
+		}
+		afterLine = lines[len(lines)-1].LineNumber
+	}
+}
+
+// packsConfigOf returns the packs configuration of the job, taking the current one when it has none yet.
+func (service *PackagingJobsServiceImpl) packsConfigOf(
+	ctx context.Context, job *model.PackagingJob,
+) (*model.PacksConfig, error) {
+	if job.PackSizes == nil {
+		packsConfig, err := service.packsService.GetPacksConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(packsConfig.Sizes) == 0 {
+			return nil, &model.EmptyPacksConfig{}
+		}
+
+		// a worker that took over the job may have taken it first, so the one saved is used
+		job, err = service.repository.SavePacksConfig(ctx, job.ID, packsConfig.Sizes, packsConfig.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &model.PacksConfig{Sizes: job.PackSizes, Version: job.PacksVersion}, nil
+}
+
+func (service *PackagingJobsServiceImpl) finish(ctx context.Context, job *model.PackagingJob, status, message string) {
+	if err := service.repository.Finish(ctx, job.ID, status, message, time.Now().UTC()); err != nil {
+		service.abandon(ctx, job, err)
+		return
+	}
+
+	slog.InfoContext(ctx, "Packaging job finished", "job_id", job.ID, "status", status)
+}
+
+// abandon stops processing the job. When the workers are being stopped, the job is put back in the queue,
+// otherwise it is retried once its lease expires.
+func (service *PackagingJobsServiceImpl) abandon(ctx context.Context, job *model.PackagingJob, cause error) {
+	if ctx.Err() == nil {
+		slog.WarnContext(ctx, "Error processing packaging job, retrying it later", "job_id", job.ID, "error", cause)
+		return
+	}
+
+	releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jobReleaseTimeout)
+	defer cancel()
+	if err := service.repository.Release(releaseCtx, job.ID); err != nil {
+		slog.ErrorContext(ctx, "Error releasing packaging job", "job_id", job.ID, "error", err)
+	}
+}
+
+func toPackagingJobResponse(job *model.PackagingJob) *model.PackagingJobResponse {
+	return &model.PackagingJobResponse{
+		ID:             job.ID,
+		Status:         job.Status,
+		TotalLines:     job.TotalLines,
+		ProcessedLines: job.ProcessedLines,
+		FailedLines:    job.FailedLines,
+		PacksVersion:   job.PacksVersion,
+		Progress:       float64(job.ProcessedLines) / float64(job.TotalLines),
+		Error:          job.Error,
+		CreatedAt:      job.CreatedAt,
+		StartedAt:      job.StartedAt,
+		CompletedAt:    job.CompletedAt,
+	}
+}
+
+func toPackagingJobResult(line model.PackagingJobLine) model.PackagingJobResult {
+	result := model.PackagingJobResult{
+		OrderID:       line.OrderID,
+		NumberOfItems: line.NumberOfItems,
+		Lines:         PackagingLines(line.Packs),
+		PacksVersion:  line.PacksVersion,
+		Error:         line.Error,
+	}
+	for _, packagingLine := range result.Lines {
+		result.TotalPacks += packagingLine.Quantity
+		result.TotalItems += packagingLine.Items
+	}
+	if line.Error == "" {
+		result.Overage = result.TotalItems - line.NumberOfItems
+	}
+
+	return result
+}
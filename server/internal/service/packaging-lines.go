@@ -0,0 +1,41 @@
+package service
+
+import (
+	"server/internal/model"
+	"sort"
+)
+
+// PackagingLines turns the packs of a packaging result into lines ordered from the largest pack size to the smallest.
+func PackagingLines(packs map[int]int) []model.PackagingLine {
+	lines := make([]model.PackagingLine, 0, len(packs))
+	for packSize, quantity := range packs {
+		if quantity == 0 {
+			continue
+		}
+		lines = append(lines, model.PackagingLine{PackSize: packSize, Quantity: quantity, Items: packSize * quantity})
+	}
+
+	sort.Slice(lines, func(i, j int) bool { return lines[i].PackSize > lines[j].PackSize })
+
+	return lines
+}
+
+// PackagingResponse describes a packaging calculation as lines, from the largest pack size to the smallest,
+// with the totals.
+func PackagingResponse(calculation *model.PackagingCalculation) model.ProductPackageResponseV2 {
+	lines := PackagingLines(calculation.Packs)
+
+	response := model.ProductPackageResponseV2{
+		NumberOfItems: calculation.NumberOfItems,
+		Lines:         lines,
+		PacksVersion:  calculation.PacksVersion,
+		Stale:         calculation.Stale,
+	}
+	for _, line := range lines {
+		response.TotalPacks += line.Quantity
+		response.TotalItems += line.Items
+	}
+	response.Overage = response.TotalItems - calculation.NumberOfItems
+
+	return response
+}
@@ -1,40 +1,122 @@
 package service
 
 import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"math"
+	"server/internal/metrics"
 	"server/internal/model"
 	"sort"
+	"strconv"
+	"time"
 )
 
-const largePackItemsBuffer = 50
+// how many items of the DP table are computed between two checks of the context
+const contextCheckInterval = 4096
+
+// PackagingObjective describes what the packaging calculation optimizes:
+// the least number of items first, then the least number of packs.
+const PackagingObjective = "min-items-then-min-packs"
 
 type PackagingService interface {
-	PackItems(numberOfItems int) (map[int]int, error)
+	PackItems(ctx context.Context, numberOfItems int) (map[int]int, error)
+	Calculate(ctx context.Context, numberOfItems int) (*model.PackagingCalculation, error)
+	// Solve packs the items with the packs configuration, instead of the current one.
+	Solve(ctx context.Context, numberOfItems int, packsConfig *model.PacksConfig) (*model.PackagingCalculation, error)
 }
 
 type PackagingServiceImpl struct {
-	packsService PacksService
+	packsService         PacksService
+	largePackItemsBuffer int
 }
 
 func NewPackagingService(packsService PacksService) PackagingService {
+	return NewPackagingServiceWithItemsBuffer(packsService, model.DefaultLargePackItemsBuffer)
+}
+
+// NewPackagingServiceWithItemsBuffer creates a packaging service leaving largePackItemsBuffer of the largest packs
+// to the DP calculation. A larger buffer costs more memory and time, but the result is only better
+// when no combination of the smaller packs makes up for the largest one within the buffer.
+func NewPackagingServiceWithItemsBuffer(packsService PacksService, largePackItemsBuffer int) PackagingService {
 	return &PackagingServiceImpl{
-		packsService: packsService,
+		packsService:         packsService,
+		largePackItemsBuffer: largePackItemsBuffer,
+	}
+}
+
+func (service PackagingServiceImpl) PackItems(ctx context.Context, numberOfItems int) (map[int]int, error) {
+	calculation, err := service.Calculate(ctx, numberOfItems)
+	if err != nil {
+		return nil, err
 	}
+
+	return calculation.Packs, nil
 }
 
-func (service PackagingServiceImpl) PackItems(numberOfItems int) (map[int]int, error) {
-	packSizes, err := service.packsService.GetPacks()
+func (service PackagingServiceImpl) Calculate(ctx context.Context, numberOfItems int) (
+	*model.PackagingCalculation, error,
+) {
+	packsConfig, err := service.packsService.GetPacksConfig(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(packSizes) == 0 {
+	return service.Solve(ctx, numberOfItems, packsConfig)
+}
+
+func (service PackagingServiceImpl) Solve(
+	ctx context.Context, numberOfItems int, packsConfig *model.PacksConfig,
+) (*model.PackagingCalculation, error) {
+	if len(packsConfig.Sizes) == 0 {
 		return nil, &model.EmptyPacksConfig{}
 	}
 
+	start := time.Now()
+	packs, err := packItems(ctx, numberOfItems, packsConfig.Sizes, service.largePackItemsBuffer)
+	metrics.PackagingSolveDuration.WithLabelValues(metrics.Outcome(err)).Observe(metrics.Since(start))
+	if err != nil {
+		return nil, err
+	}
+
+	packedItems := 0
+	for size, quantity := range packs {
+		packedItems += size * quantity
+	}
+	metrics.PackagingOverage.Observe(float64(packedItems - numberOfItems))
+
+	return &model.PackagingCalculation{
+		NumberOfItems: numberOfItems,
+		Packs:         packs,
+		PackSizes:     packsConfig.Sizes,
+		PacksVersion:  packsConfig.Version,
+		Objective:     PackagingObjective,
+		Stale:         packsConfig.Stale,
+	}, nil
+}
+
+func packItems(ctx context.Context, numberOfItems int, sizes []int, largePackItemsBuffer int) (
+	finalPacks map[int]int, err error,
+) {
+	ctx, span := tracer.Start(
+		ctx, "PackagingService.PackItems", trace.WithAttributes(
+			attribute.Int("packaging.quantity", numberOfItems),
+			attribute.Int("packaging.pack_sizes", len(sizes)),
+		),
+	)
+	defer func() {
+		packCount := 0
+		for _, quantity := range finalPacks {
+			packCount += quantity
+		}
+		span.SetAttributes(attribute.Int("packaging.pack_count", packCount))
+		endSpan(span, err)
+	}()
+
+	packSizes := append([]int(nil), sizes...)
 	sort.Sort(sort.Reverse(sort.IntSlice(packSizes)))
 
-	finalPacks := make(map[int]int)
+	finalPacks = make(map[int]int)
 	largestPack := packSizes[0]
 
 	// Buffer to use when calculating a large number of items
@@ -43,7 +125,9 @@ func (service PackagingServiceImpl) PackItems(numberOfItems int) (map[int]int, e
 	bufferLimit := largestPack * largePackItemsBuffer
 	target := numberOfItems
 
-	if target > bufferLimit {
+	bulkShortcut := target > bufferLimit
+	metrics.PackagingSolves.WithLabelValues(strconv.FormatBool(bulkShortcut)).Inc()
+	if bulkShortcut {
 		remainder := target % largestPack
 		target = remainder + ((bufferLimit / largestPack) * largestPack)
 
@@ -57,6 +141,10 @@ func (service PackagingServiceImpl) PackItems(numberOfItems int) (map[int]int, e
 	// we are also keeping another array, that is tracking what was the last pack size used for that item.
 	// this array is needed for reconstruction purposes.
 	itemsToCheck := target + largestPack + 1
+	metrics.PackagingTableSize.Observe(float64(itemsToCheck))
+	span.SetAttributes(
+		attribute.Int("packaging.table_size", itemsToCheck), attribute.Bool("packaging.bulk_shortcut", bulkShortcut),
+	)
 	minPacksForItem := make([]int, itemsToCheck)
 	lastPackUsedForItem := make([]int, itemsToCheck)
 
@@ -68,6 +156,13 @@ func (service PackagingServiceImpl) PackItems(numberOfItems int) (map[int]int, e
 	minPacksForItem[0] = 0 // initial element, 0 packs are needed to make 0 items
 
 	for i := 1; i < itemsToCheck; i++ {
+		// the table can be large, so stop early when the caller is gone or the deadline has passed
+		if i%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
 		for _, pack := range packSizes {
 			if i >= pack {
 				if minPacksForItem[i-pack] != math.MaxInt32 {
@@ -1,29 +1,69 @@
 package service
 
 import (
-	"log"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"server/internal/metrics"
+	"server/internal/model"
 	"server/internal/repository"
+	"slices"
+	"sort"
+	"sync/atomic"
+	"time"
 )
 
 type PacksService interface {
-	GetPacks() ([]int, error)
-	SyncPacks(packs []int) error
+	GetPacks(ctx context.Context) ([]int, error)
+	GetPacksConfig(ctx context.Context) (*model.PacksConfig, error)
+	SyncPacks(ctx context.Context, packs []int) error
+	// CacheStats returns the statistics of the last known packs configuration.
+	CacheStats() model.PacksCacheStats
 }
 
 type PacksServiceImpl struct {
 	repository repository.PacksRepository
+	snapshot   repository.PacksSnapshotStore
+	lastKnown  *atomic.Pointer[[]int]
+	cache      *packsCacheCounters
+}
+
+// packsCacheCounters count the uses of the last known packs configuration.
+type packsCacheCounters struct {
+	storedAt atomic.Pointer[time.Time]
+	updates  atomic.Int64
+	hits     atomic.Int64
+	misses   atomic.Int64
 }
 
 func NewPacksService(repository repository.PacksRepository) PacksService {
+	return NewPacksServiceWithSnapshot(repository, nil)
+}
+
+// NewPacksServiceWithSnapshot creates a PacksService that persists the last known packs configuration
+// to the snapshot store, so it can keep serving it while the packs storage is unavailable, even after a restart.
+func NewPacksServiceWithSnapshot(
+	repository repository.PacksRepository, snapshot repository.PacksSnapshotStore,
+) PacksService {
 	return &PacksServiceImpl{
 		repository: repository,
+		snapshot:   snapshot,
+		lastKnown:  &atomic.Pointer[[]int]{},
+		cache:      &packsCacheCounters{},
 	}
 }
 
-func (service PacksServiceImpl) GetPacks() ([]int, error) {
-	packs, err := service.repository.FindAll()
+func (service PacksServiceImpl) GetPacks(ctx context.Context) ([]int, error) {
+	ctx, span := tracer.Start(ctx, "PacksService.GetPacks")
+	packs, err := service.repository.FindAll(ctx)
+	endSpan(span, err)
 	if err != nil {
-		return nil, err
+		return nil, toStorageError(err)
 	}
 
 	sizes := make([]int, len(packs))
@@ -34,13 +74,146 @@ func (service PacksServiceImpl) GetPacks() ([]int, error) {
 	return sizes, nil
 }
 
-func (service PacksServiceImpl) SyncPacks(packs []int) error {
-	log.Printf("Syncing packs: %v", packs)
+// GetPacksConfig returns the current packs configuration. When the packs storage is unavailable,
+// the last known configuration is returned instead, flagged as stale.
+func (service PacksServiceImpl) GetPacksConfig(ctx context.Context) (*model.PacksConfig, error) {
+	ctx, span := tracer.Start(ctx, "PacksService.GetPacksConfig")
+	packsConfig, err := service.getPacksConfig(ctx)
+	if packsConfig != nil {
+		span.SetAttributes(
+			attribute.String("packs.version", packsConfig.Version), attribute.Bool("packs.stale", packsConfig.Stale),
+		)
+	}
+	endSpan(span, err)
+
+	return packsConfig, err
+}
+
+func (service PacksServiceImpl) getPacksConfig(ctx context.Context) (*model.PacksConfig, error) {
+	sizes, err := service.GetPacks(ctx)
+	if err != nil {
+		var unavailableError *model.PacksStorageUnavailable
+		if errors.As(err, &unavailableError) {
+			if lastKnown := service.loadLastKnown(ctx); lastKnown != nil {
+				service.cache.hits.Add(1)
+				slog.WarnContext(ctx, "Using last known packs configuration", "packs", lastKnown, "error", err)
+				return &model.PacksConfig{
+					Sizes:   lastKnown,
+					Version: PacksConfigVersion(lastKnown),
+					Stale:   true,
+				}, nil
+			}
+			service.cache.misses.Add(1)
+		}
+		return nil, err
+	}
+
+	service.storeLastKnown(ctx, sizes)
 
-	if err := service.repository.SyncPacks(packs); err != nil {
-		log.Printf("Error syncing packs: %v", err)
+	return &model.PacksConfig{
+		Sizes:   sizes,
+		Version: PacksConfigVersion(sizes),
+	}, nil
+}
+
+// PacksConfigVersion returns a short, deterministic identifier of a set of pack sizes.
+// The same sizes always map to the same version, regardless of their order.
+func PacksConfigVersion(sizes []int) string {
+	sorted := append([]int(nil), sizes...)
+	sort.Ints(sorted)
+
+	hash := sha256.Sum256([]byte(fmt.Sprint(sorted)))
+	return hex.EncodeToString(hash[:])[:16]
+}
+
+func (service PacksServiceImpl) SyncPacks(ctx context.Context, packs []int) error {
+	slog.InfoContext(ctx, "Syncing packs", "packs", packs)
+
+	// a size that is not positive would break every packaging calculation
+	if _, err := repository.ValidPacks(packs); err != nil {
 		return err
 	}
 
+	ctx, span := tracer.Start(
+		ctx, "PacksService.SyncPacks", trace.WithAttributes(attribute.IntSlice("packs.sizes", packs)),
+	)
+	err := service.repository.SyncPacks(ctx, packs)
+	endSpan(span, err)
+	metrics.PacksSyncs.WithLabelValues(metrics.Outcome(err)).Inc()
+	if err != nil {
+		slog.ErrorContext(ctx, "Error syncing packs", "error", err)
+		return toStorageError(err)
+	}
+
 	return nil
 }
+
+func (service PacksServiceImpl) storeLastKnown(ctx context.Context, sizes []int) {
+	if current := service.lastKnown.Load(); current != nil && slices.Equal(*current, sizes) {
+		return
+	}
+
+	stored := slices.Clone(sizes)
+	service.lastKnown.Store(&stored)
+	service.storeCacheUpdate()
+
+	if service.snapshot != nil {
+		if err := service.snapshot.Save(stored); err != nil {
+			slog.ErrorContext(ctx, "Error saving packs snapshot", "error", err)
+		}
+	}
+}
+
+func (service PacksServiceImpl) loadLastKnown(ctx context.Context) []int {
+	if current := service.lastKnown.Load(); current != nil {
+		return slices.Clone(*current)
+	}
+
+	if service.snapshot == nil {
+		return nil
+	}
+
+	sizes, err := service.snapshot.Load()
+	if err != nil {
+		slog.ErrorContext(ctx, "Error loading packs snapshot", "error", err)
+		return nil
+	}
+
+	service.lastKnown.Store(&sizes)
+	service.storeCacheUpdate()
+	return slices.Clone(sizes)
+}
+
+func (service PacksServiceImpl) storeCacheUpdate() {
+	now := time.Now()
+	service.cache.storedAt.Store(&now)
+	service.cache.updates.Add(1)
+}
+
+func (service PacksServiceImpl) CacheStats() model.PacksCacheStats {
+	stats := model.PacksCacheStats{
+		Updates: service.cache.updates.Load(),
+		Hits:    service.cache.hits.Load(),
+		Misses:  service.cache.misses.Load(),
+	}
+	if current := service.lastKnown.Load(); current != nil {
+		stats.Cached = true
+		stats.Sizes = slices.Clone(*current)
+		stats.Version = PacksConfigVersion(*current)
+	}
+	if storedAt := service.cache.storedAt.Load(); storedAt != nil {
+		stats.StoredAt = *storedAt
+	}
+
+	return stats
+}
+
+// toStorageError marks the errors caused by the packs storage being down,
+// so they can be told apart from the other failures.
+func toStorageError(err error) error {
+	if repository.IsTransientError(err) {
+		return &model.PacksStorageUnavailable{Cause: err}
+	}
+
+	return err
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"server/internal/model"
+	"strings"
+)
+
+// signQuoteToken encodes the claims as <payload>.<signature>, the base64url of their JSON and of its HMAC-SHA256.
+func signQuoteToken(key []byte, claims model.QuoteClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+	return encodedPayload + "." + base64.RawURLEncoding.EncodeToString(quoteTokenSignature(key, encodedPayload)), nil
+}
+
+// verifyQuoteToken returns the claims of the token, or model.InvalidQuoteToken when it was not signed with the key.
+func verifyQuoteToken(key []byte, token string) (*model.QuoteClaims, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, &model.InvalidQuoteToken{Reason: "malformed token"}
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, quoteTokenSignature(key, encodedPayload)) {
+		return nil, &model.InvalidQuoteToken{Reason: "signature does not match"}
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, &model.InvalidQuoteToken{Reason: "malformed payload"}
+	}
+	var claims model.QuoteClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, &model.InvalidQuoteToken{Reason: "malformed payload"}
+	}
+
+	return &claims, nil
+}
+
+func quoteTokenSignature(key []byte, encodedPayload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(encodedPayload))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"log/slog"
+	"server/internal/model"
+	"server/internal/repository"
+	"time"
+)
+
+// expiredQuoteRetention is how long the expired quotes are kept, to tell their clients they expired
+// rather than that they do not exist
+const expiredQuoteRetention = 24 * time.Hour
+
+type QuotesService interface {
+	// Create computes the packaging of the items and keeps it as a quote, with the pack sizes it was computed with,
+	// until it expires. It returns model.PacksStorageUnavailable rather than quote with the last known
+	// packs configuration.
+	Create(ctx context.Context, numberOfItems int, caller string) (*model.QuoteResponse, error)
+	// Find returns the quote as it was created. It returns model.QuoteNotFound, or model.QuoteExpired.
+	Find(ctx context.Context, id string) (*model.QuoteResponse, error)
+	// Verify returns the claims of a quote token, without reading the quote. It returns model.InvalidQuoteToken,
+	// model.QuoteExpired, or model.QuoteTokensDisabled without a signing key.
+	Verify(token string) (*model.QuoteClaims, error)
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+type QuotesServiceImpl struct {
+	repository       repository.QuotesRepository
+	packagingService PackagingService
+	historyService   PackagingHistoryService
+	ttl              time.Duration
+	signingKey       []byte
+}
+
+// NewQuotesService creates a QuotesService whose quotes are valid for the ttl. With a signing key, the quotes
+// come with a token signed with it. The packaging of the quotes is recorded in the history, unless it is nil.
+func NewQuotesService(
+	repository repository.QuotesRepository, packagingService PackagingService, historyService PackagingHistoryService,
+	ttl time.Duration, signingKey []byte,
+) QuotesService {
+	return &QuotesServiceImpl{
+		repository:       repository,
+		packagingService: packagingService,
+		historyService:   historyService,
+		ttl:              ttl,
+		signingKey:       signingKey,
+	}
+}
+
+func (service *QuotesServiceImpl) Create(ctx context.Context, numberOfItems int, caller string) (
+	*model.QuoteResponse, error,
+) {
+	calculation, err := service.packagingService.Calculate(ctx, numberOfItems)
+	if err != nil {
+		return nil, err
+	}
+	if calculation.Stale {
+		return nil, &model.PacksStorageUnavailable{}
+	}
+	RecordPackaging(ctx, service.historyService, calculation, caller)
+
+	// the DB keeps microseconds, so the quote is the same once read back
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	quote := &model.Quote{
+		ID:            uuid.NewString(),
+		NumberOfItems: numberOfItems,
+		Packs:         calculation.Packs,
+		PackSizes:     calculation.PackSizes,
+		PacksVersion:  calculation.PacksVersion,
+		Objective:     calculation.Objective,
+		Caller:        caller,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(service.ttl),
+	}
+	if err := service.repository.Create(ctx, quote); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(
+		ctx, "Quote created", "quote_id", quote.ID, "quantity", numberOfItems,
+		"expires_at", quote.ExpiresAt.Format(time.RFC3339),
+	)
+
+	return service.toQuoteResponse(quote)
+}
+
+func (service *QuotesServiceImpl) Find(ctx context.Context, id string) (*model.QuoteResponse, error) {
+	quote, err := service.repository.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if quote == nil {
+		return nil, &model.QuoteNotFound{ID: id}
+	}
+	if !time.Now().Before(quote.ExpiresAt) {
+		return nil, &model.QuoteExpired{ID: id, ExpiresAt: quote.ExpiresAt}
+	}
+
+	return service.toQuoteResponse(quote)
+}
+
+func (service *QuotesServiceImpl) Verify(token string) (*model.QuoteClaims, error) {
+	if len(service.signingKey) == 0 {
+		return nil, &model.QuoteTokensDisabled{}
+	}
+
+	claims, err := verifyQuoteToken(service.signingKey, token)
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(claims.ExpiresAt) {
+		return nil, &model.QuoteExpired{ID: claims.ID, ExpiresAt: claims.ExpiresAt}
+	}
+
+	return claims, nil
+}
+
+func (service *QuotesServiceImpl) PurgeExpired(ctx context.Context) (int64, error) {
+	return service.repository.DeleteExpired(ctx, time.Now().Add(-expiredQuoteRetention))
+}
+
+func (service *QuotesServiceImpl) toQuoteResponse(quote *model.Quote) (*model.QuoteResponse, error) {
+	lines := PackagingLines(quote.Packs)
+
+	response := &model.QuoteResponse{
+		ID:            quote.ID,
+		NumberOfItems: quote.NumberOfItems,
+		Lines:         lines,
+		PackSizes:     quote.PackSizes,
+		PacksVersion:  quote.PacksVersion,
+		Objective:     quote.Objective,
+		CreatedAt:     quote.CreatedAt,
+		ExpiresAt:     quote.ExpiresAt,
+	}
+	for _, line := range lines {
+		response.TotalPacks += line.Quantity
+		response.TotalItems += line.Items
+	}
+	response.Overage = response.TotalItems - quote.NumberOfItems
+
+	if len(service.signingKey) > 0 {
+		token, err := signQuoteToken(
+			service.signingKey, model.QuoteClaims{
+				ID:            quote.ID,
+				NumberOfItems: quote.NumberOfItems,
+				Packs:         quote.Packs,
+				PacksVersion:  quote.PacksVersion,
+				ExpiresAt:     quote.ExpiresAt,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		response.Token = token
+	}
+
+	return response, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"server/internal/model"
+	"server/internal/repository"
+	"strings"
+	"time"
+)
+
+// idleRateLimitBucketRetention is how long a bucket is kept after its last use. It is longer than any bucket
+// takes to refill and than a quota day, so deleting the bucket after it changes nothing.
+const idleRateLimitBucketRetention = 25 * time.Hour
+
+type RateLimitService interface {
+	// Take takes a request of the client from the limit of the route. It returns nil when the route is not limited.
+	Take(ctx context.Context, route string, client string) (*model.RateLimitDecision, error)
+	// LimitOf returns the limit of the route, nil when the route is not limited.
+	LimitOf(route string) *model.RateLimit
+	// TakeForIP takes a request of the client IP from its limit on all the routes. It returns nil when
+	// the IPs are not limited.
+	TakeForIP(ctx context.Context, ip string) (*model.RateLimitDecision, error)
+	// IPLimit returns the limit of each client IP, nil when the IPs are not limited.
+	IPLimit() *model.RateLimit
+	PurgeIdle(ctx context.Context) (int64, error)
+}
+
+type RateLimitServiceImpl struct {
+	repository repository.RateLimitRepository
+	limits     model.RateLimitsFile
+}
+
+func NewRateLimitService(repository repository.RateLimitRepository, limits model.RateLimitsFile) RateLimitService {
+	return &RateLimitServiceImpl{
+		repository: repository,
+		limits:     limits,
+	}
+}
+
+// ValidateRateLimits reports all the invalid limits of the configuration at once.
+func ValidateRateLimits(limits model.RateLimitsFile) error {
+	var problems []string
+	if limits.Default != nil {
+		problems = append(problems, rateLimitProblems("default", *limits.Default)...)
+	}
+	if limits.IP != nil {
+		problems = append(problems, rateLimitProblems("ip", *limits.IP)...)
+	}
+	for route, limit := range limits.Routes {
+		method, path, ok := strings.Cut(route, " ")
+		if !ok || method == "" || !strings.HasPrefix(path, "/") {
+			problems = append(problems, fmt.Sprintf("route %q must be a method and a path, e.g. \"POST /api/package\"", route))
+		}
+		problems = append(problems, rateLimitProblems(route, limit)...)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid rate limits: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+func rateLimitProblems(name string, limit model.RateLimit) []string {
+	var problems []string
+	if limit.RequestsPerSecond <= 0 {
+		problems = append(problems, fmt.Sprintf("%s: requestsPerSecond must be positive", name))
+	}
+	if limit.Burst < 1 {
+		problems = append(problems, fmt.Sprintf("%s: burst must be at least 1", name))
+	}
+	if limit.DailyQuota < 0 {
+		problems = append(problems, fmt.Sprintf("%s: dailyQuota must not be negative", name))
+	}
+	return problems
+}
+
+func (service *RateLimitServiceImpl) Take(ctx context.Context, route string, client string) (
+	*model.RateLimitDecision, error,
+) {
+	limit := service.LimitOf(route)
+	if limit == nil {
+		return nil, nil
+	}
+
+	decision, err := service.repository.Take(ctx, route+"|"+client, *limit, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &decision, nil
+}
+
+func (service *RateLimitServiceImpl) LimitOf(route string) *model.RateLimit {
+	if limit, ok := service.limits.Routes[route]; ok {
+		return &limit
+	}
+
+	return service.limits.Default
+}
+
+func (service *RateLimitServiceImpl) TakeForIP(ctx context.Context, ip string) (*model.RateLimitDecision, error) {
+	if service.limits.IP == nil {
+		return nil, nil
+	}
+
+	// the routes are keyed by "<method> <path>|", so the IP buckets can not be mistaken for a route's
+	decision, err := service.repository.Take(ctx, "ip|"+ip, *service.limits.IP, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &decision, nil
+}
+
+func (service *RateLimitServiceImpl) IPLimit() *model.RateLimit {
+	return service.limits.IP
+}
+
+func (service *RateLimitServiceImpl) PurgeIdle(ctx context.Context) (int64, error) {
+	return service.repository.DeleteIdle(ctx, time.Now().Add(-idleRateLimitBucketRetention))
+}
@@ -0,0 +1,122 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertificateReloader keeps the server certificate and the client CAs loaded from their files,
+// and reloads them when the files change, e.g. when the certificate is rotated.
+type CertificateReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mutex       sync.RWMutex
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+	modTimes    map[string]time.Time
+}
+
+// NewCertificateReloader loads the certificate and its key, and the client CAs when clientCAFile is not empty.
+func NewCertificateReloader(certFile, keyFile, clientCAFile string) (*CertificateReloader, error) {
+	reloader := &CertificateReloader{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+	if _, err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// Reload reloads the files when any of them changed since the last load, and reports whether they were reloaded.
+// When the files can not be loaded (e.g. the certificate was rotated but not its key yet), the previously loaded
+// ones are kept, and the next Reload tries again.
+func (reloader *CertificateReloader) Reload() (bool, error) {
+	modTimes, err := reloader.readModTimes()
+	if err != nil {
+		return false, err
+	}
+
+	reloader.mutex.RLock()
+	changed := reloader.modTimes == nil || !equalModTimes(reloader.modTimes, modTimes)
+	reloader.mutex.RUnlock()
+	if !changed {
+		return false, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("loading certificate %s: %w", reloader.certFile, err)
+	}
+
+	var clientCAs *x509.CertPool
+	if reloader.clientCAFile != "" {
+		content, err := os.ReadFile(reloader.clientCAFile)
+		if err != nil {
+			return false, fmt.Errorf("loading client CAs %s: %w", reloader.clientCAFile, err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(content) {
+			return false, fmt.Errorf("loading client CAs %s: no PEM certificate found", reloader.clientCAFile)
+		}
+	}
+
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+	reloader.certificate = &certificate
+	reloader.clientCAs = clientCAs
+	reloader.modTimes = modTimes
+
+	return true, nil
+}
+
+// GetCertificate returns the current certificate, to be used as tls.Config.GetCertificate.
+func (reloader *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	reloader.mutex.RLock()
+	defer reloader.mutex.RUnlock()
+
+	return reloader.certificate, nil
+}
+
+// ClientCAs returns the current client CAs, nil when there is no client CA file.
+func (reloader *CertificateReloader) ClientCAs() *x509.CertPool {
+	reloader.mutex.RLock()
+	defer reloader.mutex.RUnlock()
+
+	return reloader.clientCAs
+}
+
+func (reloader *CertificateReloader) readModTimes() (map[string]time.Time, error) {
+	modTimes := map[string]time.Time{}
+	for _, file := range []string{reloader.certFile, reloader.keyFile, reloader.clientCAFile} {
+		if file == "" {
+			continue
+		}
+
+		// Stat follows the symlinks, so the files rotated by swapping a symlink (e.g. Kubernetes secrets) are seen
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[file] = info.ModTime()
+	}
+
+	return modTimes, nil
+}
+
+func equalModTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for file, modTime := range a {
+		if !modTime.Equal(b[file]) {
+			return false
+		}
+	}
+
+	return true
+}
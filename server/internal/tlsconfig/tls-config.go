@@ -0,0 +1,89 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"fmt"
+	"server/internal/model"
+	"strings"
+)
+
+// NewServerConfig creates the TLS config of the servers, serving the certificate of the reloader, and verifying
+// the client certificates against its client CAs with mutual TLS.
+// An incoherent config is rejected with all its problems.
+func NewServerConfig(config model.TlsConfig, reloader *CertificateReloader) (*tls.Config, error) {
+	if err := ValidateTlsConfig(config); err != nil {
+		return nil, err
+	}
+
+	clientAuth := tls.NoClientCert
+	switch ClientAuthOf(config) {
+	case model.ClientAuthOptional:
+		clientAuth = tls.VerifyClientCertIfGiven
+	case model.ClientAuthRequire:
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	base := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"h2", "http/1.1"},
+		GetCertificate: reloader.GetCertificate,
+		ClientAuth:     clientAuth,
+	}
+	if clientAuth == tls.NoClientCert {
+		return base, nil
+	}
+
+	// the client CAs can be rotated as well, so they are read on every handshake
+	server := base.Clone()
+	server.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		config := base.Clone()
+		config.ClientCAs = reloader.ClientCAs()
+		return config, nil
+	}
+
+	return server, nil
+}
+
+// ClientAuthOf returns the client authentication of the config, with its default applied.
+func ClientAuthOf(config model.TlsConfig) string {
+	switch {
+	case config.ClientAuth != "":
+		return config.ClientAuth
+	case config.ClientCAFile != "":
+		return model.ClientAuthRequire
+	default:
+		return model.ClientAuthNone
+	}
+}
+
+func ValidateTlsConfig(config model.TlsConfig) error {
+	var problems []string
+	if config.CertFile == "" || config.KeyFile == "" {
+		problems = append(problems, "both the certificate and the key files must be set")
+	}
+
+	switch clientAuth := ClientAuthOf(config); clientAuth {
+	case model.ClientAuthNone:
+		if config.ClientCAFile != "" {
+			problems = append(problems, "the client CA file is set, but the client certificates are not verified")
+		}
+	case model.ClientAuthOptional, model.ClientAuthRequire:
+		if config.ClientCAFile == "" {
+			problems = append(problems, fmt.Sprintf("client auth %s needs the client CA file", clientAuth))
+		}
+	default:
+		problems = append(
+			problems, fmt.Sprintf("client auth %q must be one of none, optional or require", clientAuth),
+		)
+	}
+
+	if config.ReloadInterval < 0 {
+		problems = append(problems, "the reload interval must not be negative")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid TLS config: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
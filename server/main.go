@@ -1,15 +1,242 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"google.golang.org/grpc"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"server/internal/appcontext"
+	"server/internal/config"
 	"server/internal/controller"
+	"server/internal/grpcapi"
+	"server/internal/logging"
+	"server/internal/tracing"
+	"strings"
+	"syscall"
+	"time"
 )
 
+const usage = `Usage: server [serve] [flags]
+       server solve <qty> [flags]
+       server packs list|sync|export|import [flags]
+       server config print [flags]`
+
+// how long the job workers have to put their jobs back in the queue on shutdown
+const jobWorkersStopTimeout = 10 * time.Second
+
 func main() {
-	appContext := appcontext.BuildAppContext()
-	r := controller.SetupRouter(appContext)
-	if err := r.Run(":8080"); err != nil {
-		log.Panic("Failed to run server", err)
+	args := os.Args[1:]
+	// without a command, e.g. "server -http.port 8081", the server is run as before the subcommands
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		serve(loadConfig("server", args))
+		return
+	}
+
+	switch args[0] {
+	case "serve":
+		serve(loadConfig("serve", args[1:]))
+	case "solve":
+		runSolveCommand(args[1:])
+	case "packs":
+		runPacksCommand(args[1:])
+	case "config":
+		runConfigCommand(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// loadConfig loads the config from the args and the env, exiting on an invalid config.
+func loadConfig(command string, args []string) *config.Config {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	loader := config.NewLoader(flags)
+	_ = flags.Parse(args)
+	if flags.NArg() > 0 {
+		fail("Unexpected arguments %v, see %s -h", flags.Args(), command)
+	}
+
+	loaded, err := loader.Load()
+	if err != nil {
+		fail("Error loading the config: %v", err)
+	}
+
+	return loaded
+}
+
+func serve(loaded *config.Config) {
+	// the levels are validated with the config
+	levels, _ := logging.ParseLevels(loaded.Log.Level, loaded.Log.Packages)
+	logging.Setup(os.Stderr, levels)
+
+	shutdownTracing, err := tracing.Setup(loaded.Tracing)
+	if err != nil {
+		logging.Fatal("Error setting up tracing", "error", err)
+	}
+	appContext := appcontext.BuildAppContext(loaded)
+
+	var grpcServer *grpc.Server
+	if appContext.GrpcAddress != "" {
+		grpcServer = grpcapi.NewGrpcServer(appContext)
+		go runGrpcServer(grpcServer, appContext.GrpcAddress)
+	}
+
+	config := appContext.HttpServerConfig
+	if config.WriteTimeout > 0 && appContext.MaxRequestTimeout > config.WriteTimeout {
+		slog.Warn(
+			"HTTP write timeout is shorter than the max request timeout, the responses of the slow requests will be lost",
+			"write_timeout", config.WriteTimeout.String(), "max_request_timeout", appContext.MaxRequestTimeout.String(),
+		)
+	}
+
+	httpServer := controller.NewHttpServer(config, appContext.TlsConfig, controller.SetupRouter(appContext))
+	runHttpServer(httpServer, "HTTP")
+
+	var adminServer *http.Server
+	if appContext.AdminAddress != "" {
+		adminConfig := config
+		adminConfig.Address = appContext.AdminAddress
+		adminServer = controller.NewHttpServer(
+			adminConfig, appContext.TlsConfig, controller.SetupAdminRouter(appContext),
+		)
+		runHttpServer(adminServer, "admin")
+	}
+
+	stopJobWorkers := func(context.Context) {}
+	if appContext.PackagingJobsService != nil {
+		stopJobWorkers = runJobWorkers(appContext)
+	}
+	appContext.HealthService.MarkStarted()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	<-ctx.Done()
+	// a second signal kills the app right away
+	stop()
+
+	shutdown(appContext, httpServer, adminServer, grpcServer, stopJobWorkers)
+
+	// the spans of the last requests are flushed once they are all done
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Error flushing the traces", "error", err)
+	}
+}
+
+// runHttpServer listens on the address of the server, exiting when it can not, and serves in the background.
+// name tells the servers apart in the logs.
+func runHttpServer(server *http.Server, name string) {
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		logging.Fatal("Failed to listen for HTTP", "server", name, "address", server.Addr, "error", err)
+	}
+
+	go func() {
+		var err error
+		if server.TLSConfig != nil {
+			slog.Info("Running HTTPS server", "server", name, "address", server.Addr)
+			// the certificate comes from the TLS config, which reloads it when it is rotated
+			err = server.ServeTLS(listener, "", "")
+		} else {
+			slog.Info("Running HTTP server", "server", name, "address", server.Addr)
+			err = server.Serve(listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Fatal("Failed to run HTTP server", "server", name, "error", err)
+		}
+	}()
+}
+
+// runJobWorkers starts the packaging job workers, and returns the function that stops them,
+// waiting for them to put their jobs back in the queue until ctx is done.
+func runJobWorkers(appContext *appcontext.AppContext) func(ctx context.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		appContext.PackagingJobsService.Run(ctx)
+		close(done)
+	}()
+
+	return func(shutdownCtx context.Context) {
+		cancel()
+		select {
+		case <-done:
+		case <-shutdownCtx.Done():
+			slog.Warn("Packaging job workers did not stop in time")
+		}
+	}
+}
+
+// shutdown stops routing new requests to the app, lets the in-flight requests complete within the shutdown timeout
+// while the job workers are stopped, and then closes the DB connections.
+// The workers have their own timeout, so that the slow requests do not leave them no time to release their jobs.
+func shutdown(
+	appContext *appcontext.AppContext, httpServer *http.Server, adminServer *http.Server, grpcServer *grpc.Server,
+	stopJobWorkers func(ctx context.Context),
+) {
+	config := appContext.HttpServerConfig
+	slog.Info(
+		"Shutting down, waiting for the in-flight requests",
+		"timeout", (config.ShutdownDelay + config.ShutdownTimeout).String(),
+	)
+
+	appContext.HealthService.MarkShuttingDown()
+	time.Sleep(config.ShutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	if grpcServer != nil {
+		go func() {
+			<-ctx.Done()
+			grpcServer.Stop()
+		}()
+	}
+
+	// the jobs being processed are resumed by another replica, or after the restart
+	jobWorkersStopped := make(chan struct{})
+	go func() {
+		defer close(jobWorkersStopped)
+		workersCtx, cancelWorkers := context.WithTimeout(context.Background(), jobWorkersStopTimeout)
+		defer cancelWorkers()
+		stopJobWorkers(workersCtx)
+	}()
+
+	if err := controller.ShutdownHttpServer(ctx, httpServer); err != nil {
+		slog.Error("Error shutting down the HTTP server", "error", err)
+	}
+	// the admin server stays up until the API requests are done, to diagnose the slow ones
+	if adminServer != nil {
+		if err := controller.ShutdownHttpServer(ctx, adminServer); err != nil {
+			slog.Error("Error shutting down the admin server", "error", err)
+		}
+	}
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+	// the workers need the DB to put their jobs back in the queue
+	<-jobWorkersStopped
+	if err := appContext.Close(); err != nil {
+		slog.Error("Error closing the DB connections", "error", err)
+	}
+
+	slog.Info("Shut down")
+}
+
+func runGrpcServer(grpcServer *grpc.Server, address string) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		logging.Fatal("Failed to listen for gRPC", "address", address, "error", err)
+	}
+
+	slog.Info("Running gRPC server", "address", address)
+	if err := grpcServer.Serve(listener); err != nil {
+		logging.Fatal("Failed to run gRPC server", "error", err)
 	}
 }
@@ -0,0 +1,51 @@
+-- schema version 1: the packaging history, the idempotency keys, the rate limits and the schema version itself,
+-- on top of the packs table. The tables may already exist, as they were added before the schema was versioned
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS packaging_results
+(
+    id              BIGSERIAL PRIMARY KEY,
+    number_of_items BIGINT       NOT NULL,
+    packs           JSONB        NOT NULL,
+    pack_sizes      JSONB        NOT NULL,
+    objective       VARCHAR(64)  NOT NULL,
+    packs_version   VARCHAR(64)  NOT NULL,
+    caller          VARCHAR(255) NOT NULL,
+    created_at      TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS packaging_results_created_at_idx ON packaging_results (created_at);
+
+CREATE TABLE IF NOT EXISTS idempotency_records
+(
+    scope        VARCHAR(255) NOT NULL,
+    key          VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64)  NOT NULL,
+    completed    BOOLEAN      NOT NULL,
+    status_code  INTEGER      NOT NULL,
+    headers      JSONB        NOT NULL,
+    body         BYTEA,
+    created_at   TIMESTAMPTZ  NOT NULL,
+    expires_at   TIMESTAMPTZ  NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_records_expires_at_idx ON idempotency_records (expires_at);
+
+CREATE TABLE IF NOT EXISTS rate_limit_buckets
+(
+    key        VARCHAR(512)     PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ      NOT NULL,
+    quota_day  VARCHAR(10)      NOT NULL,
+    quota_used INTEGER          NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS schema_version
+(
+    version INTEGER PRIMARY KEY
+);
+
+INSERT INTO schema_version (version) VALUES (1);
+
+COMMIT;
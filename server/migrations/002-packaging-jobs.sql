@@ -0,0 +1,36 @@
+-- schema version 2: the asynchronous bulk packaging jobs
+BEGIN;
+
+CREATE TABLE packaging_jobs
+(
+    id              VARCHAR(36)  PRIMARY KEY,
+    status          VARCHAR(16)  NOT NULL,
+    total_lines     INTEGER      NOT NULL,
+    processed_lines INTEGER      NOT NULL,
+    failed_lines    INTEGER      NOT NULL,
+    error           TEXT         NOT NULL,
+    caller          VARCHAR(255) NOT NULL,
+    created_at      TIMESTAMPTZ  NOT NULL,
+    started_at      TIMESTAMPTZ,
+    completed_at    TIMESTAMPTZ,
+    heartbeat_at    TIMESTAMPTZ
+);
+
+CREATE INDEX packaging_jobs_status_created_at_idx ON packaging_jobs (status, created_at);
+
+CREATE TABLE packaging_job_lines
+(
+    job_id          VARCHAR(36)  NOT NULL REFERENCES packaging_jobs (id) ON DELETE CASCADE,
+    line_number     INTEGER      NOT NULL,
+    order_id        VARCHAR(255) NOT NULL,
+    number_of_items BIGINT       NOT NULL,
+    processed       BOOLEAN      NOT NULL,
+    packs           JSONB,
+    packs_version   VARCHAR(64)  NOT NULL,
+    error           TEXT         NOT NULL,
+    PRIMARY KEY (job_id, line_number)
+);
+
+INSERT INTO schema_version (version) VALUES (2);
+
+COMMIT;
@@ -0,0 +1,26 @@
+-- schema version 3: the orders
+BEGIN;
+
+CREATE TABLE orders
+(
+    id                 VARCHAR(36)  PRIMARY KEY,
+    customer_reference VARCHAR(255) NOT NULL,
+    number_of_items    BIGINT       NOT NULL,
+    packs              JSONB        NOT NULL,
+    packs_version      VARCHAR(64)  NOT NULL,
+    status             VARCHAR(16)  NOT NULL,
+    caller             VARCHAR(255) NOT NULL,
+    created_at         TIMESTAMPTZ  NOT NULL,
+    updated_at         TIMESTAMPTZ  NOT NULL,
+    quoted_at          TIMESTAMPTZ,
+    confirmed_at       TIMESTAMPTZ,
+    packed_at          TIMESTAMPTZ,
+    shipped_at         TIMESTAMPTZ,
+    cancelled_at       TIMESTAMPTZ
+);
+
+CREATE INDEX orders_customer_reference_idx ON orders (customer_reference);
+
+INSERT INTO schema_version (version) VALUES (3);
+
+COMMIT;
@@ -0,0 +1,21 @@
+-- schema version 4: the quotes
+BEGIN;
+
+CREATE TABLE quotes
+(
+    id              VARCHAR(36)  PRIMARY KEY,
+    number_of_items BIGINT       NOT NULL,
+    packs           JSONB        NOT NULL,
+    pack_sizes      JSONB        NOT NULL,
+    packs_version   VARCHAR(64)  NOT NULL,
+    objective       VARCHAR(64)  NOT NULL,
+    caller          VARCHAR(255) NOT NULL,
+    created_at      TIMESTAMPTZ  NOT NULL,
+    expires_at      TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX quotes_expires_at_idx ON quotes (expires_at);
+
+INSERT INTO schema_version (version) VALUES (4);
+
+COMMIT;
@@ -0,0 +1,9 @@
+-- schema version 5: the packs configuration the packaging jobs are packed with
+BEGIN;
+
+ALTER TABLE packaging_jobs ADD COLUMN pack_sizes JSONB;
+ALTER TABLE packaging_jobs ADD COLUMN packs_version VARCHAR(64) NOT NULL DEFAULT '';
+
+INSERT INTO schema_version (version) VALUES (5);
+
+COMMIT;
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"server/internal/cli"
+	"server/internal/model"
+	"server/internal/repository"
+	"strings"
+)
+
+const packsUsage = "Usage: server packs list|sync|export|import [flags]"
+
+// runPacksCommand runs the packs subcommands: "packs list", "packs sync <sizes>", "packs export [file]"
+// and "packs import <file>".
+func runPacksCommand(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, packsUsage)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "list":
+		listPacks(args[1:])
+	case "sync":
+		syncPacks(args[1:])
+	case "export":
+		exportPacks(args[1:])
+	case "import":
+		importPacks(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, packsUsage)
+		os.Exit(2)
+	}
+}
+
+func listPacks(args []string) {
+	clientFlags := newClientFlags("packs list", "packs list")
+	clientFlags.parse(args, 0, 0)
+
+	clientFlags.run(func(ctx context.Context, client cli.Client) error {
+		packs, err := client.GetPacks(ctx)
+		if err != nil {
+			return err
+		}
+		if clientFlags.json {
+			return printJSON(packs)
+		}
+
+		sizes := make([]string, len(packs.Packs))
+		for i, size := range packs.Packs {
+			sizes[i] = fmt.Sprint(size)
+		}
+		fmt.Printf("Pack sizes: %s\nVersion: %s\n", strings.Join(sizes, ", "), packs.Version)
+		if packs.Stale {
+			fmt.Println("The pack sizes may be outdated")
+		}
+
+		return nil
+	})
+}
+
+func syncPacks(args []string) {
+	clientFlags := newClientFlags("packs sync", "packs sync <size>[,<size>...]")
+	positional := clientFlags.parse(args, 1, 1<<16)
+
+	sizes, err := cli.ParsePackSizes(positional...)
+	if err != nil {
+		fail("Error: %v", err)
+	}
+
+	clientFlags.run(func(ctx context.Context, client cli.Client) error {
+		return replacePacks(ctx, client, sizes)
+	})
+}
+
+// exportPacks writes the pack sizes as a packs file, to the file or else to stdout.
+func exportPacks(args []string) {
+	clientFlags := newClientFlags("packs export", "packs export [file]")
+	positional := clientFlags.parse(args, 0, 1)
+
+	clientFlags.run(func(ctx context.Context, client cli.Client) error {
+		packs, err := client.GetPacks(ctx)
+		if err != nil {
+			return err
+		}
+
+		path := "stdout.yaml"
+		if len(positional) > 0 {
+			path = positional[0]
+		} else if clientFlags.json {
+			path = "stdout.json"
+		}
+		content, err := repository.EncodePacksFile(path, model.PacksFile{Packs: packs.Packs})
+		if err != nil {
+			return err
+		}
+
+		if len(positional) == 0 {
+			_, err = os.Stdout.Write(content)
+			return err
+		}
+		return os.WriteFile(path, content, 0o644)
+	})
+}
+
+// importPacks replaces the pack sizes with the ones of a packs file, YAML or JSON.
+func importPacks(args []string) {
+	clientFlags := newClientFlags("packs import", "packs import <file>")
+	positional := clientFlags.parse(args, 1, 1)
+
+	content, err := os.ReadFile(positional[0])
+	if err != nil {
+		fail("Error reading the packs file: %v", err)
+	}
+	file, err := repository.DecodePacksFile(content)
+	if err != nil {
+		fail("Error reading the packs file %s: %v", positional[0], err)
+	}
+	if len(file.Packs) == 0 {
+		fail("Error reading the packs file %s: no pack sizes", positional[0])
+	}
+	if _, err := repository.ValidPacks(file.Packs); err != nil {
+		fail("Error reading the packs file %s: %v", positional[0], err)
+	}
+
+	clientFlags.run(func(ctx context.Context, client cli.Client) error {
+		return replacePacks(ctx, client, file.Packs)
+	})
+}
+
+func replacePacks(ctx context.Context, client cli.Client, sizes []int) error {
+	if err := client.SyncPacks(ctx, sizes); err != nil {
+		return err
+	}
+
+	fmt.Printf("Pack sizes replaced with %v\n", sizes)
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"server/internal/cli"
+	"server/internal/model"
+	"server/internal/service"
+	"strconv"
+	"text/tabwriter"
+)
+
+// runSolveCommand runs "solve <qty>", printing the packs to ship the items with. With -packs the packs
+// are calculated locally with the given pack sizes, without the server or the storage.
+func runSolveCommand(args []string) {
+	clientFlags := newClientFlags("solve", "solve <qty>")
+	packs := clientFlags.flags.String("packs", "", "pack sizes to solve with, e.g. 250,500,1000")
+	positional := clientFlags.parse(args, 1, 1)
+
+	numberOfItems, err := strconv.Atoi(positional[0])
+	if err != nil || numberOfItems <= 0 {
+		fail("The quantity must be a positive integer, got %q", positional[0])
+	}
+
+	solve := func(ctx context.Context, client cli.Client) error {
+		response, err := client.Solve(ctx, numberOfItems)
+		if err != nil {
+			return err
+		}
+		if clientFlags.json {
+			return printJSON(response)
+		}
+
+		return printPackaging(response)
+	}
+
+	if *packs == "" {
+		clientFlags.run(solve)
+		return
+	}
+
+	sizes, err := cli.ParsePackSizes(*packs)
+	if err != nil {
+		fail("Error: %v", err)
+	}
+	packsService, err := cli.NewStaticPacksService(sizes)
+	if err != nil {
+		fail("Error: %v", err)
+	}
+	// the packs given on the command line are not a configuration of the service, so the packaging is not recorded
+	packagingService := service.NewPackagingService(packsService)
+	if err := solve(context.Background(), cli.NewServiceClient(packagingService, packsService, nil)); err != nil {
+		fail("Error: %v", err)
+	}
+}
+
+// printPackaging prints the packaging as a table of the packs, from the largest pack size to the smallest.
+func printPackaging(response *model.ProductPackageResponseV2) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "PACK SIZE\tQUANTITY\tITEMS\t")
+	for _, line := range response.Lines {
+		fmt.Fprintf(writer, "%d\t%d\t%d\t\n", line.PackSize, line.Quantity, line.Items)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf(
+		"\n%d packs, %d items for %d ordered, %d extra\n",
+		response.TotalPacks, response.TotalItems, response.NumberOfItems, response.Overage,
+	)
+	if response.Stale {
+		fmt.Printf("The pack sizes %s may be outdated\n", response.PacksVersion)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package itest
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/gin-gonic/gin"
@@ -10,6 +11,7 @@ import (
 	"net/http/httptest"
 	"server/internal/appcontext"
 	"server/internal/controller"
+	"server/internal/model"
 	"server/internal/service"
 	"server/test/stub"
 	"testing"
@@ -19,7 +21,7 @@ func TestPacksGet(t *testing.T) {
 	// given
 	gin.SetMode(gin.TestMode)
 
-	appContext := appcontext.BuildAppContext()
+	appContext := buildAppContext()
 	// insert initial date
 	initSql := `INSERT INTO packs(size) VALUES (100), (200), (1000);`
 	if err := appContext.DB.Exec(initSql).Error; err != nil {
@@ -72,7 +74,7 @@ func TestPacksSync_ValidRequest_EmptyState(t *testing.T) {
 	// given
 	gin.SetMode(gin.TestMode)
 
-	appContext := appcontext.BuildAppContext()
+	appContext := buildAppContext()
 	defer func() {
 		if err := cleanupDb(appContext.DB); err != nil {
 			t.Fatal(err)
@@ -91,7 +93,7 @@ func TestPacksSync_ValidRequest_EmptyState(t *testing.T) {
 	// then
 	assert.Equal(t, http.StatusOK, response.Code)
 
-	sizes, err := appContext.PacksService.GetPacks()
+	sizes, err := appContext.PacksService.GetPacks(context.Background())
 	assert.Nil(t, err)
 	assert.Equal(t, []int{100, 200, 1000}, sizes)
 }
@@ -100,7 +102,7 @@ func TestPacksSync_ValidRequest_NotEmptyState(t *testing.T) {
 	// given
 	gin.SetMode(gin.TestMode)
 
-	appContext := appcontext.BuildAppContext()
+	appContext := buildAppContext()
 
 	// insert initial date
 	initSql := `INSERT INTO packs(size) VALUES (100), (200), (1000);`
@@ -126,7 +128,7 @@ func TestPacksSync_ValidRequest_NotEmptyState(t *testing.T) {
 	// then
 	assert.Equal(t, http.StatusOK, response.Code)
 
-	sizes, err := appContext.PacksService.GetPacks()
+	sizes, err := appContext.PacksService.GetPacks(context.Background())
 	assert.Nil(t, err)
 	assert.Equal(t, []int{100, 201, 1000}, sizes)
 }
@@ -135,7 +137,7 @@ func TestPacksSync_ValidRequest_EmptyList(t *testing.T) {
 	// given
 	gin.SetMode(gin.TestMode)
 
-	appContext := appcontext.BuildAppContext()
+	appContext := buildAppContext()
 	// insert initial date
 	initSql := `INSERT INTO packs(size) VALUES (100), (200), (1000);`
 	if err := appContext.DB.Exec(initSql).Error; err != nil {
@@ -160,7 +162,7 @@ func TestPacksSync_ValidRequest_EmptyList(t *testing.T) {
 	// then
 	assert.Equal(t, http.StatusOK, response.Code)
 
-	sizes, err := appContext.PacksService.GetPacks()
+	sizes, err := appContext.PacksService.GetPacks(context.Background())
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(sizes))
 }
@@ -197,7 +199,7 @@ func TestPacksSync_InvalidRequest_Missing(t *testing.T) {
 	// given
 	gin.SetMode(gin.TestMode)
 
-	appContext := appcontext.BuildAppContext()
+	appContext := buildAppContext()
 	router := controller.SetupRouter(appContext)
 
 	requestBody := map[string]interface{}{
@@ -215,7 +217,7 @@ func TestPackItems_ValidRequest(t *testing.T) {
 	// given
 	gin.SetMode(gin.TestMode)
 
-	appContext := appcontext.BuildAppContext()
+	appContext := buildAppContext()
 	// insert packs data
 	initSql := `INSERT INTO packs(size) VALUES (100), (200), (1000);`
 	if err := appContext.DB.Exec(initSql).Error; err != nil {
@@ -250,7 +252,7 @@ func TestPackItems_ValidRequest_EmptyPacksConfig(t *testing.T) {
 	// given
 	gin.SetMode(gin.TestMode)
 
-	appContext := appcontext.BuildAppContext()
+	appContext := buildAppContext()
 	router := controller.SetupRouter(appContext)
 
 	requestBody := map[string]interface{}{
@@ -302,7 +304,7 @@ func TestPackItems_InvalidRequest(t *testing.T) {
 	// given
 	gin.SetMode(gin.TestMode)
 
-	appContext := appcontext.BuildAppContext()
+	appContext := buildAppContext()
 
 	router := controller.SetupRouter(appContext)
 
@@ -317,13 +319,121 @@ func TestPackItems_InvalidRequest(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, response.Code)
 }
 
+func TestPackagingHistory(t *testing.T) {
+	// given
+	gin.SetMode(gin.TestMode)
+
+	appContext := buildAppContext()
+	// insert packs data
+	initSql := `INSERT INTO packs(size) VALUES (100), (200), (1000);`
+	if err := appContext.DB.Exec(initSql).Error; err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cleanupDb(appContext.DB); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	router := controller.SetupRouter(appContext)
+
+	executeRequestWithHeaders(
+		router, "POST", "/package", map[string]interface{}{"numberOfItems": 1001},
+		map[string]string{"X-Caller-ID": "first"},
+	)
+	executeRequestWithHeaders(
+		router, "POST", "/package", map[string]interface{}{"numberOfItems": 250},
+		map[string]string{"X-Caller-ID": "second"},
+	)
+	executeRequestWithHeaders(
+		router, "POST", "/package", map[string]interface{}{"numberOfItems": 5000},
+		map[string]string{"X-Caller-ID": "second"},
+	)
+
+	// when
+	response := executeRequest(router, "GET", "/package/history?caller=second&maxQuantity=1000", nil)
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var history model.PackagingHistoryResponse
+	assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &history))
+	assert.Equal(t, int64(1), history.Total)
+	assert.Equal(t, 1, history.Page)
+	assert.Equal(t, 1, len(history.Items))
+	assert.Equal(t, 250, history.Items[0].NumberOfItems)
+	assert.Equal(t, map[int]int{200: 1, 100: 1}, history.Items[0].Packs)
+	assert.Equal(t, []int{100, 200, 1000}, history.Items[0].PackSizes)
+	assert.Equal(t, service.PacksConfigVersion([]int{100, 200, 1000}), history.Items[0].PacksVersion)
+	assert.Equal(t, "second", history.Items[0].Caller)
+}
+
+func TestPackagingHistory_InvalidRequest(t *testing.T) {
+	// given
+	gin.SetMode(gin.TestMode)
+
+	appContext := buildAppContext()
+	router := controller.SetupRouter(appContext)
+
+	// when
+	response := executeRequest(router, "GET", "/package/history?pageSize=1000", nil)
+
+	// then
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestPacksSync_Idempotent(t *testing.T) {
+	// given
+	gin.SetMode(gin.TestMode)
+
+	appContext := buildAppContext()
+	defer func() {
+		if err := cleanupDb(appContext.DB); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	router := controller.SetupRouter(appContext)
+	headers := map[string]string{"Idempotency-Key": "sync-1"}
+
+	// when
+	first := executeRequestWithHeaders(
+		router, "POST", "/packs", map[string]interface{}{"packs": []int{100, 200}}, headers,
+	)
+	second := executeRequestWithHeaders(
+		router, "POST", "/packs", map[string]interface{}{"packs": []int{100, 200}}, headers,
+	)
+	conflict := executeRequestWithHeaders(
+		router, "POST", "/packs", map[string]interface{}{"packs": []int{300}}, headers,
+	)
+
+	// then
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, http.StatusConflict, conflict.Code)
+
+	packs, _ := appContext.PacksService.GetPacks(context.Background())
+	assert.Equal(t, []int{100, 200}, packs)
+}
+
 func executeRequest(
 	router *gin.Engine, method string, url string, body map[string]interface{},
+) *httptest.ResponseRecorder {
+	return executeRequestWithHeaders(router, method, url, body, nil)
+}
+
+func executeRequestWithHeaders(
+	router *gin.Engine, method string, url string, body map[string]interface{}, headers map[string]string,
 ) *httptest.ResponseRecorder {
 	jsonBody, _ := json.Marshal(body)
 
 	req, _ := http.NewRequest(method, "/api"+url, bytes.NewBuffer(jsonBody))
 	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
 
 	w := httptest.NewRecorder()
 
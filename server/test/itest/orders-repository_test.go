@@ -0,0 +1,65 @@
+package itest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"server/internal/model"
+	"server/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestOrdersRepository(t *testing.T) {
+	// given
+	appContext := buildAppContext()
+	defer func() {
+		if err := cleanupDb(appContext.DB); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	repo := repository.NewOrdersRepository(appContext.DB)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	order := &model.Order{
+		ID:                "order-1",
+		CustomerReference: "CUST-42",
+		NumberOfItems:     501,
+		Packs:             map[int]int{500: 1, 250: 1},
+		PacksVersion:      "v1",
+		Status:            model.OrderStatusQuoted,
+		Caller:            "test",
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		QuotedAt:          &now,
+	}
+	assert.Nil(t, repo.Create(ctx, order))
+
+	// when
+	confirmedAt := now.Add(time.Second)
+	confirmed, confirmErr := repo.UpdateStatus(
+		ctx, "order-1", model.OrderStatusQuoted, model.OrderStatusConfirmed, confirmedAt,
+	)
+	// the order is not quoted anymore, as if it was confirmed concurrently
+	notUpdated, _ := repo.UpdateStatus(
+		ctx, "order-1", model.OrderStatusQuoted, model.OrderStatusCancelled, confirmedAt,
+	)
+	found, findErr := repo.Find(ctx, "order-1")
+	missing, missingErr := repo.Find(ctx, "order-2")
+
+	// then
+	assert.Nil(t, confirmErr)
+	assert.True(t, confirmed)
+	assert.False(t, notUpdated)
+
+	assert.Nil(t, findErr)
+	assert.Equal(t, model.OrderStatusConfirmed, found.Status)
+	assert.Equal(t, map[int]int{500: 1, 250: 1}, found.Packs)
+	assert.True(t, found.QuotedAt.Equal(now))
+	assert.True(t, found.ConfirmedAt.Equal(confirmedAt))
+	assert.True(t, found.UpdatedAt.Equal(confirmedAt))
+	assert.Nil(t, found.CancelledAt)
+
+	assert.Nil(t, missingErr)
+	assert.Nil(t, missing)
+}
@@ -0,0 +1,67 @@
+package itest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"server/internal/model"
+	"server/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestPackagingJobsRepository(t *testing.T) {
+	// given
+	appContext := buildAppContext()
+	defer func() {
+		if err := cleanupDb(appContext.DB); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	repo := repository.NewPackagingJobsRepository(appContext.DB)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	job := &model.PackagingJob{ID: "job-1", Status: model.JobStatusQueued, TotalLines: 2, CreatedAt: now}
+	lines := []model.PackagingJobLine{
+		{JobID: "job-1", LineNumber: 1, OrderID: "A-1", NumberOfItems: 1},
+		{JobID: "job-1", LineNumber: 2, OrderID: "A-2", NumberOfItems: 501},
+	}
+	assert.Nil(t, repo.Create(ctx, job, lines))
+
+	// when
+	claimed, claimErr := repo.Claim(ctx, now, now.Add(-time.Minute))
+	notClaimed, _ := repo.Claim(ctx, now, now.Add(-time.Minute))
+	unprocessed, _ := repo.FindLines(ctx, "job-1", 0, 10, true)
+	unprocessed[0].Packs = map[int]int{250: 1}
+	saveErr := repo.SaveLines(ctx, "job-1", unprocessed[:1], now)
+	// saving a line twice, as a worker that took over the job would do, does not count it again
+	_ = repo.SaveLines(ctx, "job-1", unprocessed[:1], now)
+	remaining, _ := repo.FindLines(ctx, "job-1", 0, 10, true)
+	takenOver, _ := repo.Claim(ctx, now.Add(2*time.Minute), now.Add(time.Minute))
+	finishErr := repo.Finish(ctx, "job-1", model.JobStatusCompleted, "", now)
+	finished, _ := repo.Find(ctx, "job-1")
+	all, _ := repo.FindLines(ctx, "job-1", 0, 10, false)
+	deleted, deleteErr := repo.DeleteFinished(ctx, now.Add(time.Second))
+	missing, _ := repo.Find(ctx, "job-1")
+
+	// then
+	assert.Nil(t, claimErr)
+	assert.Equal(t, "job-1", claimed.ID)
+	assert.Equal(t, model.JobStatusRunning, claimed.Status)
+	assert.Nil(t, notClaimed)
+
+	assert.Nil(t, saveErr)
+	assert.Len(t, remaining, 1)
+	assert.Equal(t, 2, remaining[0].LineNumber)
+	assert.Equal(t, "job-1", takenOver.ID)
+
+	assert.Nil(t, finishErr)
+	assert.Equal(t, model.JobStatusCompleted, finished.Status)
+	assert.Equal(t, 1, finished.ProcessedLines)
+	assert.Equal(t, map[int]int{250: 1}, all[0].Packs)
+	assert.True(t, all[0].Processed)
+
+	assert.Nil(t, deleteErr)
+	assert.Equal(t, int64(1), deleted)
+	assert.Nil(t, missing)
+}
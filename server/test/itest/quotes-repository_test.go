@@ -0,0 +1,65 @@
+package itest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"server/internal/model"
+	"server/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestQuotesRepository(t *testing.T) {
+	// given
+	appContext := buildAppContext()
+	defer func() {
+		if err := cleanupDb(appContext.DB); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	repo := repository.NewQuotesRepository(appContext.DB)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	quote := &model.Quote{
+		ID:            "quote-1",
+		NumberOfItems: 501,
+		Packs:         map[int]int{500: 1, 250: 1},
+		PackSizes:     []int{250, 500, 1000},
+		PacksVersion:  "v1",
+		Objective:     "min-items-then-min-packs",
+		Caller:        "test",
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(time.Hour),
+	}
+	expired := &model.Quote{
+		ID:            "quote-2",
+		NumberOfItems: 1,
+		Packs:         map[int]int{250: 1},
+		PackSizes:     []int{250},
+		PacksVersion:  "v1",
+		Objective:     "min-items-then-min-packs",
+		Caller:        "test",
+		CreatedAt:     now.Add(-2 * time.Hour),
+		ExpiresAt:     now.Add(-time.Hour),
+	}
+	assert.Nil(t, repo.Create(ctx, quote))
+	assert.Nil(t, repo.Create(ctx, expired))
+
+	// when
+	found, findErr := repo.Find(ctx, "quote-1")
+	deleted, deleteErr := repo.DeleteExpired(ctx, now)
+	purged, purgedErr := repo.Find(ctx, "quote-2")
+
+	// then
+	assert.Nil(t, findErr)
+	assert.Equal(t, map[int]int{500: 1, 250: 1}, found.Packs)
+	assert.Equal(t, []int{250, 500, 1000}, found.PackSizes)
+	assert.True(t, found.CreatedAt.Equal(now))
+	assert.True(t, found.ExpiresAt.Equal(now.Add(time.Hour)))
+
+	assert.Nil(t, deleteErr)
+	assert.Equal(t, int64(1), deleted)
+	assert.Nil(t, purgedErr)
+	assert.Nil(t, purged)
+}
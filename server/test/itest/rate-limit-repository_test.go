@@ -0,0 +1,40 @@
+package itest
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"server/internal/model"
+	"server/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestRateLimitRepository_Take(t *testing.T) {
+	// given
+	appContext := buildAppContext()
+	defer func() {
+		if err := cleanupDb(appContext.DB); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	repo := repository.NewRateLimitRepository(appContext.DB)
+	limit := model.RateLimit{RequestsPerSecond: 1, Burst: 2, DailyQuota: 3}
+	now := time.Now()
+	ctx := context.Background()
+
+	// when
+	first, firstErr := repo.Take(ctx, "client", limit, now)
+	second, _ := repo.Take(ctx, "client", limit, now)
+	third, _ := repo.Take(ctx, "client", limit, now)
+	afterRefill, _ := repo.Take(ctx, "client", limit, now.Add(time.Second))
+	overQuota, _ := repo.Take(ctx, "client", limit, now.Add(time.Minute))
+
+	// then
+	assert.Nil(t, firstErr)
+	assert.True(t, first.Allowed)
+	assert.True(t, second.Allowed)
+	assert.False(t, third.Allowed)
+	assert.True(t, afterRefill.Allowed)
+	assert.True(t, overQuota.QuotaExceeded)
+}
@@ -2,6 +2,7 @@ package itest
 
 import (
 	"context"
+	"flag"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -9,6 +10,8 @@ import (
 	"gorm.io/gorm"
 	"log"
 	"os"
+	"server/internal/appcontext"
+	"server/internal/config"
 	"testing"
 	"time"
 )
@@ -53,20 +56,13 @@ func initializePgSQLContainer(ctx context.Context) (testcontainers.Container, er
 		return nil, err
 	}
 
-	// Get the port mapped to 5432 and set as ENV
-	port, _ := postgresContainer.MappedPort(ctx, "5432")
-
-	os.Setenv("DB_HOST", "localhost")
-	os.Setenv("DB_PORT", port.Port())
-	os.Setenv("DB_USERNAME", dbUser)
-	os.Setenv("DB_PASSWORD", dbPassword)
-	os.Setenv("DB_NAME", dbName)
-
-	// initialize the database
 	connStr, err := postgresContainer.ConnectionString(ctx, "sslmode=disable")
 	if err != nil {
 		return nil, err
 	}
+	os.Setenv("DATABASE_URL", connStr)
+
+	// initialize the database
 
 	db, err := gorm.Open(postgresDriver.Open(connStr), &gorm.Config{})
 	if err != nil {
@@ -80,19 +76,127 @@ func initializePgSQLContainer(ctx context.Context) (testcontainers.Container, er
 	return postgresContainer, nil
 }
 
+// buildAppContext builds the app context from the config of the env, i.e. the DB of the container.
+func buildAppContext() *appcontext.AppContext {
+	loaded, err := config.NewLoader(flag.NewFlagSet("itest", flag.ContinueOnError)).Load()
+	if err != nil {
+		log.Fatalf("Error loading the config: %v", err)
+	}
+
+	return appcontext.BuildAppContext(loaded)
+}
+
 func initializeSchema(db *gorm.DB) error {
-	initSQL := `CREATE TABLE packs (size BIGINT PRIMARY KEY);`
-	if err := db.Exec(initSQL).Error; err != nil {
-		return err
+	initSQLs := []string{
+		`CREATE TABLE packs (size BIGINT PRIMARY KEY);`,
+		`CREATE TABLE packaging_results (
+			id BIGSERIAL PRIMARY KEY,
+			number_of_items BIGINT NOT NULL,
+			packs JSONB NOT NULL,
+			pack_sizes JSONB NOT NULL,
+			objective VARCHAR(64) NOT NULL,
+			packs_version VARCHAR(64) NOT NULL,
+			caller VARCHAR(255) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL
+		);`,
+		`CREATE TABLE idempotency_records (
+			scope VARCHAR(255) NOT NULL,
+			key VARCHAR(255) NOT NULL,
+			request_hash VARCHAR(64) NOT NULL,
+			completed BOOLEAN NOT NULL,
+			status_code INTEGER NOT NULL,
+			headers JSONB NOT NULL,
+			body BYTEA,
+			created_at TIMESTAMPTZ NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (scope, key)
+		);`,
+		`CREATE TABLE rate_limit_buckets (
+			key VARCHAR(512) PRIMARY KEY,
+			tokens DOUBLE PRECISION NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL,
+			quota_day VARCHAR(10) NOT NULL,
+			quota_used INTEGER NOT NULL
+		);`,
+		`CREATE TABLE packaging_jobs (
+			id VARCHAR(36) PRIMARY KEY,
+			status VARCHAR(16) NOT NULL,
+			total_lines INTEGER NOT NULL,
+			processed_lines INTEGER NOT NULL,
+			failed_lines INTEGER NOT NULL,
+			error TEXT NOT NULL,
+			pack_sizes JSONB,
+			packs_version VARCHAR(64) NOT NULL,
+			caller VARCHAR(255) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			started_at TIMESTAMPTZ,
+			completed_at TIMESTAMPTZ,
+			heartbeat_at TIMESTAMPTZ
+		);`,
+		`CREATE TABLE packaging_job_lines (
+			job_id VARCHAR(36) NOT NULL REFERENCES packaging_jobs (id) ON DELETE CASCADE,
+			line_number INTEGER NOT NULL,
+			order_id VARCHAR(255) NOT NULL,
+			number_of_items BIGINT NOT NULL,
+			processed BOOLEAN NOT NULL,
+			packs JSONB,
+			packs_version VARCHAR(64) NOT NULL,
+			error TEXT NOT NULL,
+			PRIMARY KEY (job_id, line_number)
+		);`,
+		`CREATE TABLE orders (
+			id VARCHAR(36) PRIMARY KEY,
+			customer_reference VARCHAR(255) NOT NULL,
+			number_of_items BIGINT NOT NULL,
+			packs JSONB NOT NULL,
+			packs_version VARCHAR(64) NOT NULL,
+			status VARCHAR(16) NOT NULL,
+			caller VARCHAR(255) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL,
+			quoted_at TIMESTAMPTZ,
+			confirmed_at TIMESTAMPTZ,
+			packed_at TIMESTAMPTZ,
+			shipped_at TIMESTAMPTZ,
+			cancelled_at TIMESTAMPTZ
+		);`,
+		`CREATE TABLE quotes (
+			id VARCHAR(36) PRIMARY KEY,
+			number_of_items BIGINT NOT NULL,
+			packs JSONB NOT NULL,
+			pack_sizes JSONB NOT NULL,
+			packs_version VARCHAR(64) NOT NULL,
+			objective VARCHAR(64) NOT NULL,
+			caller VARCHAR(255) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL
+		);`,
+		`CREATE TABLE schema_version (version INTEGER PRIMARY KEY);`,
+		`INSERT INTO schema_version (version) VALUES (4);`,
+	}
+	for _, initSQL := range initSQLs {
+		if err := db.Exec(initSQL).Error; err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
 func cleanupDb(db *gorm.DB) error {
-	sql := `DELETE FROM packs WHERE 1=1;`
-	if err := db.Exec(sql).Error; err != nil {
-		return err
+	sqls := []string{
+		`DELETE FROM packs WHERE 1=1;`,
+		`DELETE FROM packaging_results WHERE 1=1;`,
+		`DELETE FROM idempotency_records WHERE 1=1;`,
+		`DELETE FROM rate_limit_buckets WHERE 1=1;`,
+		`DELETE FROM packaging_jobs WHERE 1=1;`,
+		`DELETE FROM orders WHERE 1=1;`,
+		`DELETE FROM quotes WHERE 1=1;`,
+	}
+	for _, sql := range sqls {
+		if err := db.Exec(sql).Error; err != nil {
+			return err
+		}
 	}
 
 	return nil
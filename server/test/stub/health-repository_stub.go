@@ -0,0 +1,17 @@
+package stub
+
+import "context"
+
+type HealthRepositoryStub struct {
+	PingError    error
+	Version      int
+	VersionError error
+}
+
+func (h HealthRepositoryStub) Ping(_ context.Context) error {
+	return h.PingError
+}
+
+func (h HealthRepositoryStub) SchemaVersion(_ context.Context) (int, error) {
+	return h.Version, h.VersionError
+}
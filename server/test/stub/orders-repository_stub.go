@@ -0,0 +1,74 @@
+package stub
+
+import (
+	"context"
+	"server/internal/model"
+	"sync"
+	"time"
+)
+
+// OrdersRepositoryStub keeps the orders in memory, behaving like the DB repository.
+type OrdersRepositoryStub struct {
+	// BeforeUpdate is called before an update of the status, e.g. to change the order concurrently
+	BeforeUpdate func(order *model.Order)
+
+	mutex  sync.Mutex
+	orders map[string]*model.Order
+}
+
+func (o *OrdersRepositoryStub) Create(_ context.Context, order *model.Order) error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.orders == nil {
+		o.orders = map[string]*model.Order{}
+	}
+	stored := *order
+	o.orders[order.ID] = &stored
+	return nil
+}
+
+func (o *OrdersRepositoryStub) Find(_ context.Context, id string) (*model.Order, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	order, ok := o.orders[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *order
+	return &found, nil
+}
+
+func (o *OrdersRepositoryStub) UpdateStatus(
+	_ context.Context, id string, from string, to string, at time.Time,
+) (bool, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	order, ok := o.orders[id]
+	if !ok {
+		return false, nil
+	}
+	if o.BeforeUpdate != nil {
+		o.BeforeUpdate(order)
+		o.BeforeUpdate = nil
+	}
+	if order.Status != from {
+		return false, nil
+	}
+
+	order.Status = to
+	order.UpdatedAt = at
+	switch to {
+	case model.OrderStatusConfirmed:
+		order.ConfirmedAt = &at
+	case model.OrderStatusPacked:
+		order.PackedAt = &at
+	case model.OrderStatusShipped:
+		order.ShippedAt = &at
+	case model.OrderStatusCancelled:
+		order.CancelledAt = &at
+	}
+	return true, nil
+}
@@ -1,15 +1,30 @@
 package stub
 
+import (
+	"context"
+	"server/internal/model"
+	"server/internal/service"
+)
+
 type PacksServiceStub struct {
 	Sizes []int
+	Stale bool
 	Error error
 }
 
-func (p PacksServiceStub) GetPacks() ([]int, error) {
+func (p PacksServiceStub) GetPacks(_ context.Context) ([]int, error) {
 	return p.Sizes, p.Error
 }
 
-func (p PacksServiceStub) SyncPacks(packs []int) error {
+func (p PacksServiceStub) GetPacksConfig(_ context.Context) (*model.PacksConfig, error) {
+	if p.Error != nil {
+		return nil, p.Error
+	}
+
+	return &model.PacksConfig{Sizes: p.Sizes, Version: service.PacksConfigVersion(p.Sizes), Stale: p.Stale}, nil
+}
+
+func (p PacksServiceStub) SyncPacks(_ context.Context, packs []int) error {
 	if p.Error != nil {
 		return p.Error
 	}
@@ -17,3 +32,7 @@ func (p PacksServiceStub) SyncPacks(packs []int) error {
 	p.Sizes = packs
 	return nil
 }
+
+func (p PacksServiceStub) CacheStats() model.PacksCacheStats {
+	return model.PacksCacheStats{}
+}
@@ -0,0 +1,165 @@
+package stub
+
+import (
+	"context"
+	"server/internal/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PackagingJobsRepositoryStub keeps the jobs in memory, behaving like the DB repository.
+type PackagingJobsRepositoryStub struct {
+	mutex sync.Mutex
+	jobs  map[string]*model.PackagingJob
+	lines map[string][]model.PackagingJobLine
+}
+
+func (p *PackagingJobsRepositoryStub) Create(
+	_ context.Context, job *model.PackagingJob, lines []model.PackagingJobLine,
+) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.jobs == nil {
+		p.jobs = map[string]*model.PackagingJob{}
+		p.lines = map[string][]model.PackagingJobLine{}
+	}
+	stored := *job
+	p.jobs[job.ID] = &stored
+	p.lines[job.ID] = append([]model.PackagingJobLine(nil), lines...)
+	return nil
+}
+
+func (p *PackagingJobsRepositoryStub) Find(_ context.Context, id string) (*model.PackagingJob, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	job, ok := p.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *job
+	return &found, nil
+}
+
+func (p *PackagingJobsRepositoryStub) Claim(_ context.Context, now time.Time, staleBefore time.Time) (
+	*model.PackagingJob, error,
+) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var claimable []*model.PackagingJob
+	for _, job := range p.jobs {
+		if job.Status == model.JobStatusQueued ||
+			(job.Status == model.JobStatusRunning && job.HeartbeatAt != nil && job.HeartbeatAt.Before(staleBefore)) {
+			claimable = append(claimable, job)
+		}
+	}
+	if len(claimable) == 0 {
+		return nil, nil
+	}
+	sort.Slice(claimable, func(i, j int) bool { return claimable[i].CreatedAt.Before(claimable[j].CreatedAt) })
+
+	job := claimable[0]
+	job.Status = model.JobStatusRunning
+	if job.StartedAt == nil {
+		job.StartedAt = &now
+	}
+	job.HeartbeatAt = &now
+	claimed := *job
+	return &claimed, nil
+}
+
+func (p *PackagingJobsRepositoryStub) Release(_ context.Context, id string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if job, ok := p.jobs[id]; ok && job.Status == model.JobStatusRunning {
+		job.Status = model.JobStatusQueued
+		job.HeartbeatAt = nil
+	}
+	return nil
+}
+
+func (p *PackagingJobsRepositoryStub) SavePacksConfig(
+	_ context.Context, id string, sizes []int, version string,
+) (*model.PackagingJob, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	job := p.jobs[id]
+	if job.PackSizes == nil {
+		job.PackSizes = append([]int(nil), sizes...)
+		job.PacksVersion = version
+	}
+	saved := *job
+	return &saved, nil
+}
+
+func (p *PackagingJobsRepositoryStub) FindLines(
+	_ context.Context, id string, afterLine int, limit int, unprocessedOnly bool,
+) ([]model.PackagingJobLine, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var lines []model.PackagingJobLine
+	for _, line := range p.lines[id] {
+		if line.LineNumber > afterLine && (!unprocessedOnly || !line.Processed) && len(lines) < limit {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+func (p *PackagingJobsRepositoryStub) SaveLines(
+	_ context.Context, id string, lines []model.PackagingJobLine, now time.Time,
+) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	job := p.jobs[id]
+	stored := p.lines[id]
+	for _, line := range lines {
+		i := line.LineNumber - 1
+		if stored[i].Processed {
+			continue
+		}
+		line.Processed = true
+		stored[i] = line
+		job.ProcessedLines++
+		if line.Error != "" {
+			job.FailedLines++
+		}
+	}
+	job.HeartbeatAt = &now
+	return nil
+}
+
+func (p *PackagingJobsRepositoryStub) Finish(
+	_ context.Context, id string, status string, message string, now time.Time,
+) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	job := p.jobs[id]
+	job.Status = status
+	job.Error = message
+	job.CompletedAt = &now
+	return nil
+}
+
+func (p *PackagingJobsRepositoryStub) DeleteFinished(_ context.Context, before time.Time) (int64, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var deleted int64
+	for id, job := range p.jobs {
+		if job.CompletedAt != nil && job.CompletedAt.Before(before) {
+			delete(p.jobs, id)
+			delete(p.lines, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
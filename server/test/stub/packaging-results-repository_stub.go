@@ -0,0 +1,35 @@
+package stub
+
+import (
+	"context"
+	"server/internal/model"
+)
+
+type PackagingResultsRepositoryStub struct {
+	Saved      *[]model.PackagingResult
+	Results    []model.PackagingResult
+	Total      int64
+	Error      error
+	LastFilter *model.PackagingHistoryFilter
+}
+
+func (p PackagingResultsRepositoryStub) Save(_ context.Context, result *model.PackagingResult) error {
+	if p.Error != nil {
+		return p.Error
+	}
+
+	if p.Saved != nil {
+		*p.Saved = append(*p.Saved, *result)
+	}
+	return nil
+}
+
+func (p PackagingResultsRepositoryStub) Find(_ context.Context, filter model.PackagingHistoryFilter) (
+	[]model.PackagingResult, int64, error,
+) {
+	if p.LastFilter != nil {
+		*p.LastFilter = filter
+	}
+
+	return p.Results, p.Total, p.Error
+}
@@ -1,18 +1,50 @@
 package stub
 
 import (
+	"context"
 	"server/internal/model"
+	"time"
 )
 
 type PacksRepositoryStub struct {
 	Packs []model.Pack
 	Error error
+	Calls *int
+	// Delay is how long the calls take, unless their context is done before
+	Delay time.Duration
 }
 
-func (p PacksRepositoryStub) FindAll() ([]model.Pack, error) {
+func (p PacksRepositoryStub) FindAll(ctx context.Context) ([]model.Pack, error) {
+	p.count()
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
 	return p.Packs, p.Error
 }
 
-func (p PacksRepositoryStub) SyncPacks(packs []int) error {
+func (p PacksRepositoryStub) SyncPacks(ctx context.Context, packs []int) error {
+	p.count()
+	if err := p.wait(ctx); err != nil {
+		return err
+	}
 	return p.Error
 }
+
+func (p PacksRepositoryStub) count() {
+	if p.Calls != nil {
+		*p.Calls++
+	}
+}
+
+func (p PacksRepositoryStub) wait(ctx context.Context) error {
+	if p.Delay == 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(p.Delay):
+		return nil
+	}
+}
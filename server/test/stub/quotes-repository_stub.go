@@ -0,0 +1,52 @@
+package stub
+
+import (
+	"context"
+	"server/internal/model"
+	"sync"
+	"time"
+)
+
+// QuotesRepositoryStub keeps the quotes in memory, behaving like the DB repository.
+type QuotesRepositoryStub struct {
+	mutex  sync.Mutex
+	quotes map[string]*model.Quote
+}
+
+func (q *QuotesRepositoryStub) Create(_ context.Context, quote *model.Quote) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.quotes == nil {
+		q.quotes = map[string]*model.Quote{}
+	}
+	stored := *quote
+	q.quotes[quote.ID] = &stored
+	return nil
+}
+
+func (q *QuotesRepositoryStub) Find(_ context.Context, id string) (*model.Quote, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	quote, ok := q.quotes[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *quote
+	return &found, nil
+}
+
+func (q *QuotesRepositoryStub) DeleteExpired(_ context.Context, before time.Time) (int64, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	var deleted int64
+	for id, quote := range q.quotes {
+		if quote.ExpiresAt.Before(before) {
+			delete(q.quotes, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
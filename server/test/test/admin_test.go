@@ -0,0 +1,170 @@
+package test
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"runtime"
+	"server/internal/appcontext"
+	"server/internal/config"
+	"server/internal/controller"
+	"server/internal/model"
+	"server/internal/service"
+	"server/test/stub"
+	"strings"
+	"testing"
+)
+
+func TestAdminRouter(t *testing.T) {
+	// given
+	router := adminRouter()
+
+	scenarios := []struct {
+		name        string
+		url         string
+		contentType string
+		contains    string
+	}{
+		{"pprof index", "/admin/debug/pprof/", "text/html", "goroutine"},
+		{"pprof profile", "/admin/debug/pprof/heap?debug=1", "text/plain", "heap profile"},
+		{"goroutine dump", "/admin/goroutines", "text/plain", "goroutine "},
+		{"build info", "/admin/build-info", "application/json", runtime.Version()},
+		{"packs", "/admin/packs", "application/json", service.PacksConfigVersion([]int{250, 500})},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// when
+				response := executeRequest(router, "GET", scenario.url, "")
+
+				// then
+				assert.Equal(t, http.StatusOK, response.Code)
+				assert.True(t, strings.HasPrefix(response.Header().Get("Content-Type"), scenario.contentType))
+				assert.Contains(t, response.Body.String(), scenario.contains)
+			},
+		)
+	}
+}
+
+func TestAdminRouter_UnknownProfile(t *testing.T) {
+	// when
+	response := executeRequest(adminRouter(), "GET", "/admin/debug/pprof/unknown", "")
+	cmdlineResponse := executeRequest(adminRouter(), "GET", "/admin/debug/pprof/cmdline", "")
+
+	// then
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.JSONEq(t, `{"error": "unknown profile unknown"}`, response.Body.String())
+	assert.Equal(t, http.StatusNotFound, cmdlineResponse.Code)
+}
+
+func TestAdminRouter_Authorization(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		headers  []string
+		expected int
+	}{
+		{"anonymous", nil, http.StatusUnauthorized},
+		{"reader", []string{"X-API-Key", "reader-key"}, http.StatusForbidden},
+		{"admin", []string{"X-API-Key", "admin-key"}, http.StatusOK},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				gin.SetMode(gin.TestMode)
+				router := controller.SetupAdminRouter(
+					&appcontext.AppContext{PacksService: stub.PacksServiceStub{}, Authenticator: testAPIKeyAuthenticator(t)},
+				)
+
+				// when
+				response := executeRequest(router, "GET", "/admin/build-info", "", scenario.headers...)
+
+				// then
+				assert.Equal(t, scenario.expected, response.Code)
+			},
+		)
+	}
+}
+
+func TestAdminRouter_Config(t *testing.T) {
+	// when
+	response := executeRequest(adminRouter(), "GET", "/admin/config", "")
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+	var settings map[string]map[string]any
+	assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &settings))
+	assert.Equal(t, "REDACTED", settings["database"]["password"])
+	assert.Equal(t, "db", settings["database"]["host"])
+	assert.Equal(t, "30m0s", settings["database"]["connMaxLifetime"])
+}
+
+func TestAdminRouter_CacheStats(t *testing.T) {
+	// given
+	router := adminRouter()
+	executeRequest(router, "GET", "/admin/packs", "")
+
+	// when
+	response := executeRequest(router, "GET", "/admin/cache", "")
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+	var stats struct{ Packs model.PacksCacheStats }
+	assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &stats))
+	assert.True(t, stats.Packs.Cached)
+	assert.Equal(t, []int{250, 500}, stats.Packs.Sizes)
+	assert.Equal(t, int64(1), stats.Packs.Updates)
+}
+
+func TestAdminRoutes_OnTheAPIRouter(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		enabled  bool
+		address  string
+		headers  []string
+		expected int
+	}{
+		{"anonymous", true, "", nil, http.StatusUnauthorized},
+		{"reader", true, "", []string{"X-API-Key", "reader-key"}, http.StatusForbidden},
+		{"admin", true, "", []string{"X-API-Key", "admin-key"}, http.StatusOK},
+		{"disabled", false, "", []string{"X-API-Key", "admin-key"}, http.StatusNotFound},
+		{"on the admin server", true, ":9091", []string{"X-API-Key", "admin-key"}, http.StatusNotFound},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				router := testRouter(
+					&appcontext.AppContext{
+						PacksService:  stub.PacksServiceStub{},
+						Authenticator: testAPIKeyAuthenticator(t),
+						AdminEnabled:  scenario.enabled,
+						AdminAddress:  scenario.address,
+					},
+				)
+
+				// when
+				response := executeRequest(router, "GET", "/admin/build-info", "", scenario.headers...)
+
+				// then
+				assert.Equal(t, scenario.expected, response.Code)
+			},
+		)
+	}
+}
+
+func adminRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	loaded := config.Default()
+	loaded.Database.Host = "db"
+	loaded.Database.Password = "secret"
+	packsService := service.NewPacksService(stub.PacksRepositoryStub{Packs: []model.Pack{{Size: 250}, {Size: 500}}})
+
+	return controller.SetupAdminRouter(
+		&appcontext.AppContext{Config: &loaded, PacksService: packsService, AuthDisabled: true},
+	)
+}
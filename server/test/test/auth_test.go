@@ -0,0 +1,526 @@
+package test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"server/internal/appcontext"
+	"server/internal/auth"
+	"server/internal/controller"
+	"server/internal/grpcapi/pb"
+	"server/internal/model"
+	"server/internal/service"
+	"server/test/stub"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	scenarios := []struct {
+		name        string
+		credentials auth.Credentials
+		expected    *auth.Principal
+		expectedErr error
+	}{
+		{
+			name:        "valid key",
+			credentials: auth.Credentials{APIKey: "reader-key"},
+			expected:    &auth.Principal{Name: "reader", Roles: []string{auth.RoleReader}},
+		},
+		{
+			name:        "unknown key",
+			credentials: auth.Credentials{APIKey: "other-key"},
+			expectedErr: &model.InvalidCredentials{Reason: "unknown API key"},
+		},
+		{
+			name:        "no key",
+			credentials: auth.Credentials{BearerToken: "token"},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				authenticator, _ := auth.NewAPIKeyAuthenticator(
+					[]auth.APIKey{{Name: "reader", Key: "reader-key", Roles: []string{auth.RoleReader}}},
+				)
+
+				// when
+				principal, err := authenticator.Authenticate(context.Background(), scenario.credentials)
+
+				// then
+				assert.Equal(t, scenario.expected, principal)
+				assert.Equal(t, scenario.expectedErr, err)
+			},
+		)
+	}
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "api-keys.yaml")
+	content := "apiKeys:\n  - name: orders\n    key: secret\n    roles: [reader, admin]\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	keys, err := auth.LoadAPIKeys(path)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, []auth.APIKey{{Name: "orders", Key: "secret", Roles: []string{"reader", "admin"}}}, keys)
+}
+
+func TestPrincipal_HasRole(t *testing.T) {
+	admin := &auth.Principal{Roles: []string{auth.RoleAdmin}}
+	reader := &auth.Principal{Roles: []string{auth.RoleReader}}
+
+	assert.True(t, admin.HasRole(auth.RoleAdmin))
+	assert.True(t, admin.HasRole(auth.RoleReader))
+	assert.True(t, reader.HasRole(auth.RoleReader))
+	assert.False(t, reader.HasRole(auth.RoleAdmin))
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	key := generateRSAKey(t)
+	otherKey := generateRSAKey(t)
+
+	scenarios := []struct {
+		name     string
+		token    string
+		expected *auth.Principal
+		valid    bool
+	}{
+		{
+			name:     "valid token",
+			token:    signToken(t, key, "key-1", jwt.MapClaims{"sub": "orders", "roles": []string{"admin"}}),
+			expected: &auth.Principal{Name: "orders", Roles: []string{"admin"}},
+			valid:    true,
+		},
+		{
+			name:     "roles as string",
+			token:    signToken(t, key, "key-1", jwt.MapClaims{"sub": "orders", "roles": "reader admin"}),
+			expected: &auth.Principal{Name: "orders", Roles: []string{"reader", "admin"}},
+			valid:    true,
+		},
+		{
+			name:  "signed with other key",
+			token: signToken(t, otherKey, "key-1", jwt.MapClaims{"sub": "orders"}),
+		},
+		{
+			name:  "unknown key id",
+			token: signToken(t, key, "key-2", jwt.MapClaims{"sub": "orders"}),
+		},
+		{
+			name: "expired",
+			token: signToken(
+				t, key, "key-1", jwt.MapClaims{"sub": "orders", "exp": time.Now().Add(-time.Minute).Unix()},
+			),
+		},
+		{
+			name:  "wrong issuer",
+			token: signToken(t, key, "key-1", jwt.MapClaims{"sub": "orders", "iss": "other"}),
+		},
+		{
+			name:  "no subject",
+			token: signToken(t, key, "key-1", jwt.MapClaims{"roles": []string{"admin"}}),
+		},
+		{
+			name:  "not a token",
+			token: "not-a-token",
+		},
+	}
+
+	jwks, err := auth.NewJWKS(context.Background(), writeJWKS(t, map[string]*rsa.PrivateKey{"key-1": key}), 0)
+	assert.Nil(t, err)
+	authenticator := auth.NewJWTAuthenticator(jwks, auth.JWTConfig{Issuer: "issuer"})
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// when
+				principal, err := authenticator.Authenticate(
+					context.Background(), auth.Credentials{BearerToken: scenario.token},
+				)
+
+				// then
+				assert.Equal(t, scenario.expected, principal)
+				if scenario.valid {
+					assert.Nil(t, err)
+				} else {
+					assert.IsType(t, &model.InvalidCredentials{}, err)
+				}
+			},
+		)
+	}
+}
+
+func TestJWTAuthenticator_NestedRolesClaim(t *testing.T) {
+	// given
+	key := generateRSAKey(t)
+	jwks, _ := auth.NewJWKS(context.Background(), writeJWKS(t, map[string]*rsa.PrivateKey{"key-1": key}), 0)
+	authenticator := auth.NewJWTAuthenticator(jwks, auth.JWTConfig{RolesClaim: "realm_access.roles"})
+	token := signToken(
+		t, key, "key-1", jwt.MapClaims{"sub": "orders", "realm_access": map[string]interface{}{"roles": []string{"reader"}}},
+	)
+
+	// when
+	principal, err := authenticator.Authenticate(context.Background(), auth.Credentials{BearerToken: token})
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, &auth.Principal{Name: "orders", Roles: []string{"reader"}}, principal)
+}
+
+func TestJWKS_FromURL(t *testing.T) {
+	// given
+	key := generateRSAKey(t)
+	content, _ := os.ReadFile(writeJWKS(t, map[string]*rsa.PrivateKey{"key-1": key}))
+	jwksServer := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write(content) }),
+	)
+	defer jwksServer.Close()
+
+	// when
+	jwks, err := auth.NewJWKS(context.Background(), jwksServer.URL, time.Minute)
+
+	// then
+	assert.Nil(t, err)
+	publicKey, err := jwks.Key(context.Background(), "key-1")
+	assert.Nil(t, err)
+	assert.Equal(t, &key.PublicKey, publicKey)
+}
+
+func TestJWKS_RefreshDoesNotBlockKnownKeys(t *testing.T) {
+	// given
+	key := generateRSAKey(t)
+	content, _ := os.ReadFile(writeJWKS(t, map[string]*rsa.PrivateKey{"key-1": key}))
+	requests := 0
+	release := make(chan struct{})
+	jwksServer := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, _ *http.Request) {
+				requests++
+				// the refreshes hang, like a source that does not answer
+				if requests > 1 {
+					<-release
+				}
+				_, _ = w.Write(content)
+			},
+		),
+	)
+	defer jwksServer.Close()
+	defer close(release)
+	jwks, _ := auth.NewJWKS(context.Background(), jwksServer.URL, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	// when
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	publicKey, err := jwks.Key(ctx, "key-1")
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, &key.PublicKey, publicKey)
+	assert.Nil(t, ctx.Err())
+}
+
+func TestJWKS_NoKeys(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	_ = os.WriteFile(path, []byte(`{"keys": [{"kty": "oct", "kid": "secret"}]}`), 0o600)
+
+	// when
+	jwks, err := auth.NewJWKS(context.Background(), path, 0)
+
+	// then
+	assert.Nil(t, jwks)
+	assert.NotNil(t, err)
+}
+
+func TestAuthorize(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		method   string
+		url      string
+		body     string
+		headers  map[string]string
+		expected int
+	}{
+		{name: "no credentials", method: "GET", url: "/api/packs", expected: http.StatusUnauthorized},
+		{
+			name: "unknown api key", method: "GET", url: "/api/packs",
+			headers: map[string]string{"X-API-Key": "unknown"}, expected: http.StatusUnauthorized,
+		},
+		{
+			name: "basic auth", method: "GET", url: "/api/packs",
+			headers: map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, expected: http.StatusUnauthorized,
+		},
+		{
+			name: "reader reads packs", method: "GET", url: "/api/packs",
+			headers: map[string]string{"X-API-Key": "reader-key"}, expected: http.StatusOK,
+		},
+		{
+			name: "reader packages", method: "POST", url: "/api/v2/package", body: `{"numberOfItems": 1}`,
+			headers: map[string]string{"X-API-Key": "reader-key"}, expected: http.StatusOK,
+		},
+		{
+			name: "reader syncs packs", method: "POST", url: "/api/packs", body: `{"packs": [100]}`,
+			headers: map[string]string{"X-API-Key": "reader-key"}, expected: http.StatusForbidden,
+		},
+		{
+			name: "admin syncs packs", method: "POST", url: "/api/packs", body: `{"packs": [100]}`,
+			headers: map[string]string{"X-API-Key": "admin-key"}, expected: http.StatusOK,
+		},
+		{
+			name: "admin reads packs", method: "GET", url: "/api/v2/packs",
+			headers: map[string]string{"X-API-Key": "admin-key"}, expected: http.StatusOK,
+		},
+		{name: "spec is public", method: "GET", url: "/api/openapi.json", expected: http.StatusOK},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				gin.SetMode(gin.TestMode)
+				packsService := stub.PacksServiceStub{Sizes: []int{100, 200}}
+				router := controller.SetupRouter(
+					&appcontext.AppContext{
+						PacksService:   packsService,
+						PackingService: service.NewPackagingService(packsService),
+						Authenticator:  testAPIKeyAuthenticator(t),
+					},
+				)
+
+				req, _ := http.NewRequest(scenario.method, scenario.url, strings.NewReader(scenario.body))
+				req.Header.Set("Content-Type", "application/json")
+				for name, value := range scenario.headers {
+					req.Header.Set(name, value)
+				}
+
+				// when
+				response := httptest.NewRecorder()
+				router.ServeHTTP(response, req)
+
+				// then
+				assert.Equal(t, scenario.expected, response.Code)
+				if scenario.expected == http.StatusUnauthorized {
+					assert.NotEmpty(t, response.Header().Get("WWW-Authenticate"))
+				}
+			},
+		)
+	}
+}
+
+func TestAuthorize_NotConfigured(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		authDisabled   bool
+		expectedSynced int
+	}{
+		{name: "admin role refused", authDisabled: false, expectedSynced: http.StatusForbidden},
+		{name: "authentication disabled", authDisabled: true, expectedSynced: http.StatusOK},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				packsService := stub.PacksServiceStub{Sizes: []int{100, 200}}
+				router := testRouter(
+					&appcontext.AppContext{
+						PacksService:   packsService,
+						PackingService: service.NewPackagingService(packsService),
+						AuthDisabled:   scenario.authDisabled,
+					},
+				)
+
+				// when
+				read := executeRequest(router, "GET", "/api/packs", "")
+				synced := executeRequest(router, "POST", "/api/packs", `{"packs": [100]}`)
+
+				// then
+				assert.Equal(t, http.StatusOK, read.Code)
+				assert.Equal(t, scenario.expectedSynced, synced.Code)
+			},
+		)
+	}
+}
+
+func TestGrpcAuthorization(t *testing.T) {
+	// given
+	packsService := stub.PacksServiceStub{Sizes: []int{100, 200}}
+	conn := startGrpcServer(
+		t, &appcontext.AppContext{
+			PacksService:   packsService,
+			PackingService: service.NewPackagingService(packsService),
+			Authenticator:  testAPIKeyAuthenticator(t),
+		},
+	)
+	client := pb.NewPackagingServiceClient(conn)
+	readerCtx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "reader-key")
+	adminCtx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "admin-key")
+
+	// when
+	_, anonymousErr := client.ListPacks(context.Background(), &pb.ListPacksRequest{})
+	_, readerListErr := client.ListPacks(readerCtx, &pb.ListPacksRequest{})
+	_, readerSyncErr := client.SyncPacks(readerCtx, &pb.PacksSyncRequest{Packs: []int64{100}})
+	_, adminSyncErr := client.SyncPacks(adminCtx, &pb.PacksSyncRequest{Packs: []int64{100}})
+
+	// then
+	assert.Equal(t, codes.Unauthenticated, status.Code(anonymousErr))
+	assert.Nil(t, readerListErr)
+	assert.Equal(t, codes.PermissionDenied, status.Code(readerSyncErr))
+	assert.Nil(t, adminSyncErr)
+}
+
+func TestGrpcAuthorization_NotConfigured(t *testing.T) {
+	// given
+	packsService := stub.PacksServiceStub{Sizes: []int{100, 200}}
+	conn := startGrpcServer(
+		t, &appcontext.AppContext{PacksService: packsService, PackingService: service.NewPackagingService(packsService)},
+	)
+	client := pb.NewPackagingServiceClient(conn)
+
+	// when
+	_, listErr := client.ListPacks(context.Background(), &pb.ListPacksRequest{})
+	_, syncErr := client.SyncPacks(context.Background(), &pb.PacksSyncRequest{Packs: []int64{100}})
+
+	// then
+	assert.Nil(t, listErr)
+	assert.Equal(t, codes.PermissionDenied, status.Code(syncErr))
+}
+
+func TestCallerOf_AuthenticatedPrincipal(t *testing.T) {
+	scenarios := []struct {
+		name          string
+		authenticator auth.Authenticator
+		headers       map[string]string
+		expected      string
+	}{
+		{
+			name: "authenticated", authenticator: testAPIKeyAuthenticator(t),
+			headers: map[string]string{"X-API-Key": "reader-key", "X-Caller-ID": "admin"}, expected: "reader",
+		},
+		{
+			name: "without authentication", headers: map[string]string{"X-Caller-ID": "erp"}, expected: "erp",
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				gin.SetMode(gin.TestMode)
+				var saved []model.PackagingResult
+				packsService := stub.PacksServiceStub{Sizes: []int{100}}
+				router := controller.SetupRouter(
+					&appcontext.AppContext{
+						PacksService:   packsService,
+						PackingService: service.NewPackagingService(packsService),
+						HistoryService: service.NewPackagingHistoryService(
+							stub.PackagingResultsRepositoryStub{Saved: &saved},
+						),
+						Authenticator: scenario.authenticator,
+					},
+				)
+				req, _ := http.NewRequest("POST", "/api/package", strings.NewReader(`{"numberOfItems": 1}`))
+				req.Header.Set("Content-Type", "application/json")
+				for name, value := range scenario.headers {
+					req.Header.Set(name, value)
+				}
+
+				// when
+				router.ServeHTTP(httptest.NewRecorder(), req)
+
+				// then
+				assert.Equal(t, 1, len(saved))
+				assert.Equal(t, scenario.expected, saved[0].Caller)
+			},
+		)
+	}
+}
+
+func testAPIKeyAuthenticator(t *testing.T) auth.Authenticator {
+	authenticator, err := auth.NewAPIKeyAuthenticator(
+		[]auth.APIKey{
+			{Name: "reader", Key: "reader-key", Roles: []string{auth.RoleReader}},
+			{Name: "admin", Key: "admin-key", Roles: []string{auth.RoleAdmin}},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return authenticator
+}
+
+func generateRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+// signToken signs the claims, with the "issuer" issuer and a one hour expiry unless set in the claims.
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	if _, ok := claims["iss"]; !ok {
+		claims["iss"] = "issuer"
+	}
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+func writeJWKS(t *testing.T, keys map[string]*rsa.PrivateKey) string {
+	var jwks struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for kid, key := range keys {
+		jwks.Keys = append(
+			jwks.Keys, map[string]string{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			},
+		)
+	}
+
+	content, _ := json.Marshal(jwks)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
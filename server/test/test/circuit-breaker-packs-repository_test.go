@@ -0,0 +1,126 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"server/internal/model"
+	"server/internal/repository"
+	"server/test/stub"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_OpensAfterFailures(t *testing.T) {
+	// given
+	calls := 0
+	repoStub := &stub.PacksRepositoryStub{Error: io.ErrUnexpectedEOF, Calls: &calls}
+	repo := repository.NewCircuitBreakerPacksRepository(repoStub, 2, time.Hour, time.Second)
+
+	// when
+	_, firstErr := repo.FindAll(context.Background())
+	_, secondErr := repo.FindAll(context.Background())
+	_, thirdErr := repo.FindAll(context.Background())
+	syncErr := repo.SyncPacks(context.Background(), []int{1})
+
+	// then
+	assert.Equal(t, io.ErrUnexpectedEOF, firstErr)
+	assert.Equal(t, io.ErrUnexpectedEOF, secondErr)
+	assert.Equal(t, &model.PacksStorageUnavailable{}, thirdErr)
+	assert.Equal(t, &model.PacksStorageUnavailable{}, syncErr)
+	assert.Equal(t, 2, calls)
+}
+
+func TestCircuitBreaker_IgnoresNonTransientErrors(t *testing.T) {
+	// given
+	calls := 0
+	repoErr := errors.New("error")
+	repoStub := &stub.PacksRepositoryStub{Error: repoErr, Calls: &calls}
+	repo := repository.NewCircuitBreakerPacksRepository(repoStub, 1, time.Hour, time.Second)
+
+	// when
+	_, firstErr := repo.FindAll(context.Background())
+	_, secondErr := repo.FindAll(context.Background())
+
+	// then
+	assert.Equal(t, repoErr, firstErr)
+	assert.Equal(t, repoErr, secondErr)
+	assert.Equal(t, 2, calls)
+}
+
+func TestCircuitBreaker_ClosesAfterSuccessfulTrial(t *testing.T) {
+	// given
+	calls := 0
+	repoStub := &stub.PacksRepositoryStub{Error: io.ErrUnexpectedEOF, Calls: &calls}
+	repo := repository.NewCircuitBreakerPacksRepository(repoStub, 1, 10*time.Millisecond, time.Second)
+	_, _ = repo.FindAll(context.Background())
+
+	// when
+	repoStub.Error = nil
+	repoStub.Packs = []model.Pack{{Size: 100}}
+	time.Sleep(20 * time.Millisecond)
+	packs, trialErr := repo.FindAll(context.Background())
+	_, nextErr := repo.FindAll(context.Background())
+
+	// then
+	assert.Nil(t, trialErr)
+	assert.Nil(t, nextErr)
+	assert.Equal(t, []model.Pack{{Size: 100}}, packs)
+	assert.Equal(t, 3, calls)
+}
+
+func TestCircuitBreaker_ReopensAfterFailedTrial(t *testing.T) {
+	// given
+	calls := 0
+	repoStub := &stub.PacksRepositoryStub{Error: io.ErrUnexpectedEOF, Calls: &calls}
+	repo := repository.NewCircuitBreakerPacksRepository(repoStub, 1, 10*time.Millisecond, time.Second)
+	_, _ = repo.FindAll(context.Background())
+
+	// when
+	time.Sleep(20 * time.Millisecond)
+	_, trialErr := repo.FindAll(context.Background())
+	_, nextErr := repo.FindAll(context.Background())
+
+	// then
+	assert.Equal(t, io.ErrUnexpectedEOF, trialErr)
+	assert.Equal(t, &model.PacksStorageUnavailable{}, nextErr)
+	assert.Equal(t, 2, calls)
+}
+
+func TestCircuitBreaker_OpensOnHungStorage(t *testing.T) {
+	// given
+	calls := 0
+	repoStub := &stub.PacksRepositoryStub{Delay: time.Second, Calls: &calls}
+	repo := repository.NewCircuitBreakerPacksRepository(repoStub, 1, time.Hour, 10*time.Millisecond)
+
+	// when
+	_, firstErr := repo.FindAll(context.Background())
+	_, secondErr := repo.FindAll(context.Background())
+
+	// then
+	var unavailableError *model.PacksStorageUnavailable
+	assert.True(t, errors.As(firstErr, &unavailableError))
+	assert.ErrorIs(t, firstErr, context.DeadlineExceeded)
+	assert.Equal(t, &model.PacksStorageUnavailable{}, secondErr)
+	assert.Equal(t, 1, calls)
+}
+
+func TestCircuitBreaker_IgnoresCallerDeadline(t *testing.T) {
+	// given
+	calls := 0
+	repoStub := &stub.PacksRepositoryStub{Delay: time.Second, Calls: &calls}
+	repo := repository.NewCircuitBreakerPacksRepository(repoStub, 1, time.Hour, time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// when
+	_, firstErr := repo.FindAll(ctx)
+	repoStub.Delay = 0
+	_, secondErr := repo.FindAll(context.Background())
+
+	// then
+	assert.Equal(t, context.DeadlineExceeded, firstErr)
+	assert.Nil(t, secondErr)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,236 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"server/internal/appcontext"
+	"server/internal/cli"
+	"server/internal/controller"
+	"server/internal/model"
+	"server/internal/repository"
+	"server/internal/service"
+	"server/test/stub"
+	"testing"
+	"time"
+)
+
+func TestServiceClient_Solve(t *testing.T) {
+	// given
+	var saved []model.PackagingResult
+	packsService := stub.PacksServiceStub{Sizes: []int{250, 500, 1000, 2000, 5000}}
+	client := cli.NewServiceClient(
+		service.NewPackagingService(packsService), packsService,
+		service.NewPackagingHistoryService(stub.PackagingResultsRepositoryStub{Saved: &saved}),
+	)
+
+	// when
+	response, err := client.Solve(context.Background(), 12001)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(
+		t, []model.PackagingLine{
+			{PackSize: 5000, Quantity: 2, Items: 10000},
+			{PackSize: 2000, Quantity: 1, Items: 2000},
+			{PackSize: 250, Quantity: 1, Items: 250},
+		}, response.Lines,
+	)
+	assert.Equal(t, 4, response.TotalPacks)
+	assert.Equal(t, 249, response.Overage)
+	assert.Equal(t, 1, len(saved))
+	assert.Equal(t, 12001, saved[0].NumberOfItems)
+	assert.Equal(t, "cli", saved[0].Caller)
+}
+
+func TestServiceClient_SyncInvalidPacks(t *testing.T) {
+	// given
+	calls := 0
+	packsService := service.NewPacksService(stub.PacksRepositoryStub{Calls: &calls})
+	client := cli.NewServiceClient(service.NewPackagingService(packsService), packsService, nil)
+
+	// when
+	err := client.SyncPacks(context.Background(), []int{250, -500})
+
+	// then
+	var invalidPacksConfig *model.InvalidPacksConfig
+	assert.True(t, errors.As(err, &invalidPacksConfig))
+	assert.Equal(t, 0, calls)
+}
+
+func TestHttpClient_SolveAndPacks(t *testing.T) {
+	// given
+	client := cliTestServer(t, "packs: [250, 500]\n")
+
+	// when
+	solved, solveErr := client.Solve(context.Background(), 501)
+	syncErr := client.SyncPacks(context.Background(), []int{23, 31, 53})
+	packs, packsErr := client.GetPacks(context.Background())
+
+	// then
+	assert.Nil(t, solveErr)
+	assert.Equal(
+		t, []model.PackagingLine{{PackSize: 500, Quantity: 1, Items: 500}, {PackSize: 250, Quantity: 1, Items: 250}},
+		solved.Lines,
+	)
+	assert.Equal(t, packsVersion, solved.PacksVersion)
+	assert.Nil(t, syncErr)
+	assert.Nil(t, packsErr)
+	assert.Equal(t, []int{23, 31, 53}, packs.Packs)
+	assert.Equal(t, service.PacksConfigVersion([]int{23, 31, 53}), packs.Version)
+}
+
+func TestHttpClient_ServerError(t *testing.T) {
+	// given
+	client := cliTestServer(t, "packs: [250, 500]\n")
+
+	// when
+	err := client.SyncPacks(context.Background(), []int{250, 250})
+
+	// then
+	var serverError *model.ServerError
+	assert.True(t, errors.As(err, &serverError))
+	assert.Equal(t, http.StatusBadRequest, serverError.StatusCode)
+	assert.NotEmpty(t, serverError.Message)
+}
+
+func TestHttpClient_Credentials(t *testing.T) {
+	// given
+	var apiKey, authorization string
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				apiKey = r.Header.Get("X-API-Key")
+				authorization = r.Header.Get("Authorization")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":"invalid credentials"}`))
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	client := cli.NewHttpClient(server.URL+"/", "key", "token", server.Client())
+
+	// when
+	_, err := client.GetPacks(context.Background())
+
+	// then
+	assert.Equal(t, "the server answered 401: invalid credentials", err.Error())
+	assert.Equal(t, "key", apiKey)
+	assert.Equal(t, "Bearer token", authorization)
+}
+
+func TestReadCredential(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "token")
+	assert.Nil(t, os.WriteFile(path, []byte("file-token\n"), 0o600))
+	t.Setenv("TEST_API_KEY", "env-key")
+	t.Setenv("TEST_TOKEN_FILE", path)
+	t.Setenv("TEST_BOTH", "env-value")
+	t.Setenv("TEST_BOTH_FILE", path)
+
+	// when
+	apiKey, apiKeyErr := cli.ReadCredential("TEST_API_KEY")
+	token, tokenErr := cli.ReadCredential("TEST_TOKEN")
+	missing, missingErr := cli.ReadCredential("TEST_MISSING")
+	_, bothErr := cli.ReadCredential("TEST_BOTH")
+
+	// then
+	assert.Nil(t, apiKeyErr)
+	assert.Equal(t, "env-key", apiKey)
+	assert.Nil(t, tokenErr)
+	assert.Equal(t, "file-token", token)
+	assert.Nil(t, missingErr)
+	assert.Empty(t, missing)
+	assert.EqualError(t, bothErr, "both TEST_BOTH and TEST_BOTH_FILE are set")
+}
+
+func TestStaticPacksService(t *testing.T) {
+	scenarios := []struct {
+		name  string
+		sizes []int
+		err   string
+	}{
+		{"sorted", []int{500, 250}, ""},
+		{"not positive", []int{250, 0}, "invalid packs configuration: pack size must be positive, got 0"},
+		{"duplicate", []int{250, 500, 250}, "invalid packs configuration: duplicate pack size 250"},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// when
+				packsService, err := cli.NewStaticPacksService(scenario.sizes)
+
+				// then
+				if scenario.err != "" {
+					assert.EqualError(t, err, scenario.err)
+					return
+				}
+				assert.Nil(t, err)
+				sizes, _ := packsService.GetPacks(context.Background())
+				assert.Equal(t, []int{250, 500}, sizes)
+				var readOnly *model.ReadOnlyPacksConfig
+				assert.True(t, errors.As(packsService.SyncPacks(context.Background(), []int{1}), &readOnly))
+			},
+		)
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	// given
+	flags := flag.NewFlagSet("solve", flag.ContinueOnError)
+	packs := flags.String("packs", "", "")
+	asJson := flags.Bool("json", false, "")
+
+	// when
+	positional, err := cli.ParseArgs(flags, []string{"12001", "-packs", "250,500", "-json"})
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"12001"}, positional)
+	assert.Equal(t, "250,500", *packs)
+	assert.True(t, *asJson)
+}
+
+func TestParsePackSizes(t *testing.T) {
+	// when
+	sizes, err := cli.ParsePackSizes("250,500", "1000 2000")
+	_, invalidErr := cli.ParsePackSizes("250,big")
+	_, zeroErr := cli.ParsePackSizes("250,0")
+	_, negativeErr := cli.ParsePackSizes("-250")
+	_, duplicateErr := cli.ParsePackSizes("250,500", "250")
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, []int{250, 500, 1000, 2000}, sizes)
+	assert.EqualError(t, invalidErr, `pack size "big" is not an integer`)
+	assert.EqualError(t, zeroErr, "invalid packs configuration: pack size must be positive, got 0")
+	assert.EqualError(t, negativeErr, "invalid packs configuration: pack size must be positive, got -250")
+	assert.EqualError(t, duplicateErr, "invalid packs configuration: duplicate pack size 250")
+}
+
+// cliTestServer runs the API with the pack sizes in a writable packs file, and returns an HTTP client of it.
+func cliTestServer(t *testing.T, packsFile string) cli.Client {
+	repo, err := repository.NewFilePacksRepository(writePacksFile(t, "packs.yaml", packsFile), true, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(repo.Close)
+	packsService := service.NewPacksService(repo)
+	appContext := &appcontext.AppContext{
+		AuthDisabled:   true,
+		PacksService:   packsService,
+		PackingService: service.NewPackagingService(packsService),
+	}
+
+	server := httptest.NewServer(controller.SetupRouter(appContext))
+	t.Cleanup(server.Close)
+
+	return cli.NewHttpClient(server.URL, "", "", server.Client())
+}
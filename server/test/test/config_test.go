@@ -0,0 +1,471 @@
+package test
+
+import (
+	"errors"
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"server/internal/config"
+	"server/internal/model"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	// given
+	setDatabaseEnv(t)
+
+	// when
+	loaded, err := loadTestConfig()
+
+	// then
+	assert.Nil(t, err)
+	expected := config.Default()
+	expected.Database.Host = "localhost"
+	expected.Database.Username = "server"
+	expected.Database.Password = "secret"
+	expected.Database.Name = "server"
+	assert.Equal(t, expected, *loaded)
+	assert.Equal(t, ":8080", loaded.Http.ServerConfig().Address)
+	assert.Empty(t, loaded.Grpc.Address())
+}
+
+func TestLoadConfig_Precedence(t *testing.T) {
+	// given
+	setDatabaseEnv(t)
+	configFile := writePacksFile(
+		t, "config.yaml", `
+http:
+  port: 8081
+  writeTimeout: 2m
+grpc:
+  enabled: true
+  port: 9091
+jobs:
+  workers: 3
+cors:
+  allowedOrigins: [ "https://app.example.com" ]
+`,
+	)
+	t.Setenv("GRPC_PORT", "9092")
+	t.Setenv("JOBS_WORKERS", "4")
+
+	// when
+	loaded, err := loadTestConfig("-config", configFile, "-jobs.workers", "5", "-grpc.enabled=false")
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, 8081, loaded.Http.Port)
+	assert.Equal(t, 2*time.Minute, loaded.Http.WriteTimeout)
+	assert.Equal(t, 30*time.Second, loaded.Http.ReadTimeout)
+	assert.Equal(t, 9092, loaded.Grpc.Port)
+	assert.False(t, loaded.Grpc.Enabled)
+	assert.Equal(t, 5, loaded.Jobs.Workers)
+	assert.Equal(t, []string{"https://app.example.com"}, loaded.Cors.AllowedOrigins)
+}
+
+func TestLoadConfig_ConfigFileFromEnv(t *testing.T) {
+	// given
+	configFile := writePacksFile(t, "config.yaml", "packs:\n  file: packs.yaml\n")
+	t.Setenv("CONFIG_FILE", configFile)
+
+	// when
+	loaded, err := loadTestConfig()
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, "packs.yaml", loaded.Packs.File)
+	assert.False(t, loaded.UsesDatabase())
+}
+
+func TestLoadConfig_SecretFile(t *testing.T) {
+	// given
+	setDatabaseEnv(t)
+	t.Setenv("DB_PASSWORD", "")
+	t.Setenv("DB_PASSWORD_FILE", writePacksFile(t, "password", "from file\n"))
+
+	// when
+	loaded, err := loadTestConfig()
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, "from file", loaded.Database.Password)
+	assert.Equal(
+		t, "host='localhost' port=5432 user='server' password='from file' dbname='server' sslmode='disable'",
+		loaded.Database.DSN(),
+	)
+}
+
+func TestLoadConfig_DatabaseUrl(t *testing.T) {
+	// given
+	t.Setenv("DATABASE_URL", "postgres://server:secret@db:5432/server")
+
+	// when
+	loaded, err := loadTestConfig()
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, "postgres://server:secret@db:5432/server", loaded.Database.DSN())
+	assert.Equal(t, "postgres://server:REDACTED@db:5432/server", loaded.Redacted().Database.URL)
+}
+
+func TestConfig_RedactedDatabaseURL(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{"user info", "postgres://server:secret@db:5432/server", "postgres://server:REDACTED@db:5432/server"},
+		{
+			"query parameters", "postgres://db/server?user=server&password=secret&sslpassword=key",
+			"postgres://db/server?password=REDACTED&sslpassword=REDACTED&user=server",
+		},
+		{"without password", "postgres://server@db/server", "postgres://server@db/server"},
+		{
+			"keyword DSN", "host=db user=server password=secret dbname=server",
+			"host=db user=server password=REDACTED dbname=server",
+		},
+		{
+			"quoted keyword DSN", `host=db password = 'sec ret\'s' sslpassword=key dbname=server`,
+			"host=db password = REDACTED sslpassword=REDACTED dbname=server",
+		},
+		{"not a URL", "postgres://server:sec%zzret@db/server", "REDACTED"},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				loaded := config.Default()
+				loaded.Database.URL = scenario.url
+
+				// when
+				redacted := loaded.Redacted()
+
+				// then
+				assert.Equal(t, scenario.expected, redacted.Database.URL)
+				assert.Equal(t, scenario.url, loaded.Database.URL)
+			},
+		)
+	}
+}
+
+func TestLoadConfig_EmptyList(t *testing.T) {
+	// given
+	setDatabaseEnv(t)
+	t.Setenv("CORS_ALLOWED_ORIGINS", "")
+	t.Setenv("CORS_ALLOWED_METHODS", "GET, POST")
+
+	// when
+	loaded, err := loadTestConfig()
+
+	// then
+	assert.Nil(t, err)
+	assert.Empty(t, loaded.Cors.AllowedOrigins)
+	assert.Equal(t, []string{"GET", "POST"}, loaded.Cors.AllowedMethods)
+}
+
+func TestLoadConfig_ReportsAllProblems(t *testing.T) {
+	// given
+	configFile := writePacksFile(t, "config.yaml", "http:\n  prot: 8081\n")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "90")
+	t.Setenv("RATE_LIMIT_STORE", "redis")
+	t.Setenv("DB_NAME", "server")
+	t.Setenv("DB_NAME_FILE", "name")
+
+	// when
+	loaded, err := loadTestConfig("-config", configFile, "-jobs.workers", "0")
+
+	// then
+	assert.Nil(t, loaded)
+	var invalidConfig *model.InvalidConfig
+	assert.True(t, errors.As(err, &invalidConfig))
+	assert.Len(t, invalidConfig.Problems, 9)
+	assert.Contains(t, invalidConfig.Problems[0], "field prot not found")
+	assert.Equal(
+		t, []string{
+			`env DB_NAME: both DB_NAME and DB_NAME_FILE are set`,
+			`env HTTP_WRITE_TIMEOUT: "90" is not a duration, e.g. 30s or 5m`,
+			`database.host (DB_HOST) is required unless database.url (DATABASE_URL) or packs.file (PACKS_FILE) is set`,
+			`database.username (DB_USERNAME) is required unless database.url (DATABASE_URL) or packs.file (PACKS_FILE) ` +
+				`is set`,
+			`database.password (DB_PASSWORD) is required unless database.url (DATABASE_URL) or packs.file (PACKS_FILE) ` +
+				`is set`,
+			`database.name (DB_NAME) is required unless database.url (DATABASE_URL) or packs.file (PACKS_FILE) is set`,
+			`jobs.workers (JOBS_WORKERS) must be positive, got 0`,
+			`rateLimit.store (RATE_LIMIT_STORE) must be memory or postgres, got "redis"`,
+		}, invalidConfig.Problems[1:9],
+	)
+}
+
+func TestLoadConfig_Tracing(t *testing.T) {
+	// given
+	setDatabaseEnv(t)
+	t.Setenv("TRACING_ENABLED", "true")
+	t.Setenv("TRACING_ENDPOINT", "localhost:4318")
+
+	// when
+	loaded, err := loadTestConfig("-tracing.sampleRatio", "1.5")
+
+	// then
+	assert.Nil(t, loaded)
+	var invalidConfig *model.InvalidConfig
+	assert.True(t, errors.As(err, &invalidConfig))
+	assert.Equal(
+		t, []string{
+			`tracing.endpoint (TRACING_ENDPOINT) must be an http or https URL, got "localhost:4318"`,
+			`tracing.sampleRatio (TRACING_SAMPLE_RATIO) must be between 0 and 1, got 1.5`,
+		}, invalidConfig.Problems,
+	)
+}
+
+func TestLoadConfig_Log(t *testing.T) {
+	// given
+	setDatabaseEnv(t)
+	t.Setenv("LOG_PACKAGES", "service=debug,repository")
+
+	// when
+	loaded, err := loadTestConfig()
+
+	// then
+	assert.Nil(t, loaded)
+	var invalidConfig *model.InvalidConfig
+	assert.True(t, errors.As(err, &invalidConfig))
+	assert.Equal(
+		t, []string{
+			`log.level (LOG_LEVEL) or log.packages (LOG_PACKAGES): ` +
+				`invalid package log level "repository", must be like service=debug`,
+		}, invalidConfig.Problems,
+	)
+}
+
+func TestLoadConfig_Admin(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{"on its own port", []string{"-admin.enabled", "-admin.port", "9091"}, nil},
+		{"on its own address", []string{"-admin.enabled", "-admin.address", "10.0.0.1:9091"}, nil},
+		{
+			"on the API port without authentication", []string{"-admin.enabled"}, []string{
+				"admin.enabled (ADMIN_ENABLED) needs admin.address (ADMIN_ADDRESS) or admin.port (ADMIN_PORT), " +
+					"or auth.apiKeysFile (AUTH_API_KEYS_FILE), auth.jwks (AUTH_JWKS) or " +
+					"auth.clientCertificatesFile (AUTH_CLIENT_CERTIFICATES_FILE) for the admin role",
+			},
+		},
+		{"on the API port with authentication", []string{"-admin.enabled", "-auth.apiKeysFile", "keys.yaml"}, nil},
+		{
+			"on the API port number", []string{"-admin.enabled", "-admin.port", "8080"},
+			[]string{"admin.port (ADMIN_PORT) must not be http.port (HTTP_PORT)"},
+		},
+		{"disabled", []string{"-admin.port", "8080"}, nil},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				setDatabaseEnv(t)
+
+				// when
+				loaded, err := loadTestConfig(scenario.args...)
+
+				// then
+				if scenario.expected == nil {
+					assert.Nil(t, err)
+					assert.NotNil(t, loaded)
+					return
+				}
+				var invalidConfig *model.InvalidConfig
+				assert.True(t, errors.As(err, &invalidConfig))
+				assert.Equal(t, scenario.expected, invalidConfig.Problems)
+			},
+		)
+	}
+}
+
+func TestLoadConfig_AuthDisabled(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{"disabled", []string{"-auth.disabled"}, nil},
+		{
+			"disabled with API keys", []string{"-auth.disabled", "-auth.apiKeysFile", "keys.yaml"}, []string{
+				"auth.disabled (AUTH_DISABLED) must not be set with auth.apiKeysFile (AUTH_API_KEYS_FILE), " +
+					"auth.jwks (AUTH_JWKS) or auth.clientCertificatesFile (AUTH_CLIENT_CERTIFICATES_FILE)",
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				setDatabaseEnv(t)
+
+				// when
+				loaded, err := loadTestConfig(scenario.args...)
+
+				// then
+				if scenario.expected == nil {
+					assert.Nil(t, err)
+					assert.True(t, loaded.Auth.Disabled)
+					return
+				}
+				var invalidConfig *model.InvalidConfig
+				assert.True(t, errors.As(err, &invalidConfig))
+				assert.Equal(t, scenario.expected, invalidConfig.Problems)
+			},
+		)
+	}
+}
+
+func TestAdminConfig_ServerAddress(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		config   config.AdminConfig
+		expected string
+	}{
+		{"on the loopback interface", config.AdminConfig{Enabled: true, Port: 9091}, "127.0.0.1:9091"},
+		{"on its address", config.AdminConfig{Enabled: true, Address: ":9092", Port: 9091}, ":9092"},
+		{"on the API server", config.AdminConfig{Enabled: true}, ""},
+		{"disabled", config.AdminConfig{Port: 9091}, ""},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// when
+				address := scenario.config.ServerAddress()
+
+				// then
+				assert.Equal(t, scenario.expected, address)
+			},
+		)
+	}
+}
+
+func TestLoadConfig_Quotes(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		env      map[string]string
+		expected []string
+	}{
+		{"without tokens", nil, nil},
+		{"with a signing key", map[string]string{"QUOTES_SIGNING_KEY": "0123456789abcdef0123456789abcdef"}, nil},
+		{
+			"with a short signing key", map[string]string{"QUOTES_SIGNING_KEY": "secret"},
+			[]string{"quotes.signingKey (QUOTES_SIGNING_KEY) must be at least 32 bytes long, got 6"},
+		},
+		{"with no ttl", map[string]string{"QUOTES_TTL": "0s"}, []string{"quotes.ttl (QUOTES_TTL) must be positive, got 0s"}},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				setDatabaseEnv(t)
+				for name, value := range scenario.env {
+					t.Setenv(name, value)
+				}
+
+				// when
+				loaded, err := loadTestConfig()
+
+				// then
+				if scenario.expected == nil {
+					assert.Nil(t, err)
+					assert.NotEqual(t, "0123456789abcdef0123456789abcdef", loaded.Redacted().Quotes.SigningKey)
+					return
+				}
+				var invalidConfig *model.InvalidConfig
+				assert.True(t, errors.As(err, &invalidConfig))
+				assert.Equal(t, scenario.expected, invalidConfig.Problems)
+			},
+		)
+	}
+}
+
+func TestLoadConfig_TrustedProxies(t *testing.T) {
+	// given
+	setDatabaseEnv(t)
+	t.Setenv("HTTP_TRUSTED_PROXIES", "10.0.0.0/8,192.168.1.1,proxy")
+
+	// when
+	_, err := loadTestConfig()
+
+	// then
+	var invalidConfig *model.InvalidConfig
+	assert.True(t, errors.As(err, &invalidConfig))
+	assert.Equal(
+		t, []string{`http.trustedProxies (HTTP_TRUSTED_PROXIES) has an invalid IP or CIDR "proxy"`},
+		invalidConfig.Problems,
+	)
+}
+
+func TestLoadConfig_Redacted(t *testing.T) {
+	// given
+	setDatabaseEnv(t)
+
+	// when
+	loaded, err := loadTestConfig()
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, "REDACTED", loaded.Redacted().Database.Password)
+	assert.Equal(t, "secret", loaded.Database.Password)
+}
+
+func TestLoadConfig_NoFlagsForSecrets(t *testing.T) {
+	for _, flagName := range []string{"-database.url", "-database.password", "-quotes.signingKey"} {
+		t.Run(
+			flagName, func(t *testing.T) {
+				// given
+				setDatabaseEnv(t)
+
+				// when
+				_, err := loadTestConfig(flagName, "secret")
+
+				// then
+				assert.NotNil(t, err)
+				assert.Contains(t, err.Error(), "flag provided but not defined")
+			},
+		)
+	}
+}
+
+func TestLoadConfig_ExampleHasTheDefaults(t *testing.T) {
+	// given
+	t.Setenv("DB_PASSWORD", "secret")
+
+	// when
+	loaded, err := loadTestConfig("-config", "../../config.example.yaml")
+
+	// then
+	assert.Nil(t, err)
+	expected := config.Default()
+	expected.Database.Host = "localhost"
+	expected.Database.Username = "server"
+	expected.Database.Password = "secret"
+	expected.Database.Name = "server"
+	assert.Equal(t, expected, *loaded)
+}
+
+func setDatabaseEnv(t *testing.T) {
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_USERNAME", "server")
+	t.Setenv("DB_PASSWORD", "secret")
+	t.Setenv("DB_NAME", "server")
+}
+
+func loadTestConfig(args ...string) (*config.Config, error) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	loader := config.NewLoader(flags)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	return loader.Load()
+}
@@ -0,0 +1,133 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"server/internal/appcontext"
+	"server/internal/controller"
+	"server/internal/model"
+	"server/internal/service"
+	"server/test/stub"
+	"testing"
+)
+
+func TestPackageV2(t *testing.T) {
+	// given
+	gin.SetMode(gin.TestMode)
+	packsService := stub.PacksServiceStub{Sizes: []int{250, 500, 1000, 2000, 5000}}
+	router := controller.SetupRouter(
+		&appcontext.AppContext{PackingService: service.NewPackagingService(packsService)},
+	)
+
+	// when
+	response := executeJSONRequest(router, "POST", "/api/v2/package", map[string]interface{}{"numberOfItems": 12001})
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var result model.ProductPackageResponseV2
+	assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &result))
+	assert.Equal(
+		t, model.ProductPackageResponseV2{
+			NumberOfItems: 12001,
+			Lines: []model.PackagingLine{
+				{PackSize: 5000, Quantity: 2, Items: 10000},
+				{PackSize: 2000, Quantity: 1, Items: 2000},
+				{PackSize: 250, Quantity: 1, Items: 250},
+			},
+			TotalPacks:   4,
+			TotalItems:   12250,
+			Overage:      249,
+			PacksVersion: service.PacksConfigVersion([]int{250, 500, 1000, 2000, 5000}),
+		}, result,
+	)
+}
+
+func TestPackageV2_EmptyPacksConfig(t *testing.T) {
+	// given
+	gin.SetMode(gin.TestMode)
+	packsService := stub.PacksServiceStub{Sizes: []int{}}
+	router := controller.SetupRouter(
+		&appcontext.AppContext{PackingService: service.NewPackagingService(packsService)},
+	)
+
+	// when
+	response := executeJSONRequest(router, "POST", "/api/v2/package", map[string]interface{}{"numberOfItems": 1})
+
+	// then
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{"error": "no packs configured"}`, response.Body.String())
+}
+
+func TestPackageV1_Unchanged(t *testing.T) {
+	// given
+	gin.SetMode(gin.TestMode)
+	packsService := stub.PacksServiceStub{Sizes: []int{100, 200, 1000}}
+	router := controller.SetupRouter(
+		&appcontext.AppContext{PackingService: service.NewPackagingService(packsService)},
+	)
+
+	// when
+	response := executeJSONRequest(router, "POST", "/api/package", map[string]interface{}{"numberOfItems": 1001})
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"1000": 1, "100": 1}`, response.Body.String())
+}
+
+func TestPackageV2_StalePacksConfig(t *testing.T) {
+	// given
+	gin.SetMode(gin.TestMode)
+	packsService := stub.PacksServiceStub{Sizes: []int{100}, Stale: true}
+	router := controller.SetupRouter(
+		&appcontext.AppContext{PackingService: service.NewPackagingService(packsService)},
+	)
+
+	// when
+	response := executeJSONRequest(router, "POST", "/api/v2/package", map[string]interface{}{"numberOfItems": 1})
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "true", response.Header().Get("X-Packs-Stale"))
+
+	var result model.ProductPackageResponseV2
+	assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &result))
+	assert.True(t, result.Stale)
+}
+
+func TestGetPacksV2(t *testing.T) {
+	// given
+	router := testRouter(
+		&appcontext.AppContext{PacksService: stub.PacksServiceStub{Sizes: []int{100, 200}}},
+	)
+
+	// when
+	response := executeJSONRequest(router, "GET", "/api/v2/packs", nil)
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var result model.PacksResponseV2
+	assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &result))
+	assert.Equal(
+		t, model.PacksResponseV2{Packs: []int{100, 200}, Version: service.PacksConfigVersion([]int{100, 200})},
+		result,
+	)
+}
+
+func TestGetPacksV2_Error(t *testing.T) {
+	// given
+	router := testRouter(
+		&appcontext.AppContext{PacksService: stub.PacksServiceStub{Error: errors.New("error")}},
+	)
+
+	// when
+	response := executeJSONRequest(router, "GET", "/api/v2/packs", nil)
+
+	// then
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.JSONEq(t, `{"error": "failed to get packs"}`, response.Body.String())
+}
@@ -0,0 +1,230 @@
+package test
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/appcontext"
+	"server/internal/controller"
+	"server/internal/model"
+	"server/test/stub"
+	"testing"
+	"time"
+)
+
+func TestValidateCorsConfig_Valid(t *testing.T) {
+	scenarios := []struct {
+		name   string
+		config model.CorsConfig
+	}{
+		{name: "disabled", config: model.CorsConfig{}},
+		{name: "any origin", config: model.CorsConfig{AllowedOrigins: []string{"*"}}},
+		{
+			name: "origins with credentials",
+			config: model.CorsConfig{
+				AllowedOrigins: []string{
+					"https://app.example.com", "https://*.example.com", "https://*.example.com:8443",
+					"http://localhost:4200",
+				},
+				AllowedMethods:   []string{"GET", "POST"},
+				AllowedHeaders:   []string{"Content-Type", "Authorization"},
+				AllowCredentials: true,
+				MaxAge:           time.Hour,
+			},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// when
+				err := controller.ValidateCorsConfig(scenario.config)
+
+				// then
+				assert.Nil(t, err)
+			},
+		)
+	}
+}
+
+func TestValidateCorsConfig_Invalid(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		config   model.CorsConfig
+		expected string
+	}{
+		{
+			name:     "any origin with credentials",
+			config:   model.CorsConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			expected: `origin "*" can not be used with credentials`,
+		},
+		{
+			name:     "any origin with other origins",
+			config:   model.CorsConfig{AllowedOrigins: []string{"*", "https://example.com"}},
+			expected: `origin "*" allows any origin, it can not be combined with other origins`,
+		},
+		{
+			name:     "no scheme",
+			config:   model.CorsConfig{AllowedOrigins: []string{"example.com"}},
+			expected: `origin "example.com" must start with http:// or https://`,
+		},
+		{
+			name:     "with path",
+			config:   model.CorsConfig{AllowedOrigins: []string{"https://example.com/"}},
+			expected: `origin "https://example.com/" must be a scheme and a host, without a path`,
+		},
+		{
+			name:     "two wildcards",
+			config:   model.CorsConfig{AllowedOrigins: []string{"https://*.*.example.com"}},
+			expected: `origin "https://*.*.example.com" can only have a leading *. wildcard before a domain`,
+		},
+		{
+			name:     "wildcard glued to a name",
+			config:   model.CorsConfig{AllowedOrigins: []string{"https://*evil.com"}},
+			expected: `origin "https://*evil.com" can only have a leading *. wildcard before a domain`,
+		},
+		{
+			name:     "wildcard TLD",
+			config:   model.CorsConfig{AllowedOrigins: []string{"https://*.com"}},
+			expected: `origin "https://*.com" can only have a leading *. wildcard before a domain`,
+		},
+		{
+			name:     "wildcard without scheme",
+			config:   model.CorsConfig{AllowedOrigins: []string{"*.com"}},
+			expected: `origin "*.com" must start with http:// or https://`,
+		},
+		{
+			name:     "wildcard in the middle",
+			config:   model.CorsConfig{AllowedOrigins: []string{"https://a.*.example.com"}},
+			expected: `origin "https://a.*.example.com" can only have a leading *. wildcard before a domain`,
+		},
+		{
+			name:     "trailing wildcard",
+			config:   model.CorsConfig{AllowedOrigins: []string{"https://example.*"}},
+			expected: `origin "https://example.*" can only have a leading *. wildcard before a domain`,
+		},
+		{
+			name:     "unknown method",
+			config:   model.CorsConfig{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"get"}},
+			expected: `method "get" is not supported`,
+		},
+		{
+			name: "any header with credentials",
+			config: model.CorsConfig{
+				AllowedOrigins: []string{"https://example.com"}, AllowedHeaders: []string{"*"}, AllowCredentials: true,
+			},
+			expected: `header "*" is not a wildcard when credentials are allowed`,
+		},
+		{
+			name:     "negative max age",
+			config:   model.CorsConfig{AllowedOrigins: []string{"*"}, MaxAge: -time.Second},
+			expected: "max age must not be negative",
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// when
+				err := controller.ValidateCorsConfig(scenario.config)
+
+				// then
+				assert.NotNil(t, err)
+				assert.Contains(t, err.Error(), scenario.expected)
+			},
+		)
+	}
+}
+
+func TestValidateCorsConfig_ReportsAllProblems(t *testing.T) {
+	// given
+	config := model.CorsConfig{
+		AllowedOrigins:   []string{"*", "example.com"},
+		AllowedMethods:   []string{"FETCH"},
+		AllowCredentials: true,
+	}
+
+	// when
+	err := controller.ValidateCorsConfig(config)
+
+	// then
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), `origin "*" can not be used with credentials`)
+	assert.Contains(t, err.Error(), `origin "example.com" must start with http:// or https://`)
+	assert.Contains(t, err.Error(), `method "FETCH" is not supported`)
+}
+
+func TestCors_Preflight(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		origin         string
+		expectedOrigin string
+	}{
+		{name: "listed origin", origin: "https://app.example.com", expectedOrigin: "https://app.example.com"},
+		{name: "wildcard origin", origin: "https://orders.example.com", expectedOrigin: "https://orders.example.com"},
+		{name: "other origin", origin: "https://example.org", expectedOrigin: ""},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				router := corsRouter(
+					model.CorsConfig{
+						AllowedOrigins:   []string{"https://app.example.com", "https://*.example.com"},
+						AllowCredentials: true,
+						MaxAge:           time.Hour,
+					},
+				)
+
+				// when
+				response := executePreflightRequest(router, scenario.origin)
+
+				// then
+				assert.Equal(t, scenario.expectedOrigin, response.Header().Get("Access-Control-Allow-Origin"))
+				if scenario.expectedOrigin != "" {
+					assert.Equal(t, http.StatusNoContent, response.Code)
+					assert.Equal(t, "true", response.Header().Get("Access-Control-Allow-Credentials"))
+					assert.Equal(t, "3600", response.Header().Get("Access-Control-Max-Age"))
+					assert.Contains(t, response.Header().Get("Access-Control-Allow-Headers"), "X-Api-Key")
+				} else {
+					assert.Equal(t, http.StatusForbidden, response.Code)
+				}
+			},
+		)
+	}
+}
+
+func TestCors_AnyOrigin(t *testing.T) {
+	// given
+	router := corsRouter(model.CorsConfig{AllowedOrigins: []string{"*"}})
+
+	// when
+	response := executePreflightRequest(router, "https://example.org")
+
+	// then
+	assert.Equal(t, "*", response.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, response.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCors_Disabled(t *testing.T) {
+	// given
+	router := corsRouter(model.CorsConfig{})
+
+	// when
+	response := executePreflightRequest(router, "https://example.org")
+
+	// then
+	assert.Empty(t, response.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func corsRouter(config model.CorsConfig) *gin.Engine {
+	return testRouter(
+		&appcontext.AppContext{PacksService: stub.PacksServiceStub{Sizes: []int{250}}, CorsConfig: config},
+	)
+}
+
+func executePreflightRequest(router *gin.Engine, origin string) *httptest.ResponseRecorder {
+	return executeRequest(router, "OPTIONS", "/api/packs", "", "Origin", origin, "Access-Control-Request-Method", "GET")
+}
@@ -0,0 +1,179 @@
+package test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"server/internal/model"
+	"server/internal/repository"
+	"testing"
+	"time"
+)
+
+func TestFileRepository_LoadYaml(t *testing.T) {
+	// given
+	path := writePacksFile(t, "packs.yaml", "packs: [1000, 100, 200]\n")
+
+	// when
+	repo, err := repository.NewFilePacksRepository(path, false, 0)
+
+	// then
+	assert.Nil(t, err)
+	packs, err := repo.FindAll(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []model.Pack{{Size: 100}, {Size: 200}, {Size: 1000}}, packs)
+}
+
+func TestFileRepository_LoadJson(t *testing.T) {
+	// given
+	path := writePacksFile(t, "packs.json", `{"packs": [250, 500]}`)
+
+	// when
+	repo, err := repository.NewFilePacksRepository(path, false, 0)
+
+	// then
+	assert.Nil(t, err)
+	packs, err := repo.FindAll(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []model.Pack{{Size: 250}, {Size: 500}}, packs)
+}
+
+func TestFileRepository_InvalidFile(t *testing.T) {
+	scenarios := []struct {
+		name    string
+		content string
+	}{
+		{name: "not parsable", content: "packs: [1, 2"},
+		{name: "negative size", content: "packs: [100, -1]"},
+		{name: "zero size", content: "packs: [0]"},
+		{name: "duplicate size", content: "packs: [100, 100]"},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				path := writePacksFile(t, "packs.yaml", scenario.content)
+
+				// when
+				repo, err := repository.NewFilePacksRepository(path, false, 0)
+
+				// then
+				assert.Nil(t, repo)
+				assert.IsType(t, &model.InvalidPacksConfig{}, err)
+			},
+		)
+	}
+}
+
+func TestFileRepository_HotReload(t *testing.T) {
+	// given
+	path := writePacksFile(t, "packs.yaml", "packs: [100, 200]")
+	repo, err := repository.NewFilePacksRepository(path, false, 10*time.Millisecond)
+	assert.Nil(t, err)
+	defer repo.Close()
+
+	// when
+	writePacksFileAt(t, path, "packs: [300, 400, 500]")
+
+	// then
+	assert.Eventually(
+		t, func() bool {
+			packs, _ := repo.FindAll(context.Background())
+			return len(packs) == 3
+		}, time.Second, 10*time.Millisecond,
+	)
+}
+
+func TestFileRepository_HotReload_KeepsLastGoodConfig(t *testing.T) {
+	scenarios := []struct {
+		name    string
+		content string
+	}{
+		{"invalid size", "packs: [100, -200, 300]"},
+		{"empty file", ""},
+		{"no packs", "packs: []"},
+		{"misspelled key", "pack: [300]"},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				path := writePacksFile(t, "packs.yaml", "packs: [100, 200]")
+				repo, err := repository.NewFilePacksRepository(path, false, 10*time.Millisecond)
+				assert.Nil(t, err)
+				defer repo.Close()
+
+				// when
+				writePacksFileAt(t, path, scenario.content)
+				time.Sleep(100 * time.Millisecond)
+
+				// then
+				packs, err := repo.FindAll(context.Background())
+				assert.Nil(t, err)
+				assert.Equal(t, []model.Pack{{Size: 100}, {Size: 200}}, packs)
+			},
+		)
+	}
+}
+
+func TestFileRepository_SyncPacks_ReadOnly(t *testing.T) {
+	// given
+	path := writePacksFile(t, "packs.yaml", "packs: [100, 200]")
+	repo, _ := repository.NewFilePacksRepository(path, false, 0)
+
+	// when
+	err := repo.SyncPacks(context.Background(), []int{300})
+
+	// then
+	assert.Equal(t, &model.ReadOnlyPacksConfig{}, err)
+	packs, _ := repo.FindAll(context.Background())
+	assert.Equal(t, []model.Pack{{Size: 100}, {Size: 200}}, packs)
+}
+
+func TestFileRepository_SyncPacks_Writable(t *testing.T) {
+	// given
+	path := writePacksFile(t, "packs.json", `{"packs": [100, 200]}`)
+	repo, _ := repository.NewFilePacksRepository(path, true, 0)
+
+	// when
+	err := repo.SyncPacks(context.Background(), []int{500, 300})
+
+	// then
+	assert.Nil(t, err)
+	packs, _ := repo.FindAll(context.Background())
+	assert.Equal(t, []model.Pack{{Size: 300}, {Size: 500}}, packs)
+
+	reloaded, err := repository.NewFilePacksRepository(path, false, 0)
+	assert.Nil(t, err)
+	packs, _ = reloaded.FindAll(context.Background())
+	assert.Equal(t, []model.Pack{{Size: 300}, {Size: 500}}, packs)
+}
+
+func TestFileRepository_SyncPacks_Invalid(t *testing.T) {
+	// given
+	path := writePacksFile(t, "packs.yaml", "packs: [100, 200]")
+	repo, _ := repository.NewFilePacksRepository(path, true, 0)
+
+	// when
+	err := repo.SyncPacks(context.Background(), []int{100, 0})
+
+	// then
+	assert.IsType(t, &model.InvalidPacksConfig{}, err)
+	packs, _ := repo.FindAll(context.Background())
+	assert.Equal(t, []model.Pack{{Size: 100}, {Size: 200}}, packs)
+}
+
+func writePacksFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	writePacksFileAt(t, path, content)
+	return path
+}
+
+func writePacksFileAt(t *testing.T, path string, content string) {
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,186 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"path/filepath"
+	"server/internal/appcontext"
+	"server/internal/model"
+	"server/internal/repository"
+	"server/internal/service"
+	"server/test/stub"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestLiveness(t *testing.T) {
+	// given
+	healthService := service.NewHealthService(
+		stub.HealthRepositoryStub{PingError: errors.New("connection refused")}, stub.PacksServiceStub{},
+	)
+	router := healthRouter(healthService)
+
+	// when
+	response := executeJSONRequest(router, "GET", "/healthz", nil)
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"status": "up"}`, response.Body.String())
+}
+
+func TestReadiness(t *testing.T) {
+	// given
+	healthService := service.NewHealthService(
+		stub.HealthRepositoryStub{Version: repository.SchemaVersion}, stub.PacksServiceStub{Sizes: []int{250, 500}},
+	)
+	router := healthRouter(healthService)
+
+	// when
+	response := executeJSONRequest(router, "GET", "/readyz", nil)
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "no-store", response.Header().Get("Cache-Control"))
+
+	var report model.HealthReport
+	assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &report))
+	assert.Equal(t, model.HealthStatusUp, report.Status)
+	assert.Equal(t, []string{"database", "packs", "schema", "shutdown"}, sortedKeys(report.Checks))
+	assert.Equal(t, "2", report.Checks["packs"].Details["packs"])
+	assert.Equal(t, service.PacksConfigVersion([]int{250, 500}), report.Checks["packs"].Details["version"])
+}
+
+func TestReadiness_NotReady(t *testing.T) {
+	scenarios := []struct {
+		name          string
+		repository    stub.HealthRepositoryStub
+		packsService  stub.PacksServiceStub
+		shuttingDown  bool
+		expectedCheck string
+	}{
+		{
+			name: "database down",
+			repository: stub.HealthRepositoryStub{
+				PingError: errors.New("connection refused"), Version: repository.SchemaVersion,
+			},
+			expectedCheck: "database",
+		},
+		{
+			name:          "old schema",
+			repository:    stub.HealthRepositoryStub{Version: repository.SchemaVersion - 1},
+			expectedCheck: "schema",
+		},
+		{
+			name:          "packs not loaded",
+			repository:    stub.HealthRepositoryStub{Version: repository.SchemaVersion},
+			packsService:  stub.PacksServiceStub{Error: &model.PacksStorageUnavailable{}},
+			expectedCheck: "packs",
+		},
+		{
+			name:          "shutting down",
+			repository:    stub.HealthRepositoryStub{Version: repository.SchemaVersion},
+			shuttingDown:  true,
+			expectedCheck: "shutdown",
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				healthService := service.NewHealthService(scenario.repository, scenario.packsService)
+				if scenario.shuttingDown {
+					healthService.MarkShuttingDown()
+				}
+				router := healthRouter(healthService)
+
+				// when
+				response := executeJSONRequest(router, "GET", "/readyz", nil)
+
+				// then
+				assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+
+				var report model.HealthReport
+				assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &report))
+				assert.Equal(t, model.HealthStatusDown, report.Status)
+				for name, check := range report.Checks {
+					if name == scenario.expectedCheck {
+						assert.Equal(t, model.HealthStatusDown, check.Status)
+						assert.NotEmpty(t, check.Message)
+					} else {
+						assert.Equal(t, model.HealthStatusUp, check.Status, name)
+					}
+				}
+			},
+		)
+	}
+}
+
+func TestReadiness_WithoutDatabase(t *testing.T) {
+	// given
+	healthService := service.NewHealthService(nil, stub.PacksServiceStub{Sizes: []int{250}})
+
+	// when
+	report := healthService.Ready(t.Context())
+
+	// then
+	assert.Equal(t, model.HealthStatusUp, report.Status)
+	assert.Equal(t, []string{"packs", "shutdown"}, sortedKeys(report.Checks))
+}
+
+func TestStartup(t *testing.T) {
+	// given
+	healthService := service.NewHealthService(
+		stub.HealthRepositoryStub{Version: repository.SchemaVersion}, stub.PacksServiceStub{},
+	)
+	router := healthRouter(healthService)
+
+	// when
+	starting := executeJSONRequest(router, "GET", "/startupz", nil)
+	healthService.MarkStarted()
+	started := executeJSONRequest(router, "GET", "/startupz", nil)
+
+	// then
+	assert.Equal(t, http.StatusServiceUnavailable, starting.Code)
+	assert.Contains(t, starting.Body.String(), "starting")
+	assert.Equal(t, http.StatusOK, started.Code)
+}
+
+func TestSchemaVersion_HasMigrations(t *testing.T) {
+	// given
+	initScript, initErr := os.ReadFile("../../db-init.sql")
+	migrations, globErr := filepath.Glob("../../migrations/*.sql")
+	sort.Strings(migrations)
+
+	// then
+	assert.Nil(t, initErr)
+	assert.Nil(t, globErr)
+	assert.Contains(t, string(initScript), fmt.Sprintf("VALUES (%d);", repository.SchemaVersion))
+	assert.Len(t, migrations, repository.SchemaVersion)
+	for index, migration := range migrations {
+		version := index + 1
+		content, err := os.ReadFile(migration)
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(filepath.Base(migration), fmt.Sprintf("%03d-", version)), migration)
+		assert.Contains(t, string(content), fmt.Sprintf("INSERT INTO schema_version (version) VALUES (%d);", version))
+	}
+}
+
+func healthRouter(healthService service.HealthService) *gin.Engine {
+	return testRouter(&appcontext.AppContext{HealthService: healthService})
+}
+
+func sortedKeys(checks map[string]model.HealthCheck) []string {
+	keys := make([]string, 0, len(checks))
+	for key := range checks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
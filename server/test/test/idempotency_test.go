@@ -0,0 +1,309 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/appcontext"
+	"server/internal/controller"
+	"server/internal/model"
+	"server/internal/repository"
+	"server/internal/service"
+	"server/test/stub"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyService_Begin(t *testing.T) {
+	// given
+	idempotencyService := service.NewIdempotencyService(repository.NewInMemoryIdempotencyRepository(), time.Hour)
+	ctx := context.Background()
+
+	// when
+	stored, err := idempotencyService.Begin(ctx, "POST /api/packs", "key", "hash")
+
+	// then
+	assert.Nil(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestIdempotencyService_Begin_Replay(t *testing.T) {
+	// given
+	idempotencyService := service.NewIdempotencyService(repository.NewInMemoryIdempotencyRepository(), time.Hour)
+	ctx := context.Background()
+	response := model.IdempotentResponse{StatusCode: http.StatusOK, Body: []byte(`{"status":"OK"}`)}
+	_, _ = idempotencyService.Begin(ctx, "POST /api/packs", "key", "hash")
+	_ = idempotencyService.Complete(ctx, "POST /api/packs", "key", response)
+
+	// when
+	stored, err := idempotencyService.Begin(ctx, "POST /api/packs", "key", "hash")
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, &response, stored)
+}
+
+func TestIdempotencyService_Begin_Conflict(t *testing.T) {
+	scenarios := []struct {
+		name        string
+		complete    bool
+		requestHash string
+		expected    error
+	}{
+		{name: "different request", complete: true, requestHash: "other", expected: &model.IdempotencyKeyMismatch{}},
+		{name: "in progress", complete: false, requestHash: "hash", expected: &model.IdempotencyKeyInProgress{}},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				idempotencyService := service.NewIdempotencyService(
+					repository.NewInMemoryIdempotencyRepository(), time.Hour,
+				)
+				ctx := context.Background()
+				_, _ = idempotencyService.Begin(ctx, "POST /api/packs", "key", "hash")
+				if scenario.complete {
+					_ = idempotencyService.Complete(
+						ctx, "POST /api/packs", "key", model.IdempotentResponse{StatusCode: http.StatusOK},
+					)
+				}
+
+				// when
+				stored, err := idempotencyService.Begin(ctx, "POST /api/packs", "key", scenario.requestHash)
+
+				// then
+				assert.Nil(t, stored)
+				assert.Equal(t, scenario.expected, err)
+			},
+		)
+	}
+}
+
+func TestIdempotencyService_Begin_ScopedPerRoute(t *testing.T) {
+	// given
+	idempotencyService := service.NewIdempotencyService(repository.NewInMemoryIdempotencyRepository(), time.Hour)
+	ctx := context.Background()
+	_, _ = idempotencyService.Begin(ctx, "POST /api/packs", "key", "hash")
+
+	// when
+	stored, err := idempotencyService.Begin(ctx, "POST /api/package", "key", "other")
+
+	// then
+	assert.Nil(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestIdempotencyService_Begin_AfterRelease(t *testing.T) {
+	// given
+	idempotencyService := service.NewIdempotencyService(repository.NewInMemoryIdempotencyRepository(), time.Hour)
+	ctx := context.Background()
+	_, _ = idempotencyService.Begin(ctx, "POST /api/packs", "key", "hash")
+	_ = idempotencyService.Release(ctx, "POST /api/packs", "key")
+
+	// when
+	stored, err := idempotencyService.Begin(ctx, "POST /api/packs", "key", "other")
+
+	// then
+	assert.Nil(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestIdempotencyService_Expired(t *testing.T) {
+	// given
+	idempotencyService := service.NewIdempotencyService(repository.NewInMemoryIdempotencyRepository(), 0)
+	ctx := context.Background()
+	_, _ = idempotencyService.Begin(ctx, "POST /api/packs", "key", "hash")
+
+	// when
+	purged, err := idempotencyService.PurgeExpired(ctx)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), purged)
+	stored, err := idempotencyService.Begin(ctx, "POST /api/packs", "key", "other")
+	assert.Nil(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	// given
+	calls := 0
+	router := idempotentRouter(stub.PacksRepositoryStub{Calls: &calls})
+
+	// when
+	first := executeIdempotentRequest(router, "/api/packs", "key-1", `{"packs": [250, 500]}`)
+	second := executeIdempotentRequest(router, "/api/packs", "key-1", `{ "packs":[250,500] }`)
+
+	// then
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Header().Get("Content-Type"), second.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+}
+
+func TestIdempotency_ReplaysClientErrors(t *testing.T) {
+	// given
+	calls := 0
+	router := idempotentRouter(
+		stub.PacksRepositoryStub{Calls: &calls, Error: &model.InvalidPacksConfig{Reason: "invalid size"}},
+	)
+
+	// when
+	first := executeIdempotentRequest(router, "/api/packs", "key-1", `{"packs": [250, 300]}`)
+	second := executeIdempotentRequest(router, "/api/packs", "key-1", `{"packs": [250, 300]}`)
+
+	// then
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusBadRequest, first.Code)
+	assert.Equal(t, http.StatusBadRequest, second.Code)
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+}
+
+func TestIdempotency_DifferentBody(t *testing.T) {
+	// given
+	calls := 0
+	router := idempotentRouter(stub.PacksRepositoryStub{Calls: &calls})
+
+	// when
+	first := executeIdempotentRequest(router, "/api/packs", "key-1", `{"packs": [250, 500]}`)
+	second := executeIdempotentRequest(router, "/api/packs", "key-1", `{"packs": [250, 1000]}`)
+
+	// then
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusConflict, second.Code)
+	assert.JSONEq(t, `{"error": "Idempotency-Key was already used with a different request"}`, second.Body.String())
+}
+
+func TestIdempotency_ServerErrorNotStored(t *testing.T) {
+	// given
+	calls := 0
+	router := idempotentRouter(stub.PacksRepositoryStub{Calls: &calls, Error: errors.New("failed to sync")})
+
+	// when
+	first := executeIdempotentRequest(router, "/api/packs", "key-1", `{"packs": [250, 500]}`)
+	second := executeIdempotentRequest(router, "/api/packs", "key-1", `{"packs": [250, 500]}`)
+
+	// then
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.Equal(t, http.StatusInternalServerError, second.Code)
+	assert.Empty(t, second.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotency_WithoutKey(t *testing.T) {
+	// given
+	calls := 0
+	router := idempotentRouter(stub.PacksRepositoryStub{Calls: &calls})
+
+	// when
+	executeIdempotentRequest(router, "/api/packs", "", `{"packs": [250, 500]}`)
+	executeIdempotentRequest(router, "/api/packs", "", `{"packs": [250, 500]}`)
+
+	// then
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_Package(t *testing.T) {
+	// given
+	router := idempotentRouter(stub.PacksRepositoryStub{Packs: []model.Pack{{Size: 250}, {Size: 500}}})
+
+	// when
+	first := executeIdempotentRequest(router, "/api/package", "key-1", `{"numberOfItems": 501}`)
+	second := executeIdempotentRequest(router, "/api/package", "key-1", `{"numberOfItems": 501}`)
+	third := executeIdempotentRequest(router, "/api/package", "key-1", `{"numberOfItems": 251}`)
+
+	// then
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.JSONEq(t, `{"500": 1, "250": 1}`, second.Body.String())
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, http.StatusConflict, third.Code)
+}
+
+func TestIdempotency_ScopedPerCaller(t *testing.T) {
+	// given
+	calls := 0
+	router := idempotentRouter(stub.PacksRepositoryStub{Calls: &calls})
+
+	// when
+	first := executeIdempotentRequest(router, "/api/packs", "1", `{"packs": [250, 500]}`, "X-Caller-ID", "erp")
+	second := executeIdempotentRequest(router, "/api/packs", "1", `{"packs": [250, 1000]}`, "X-Caller-ID", "shop")
+
+	// then
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Empty(t, second.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotency_DifferentAccept(t *testing.T) {
+	// given
+	router := idempotentRouter(stub.PacksRepositoryStub{Packs: []model.Pack{{Size: 250}, {Size: 500}}})
+
+	// when
+	first := executeIdempotentRequest(router, "/api/package", "key-1", `{"numberOfItems": 501}`)
+	second := executeIdempotentRequest(
+		router, "/api/package", "key-1", `{"numberOfItems": 501}`, "Accept", "application/msgpack",
+	)
+
+	// then
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusConflict, second.Code)
+}
+
+func TestIdempotency_PanicReleasesKey(t *testing.T) {
+	// given
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	router := gin.New()
+	router.Use(controller.Recovery())
+	router.POST(
+		"/api/packs", controller.Idempotency(
+			service.NewIdempotencyService(repository.NewInMemoryIdempotencyRepository(), time.Hour),
+		), func(requestContext *gin.Context) {
+			calls++
+			if calls == 1 {
+				panic("failed")
+			}
+			requestContext.JSON(http.StatusOK, gin.H{"status": "OK"})
+		},
+	)
+
+	// when
+	first := executeIdempotentRequest(router, "/api/packs", "key-1", `{"packs": [250]}`)
+	second := executeIdempotentRequest(router, "/api/packs", "key-1", `{"packs": [250]}`)
+
+	// then
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, 2, calls)
+}
+
+func idempotentRouter(packsRepository stub.PacksRepositoryStub) *gin.Engine {
+	packsService := service.NewPacksService(packsRepository)
+	return testRouter(
+		&appcontext.AppContext{
+			AuthDisabled:   true,
+			PacksService:   packsService,
+			PackingService: service.NewPackagingService(packsService),
+			IdempotencyService: service.NewIdempotencyService(
+				repository.NewInMemoryIdempotencyRepository(), time.Hour,
+			),
+		},
+	)
+}
+
+// executeIdempotentRequest sends a POST with the key, and the headers given as name and value pairs.
+func executeIdempotentRequest(
+	router *gin.Engine, url string, key string, body string, headers ...string,
+) *httptest.ResponseRecorder {
+	return executeRequest(router, "POST", url, body, append([]string{"Idempotency-Key", key}, headers...)...)
+}
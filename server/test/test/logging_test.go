@@ -0,0 +1,206 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/appcontext"
+	"server/internal/grpcapi/pb"
+	"server/internal/logging"
+	"server/internal/model"
+	"server/internal/service"
+	"server/test/stub"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{"from the client", "order-42", "order-42"},
+		{"generated", "", ""},
+		{"invalid from the client", "order 42", ""},
+		{"too long from the client", strings.Repeat("a", 129), ""},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				router := negotiationRouter(&appcontext.AppContext{})
+				req, _ := http.NewRequest("GET", "/api/packs", nil)
+				if scenario.header != "" {
+					req.Header.Set("X-Request-ID", scenario.header)
+				}
+
+				// when
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				// then
+				requestID := w.Header().Get("X-Request-ID")
+				if scenario.expected != "" {
+					assert.Equal(t, scenario.expected, requestID)
+				} else {
+					_, err := uuid.Parse(requestID)
+					assert.Nil(t, err)
+				}
+			},
+		)
+	}
+}
+
+func TestLogging_RequestIDInEveryLine(t *testing.T) {
+	// given
+	logs := captureLogs(t, logging.Levels{Default: slog.LevelInfo})
+	router := loggingRouter()
+
+	// when
+	response := executeRequest(router, "POST", "/api/v2/packs", `{"packs": [250, 500]}`, "X-Request-ID", "sync-1")
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+	lines := logLines(t, logs)
+	assert.Equal(t, []string{"Syncing packs", "Request handled"}, messagesOf(lines))
+	for _, line := range lines {
+		assert.Equal(t, "sync-1", line["request_id"])
+	}
+	assert.Equal(t, "/api/v2/packs", lines[1]["route"])
+	assert.Equal(t, float64(200), lines[1]["status"])
+}
+
+func TestLogging_PackageLevels(t *testing.T) {
+	// given
+	logs := captureLogs(t, logging.Levels{Default: slog.LevelWarn, Packages: map[string]slog.Level{"service": slog.LevelInfo}})
+	router := loggingRouter()
+
+	// when
+	executeRequest(router, "POST", "/api/v2/packs", `{"packs": [250, 500]}`, "X-Request-ID", "sync-2")
+
+	// then
+	assert.Equal(t, []string{"Syncing packs"}, messagesOf(logLines(t, logs)))
+}
+
+func TestLogging_LogLevelsEndpoint(t *testing.T) {
+	// given
+	captureLogs(t, logging.Levels{Default: slog.LevelInfo})
+	router := loggingRouter()
+
+	// when
+	changed := executeRequest(
+		router, "PUT", "/api/log-levels", `{"level": "warn", "packages": {"service": "debug"}}`,
+		"X-Request-ID", "levels-1",
+	)
+	invalid := executeRequest(router, "PUT", "/api/log-levels", `{"level": "verbose"}`, "X-Request-ID", "levels-2")
+	current := executeRequest(router, "GET", "/api/log-levels", "", "X-Request-ID", "levels-3")
+
+	// then
+	assert.Equal(t, http.StatusOK, changed.Code)
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+	assert.Equal(t, http.StatusOK, current.Code)
+	assert.JSONEq(t, `{"level": "warn", "packages": {"service": "debug"}}`, current.Body.String())
+	assert.Equal(
+		t, logging.Levels{Default: slog.LevelWarn, Packages: map[string]slog.Level{"service": slog.LevelDebug}},
+		logging.CurrentLevels(),
+	)
+}
+
+func TestLogging_ParseLevels(t *testing.T) {
+	// when
+	levels, err := logging.ParseLevels("debug", []string{"repository=error", "controller=WARN"})
+	_, invalidLevelErr := logging.ParseLevels("verbose", nil)
+	_, invalidPackageErr := logging.ParseLevels("info", []string{"service"})
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(
+		t, logging.Levels{
+			Default:  slog.LevelDebug,
+			Packages: map[string]slog.Level{"repository": slog.LevelError, "controller": slog.LevelWarn},
+		}, levels,
+	)
+	assert.EqualError(t, invalidLevelErr, `invalid log level "verbose", must be debug, info, warn or error`)
+	assert.EqualError(t, invalidPackageErr, `invalid package log level "service", must be like service=debug`)
+}
+
+func TestGrpcRequestID(t *testing.T) {
+	// given
+	logs := captureLogs(t, logging.Levels{Default: slog.LevelInfo})
+	packsService := service.NewPacksService(stub.PacksRepositoryStub{})
+	conn := startGrpcServer(t, &appcontext.AppContext{PacksService: packsService, AuthDisabled: true})
+	client := pb.NewPackagingServiceClient(conn)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "grpc-1")
+
+	// when
+	var header metadata.MD
+	_, err := client.SyncPacks(ctx, &pb.PacksSyncRequest{Packs: []int64{250}}, grpc.Header(&header))
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"grpc-1"}, header.Get("x-request-id"))
+	lines := logLines(t, logs)
+	assert.Equal(t, []string{"Syncing packs"}, messagesOf(lines))
+	assert.Equal(t, "grpc-1", lines[0]["request_id"])
+}
+
+// captureLogs makes the logs JSON lines in the returned buffer for the test, with the levels.
+func captureLogs(t *testing.T, levels logging.Levels) *bytes.Buffer {
+	previousLogger, previousLevels := slog.Default(), logging.CurrentLevels()
+	t.Cleanup(
+		func() {
+			slog.SetDefault(previousLogger)
+			logging.SetLevels(previousLevels)
+		},
+	)
+
+	var logs bytes.Buffer
+	logging.Setup(&logs, levels)
+	return &logs
+}
+
+func loggingRouter() *gin.Engine {
+	packsService := service.NewPacksService(stub.PacksRepositoryStub{Packs: []model.Pack{{Size: 250}}})
+	return testRouter(
+		&appcontext.AppContext{
+			PacksService:   packsService,
+			PackingService: service.NewPackagingService(packsService),
+			AuthDisabled:   true,
+		},
+	)
+}
+
+func logLines(t *testing.T, logs *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var parsed map[string]any
+		if err := json.Unmarshal([]byte(line), &parsed); err != nil {
+			t.Fatalf("log line is not JSON: %s", line)
+		}
+		lines = append(lines, parsed)
+	}
+
+	return lines
+}
+
+func messagesOf(lines []map[string]any) []string {
+	var messages []string
+	for _, line := range lines {
+		messages = append(messages, line["msg"].(string))
+	}
+
+	return messages
+}
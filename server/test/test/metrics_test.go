@@ -0,0 +1,109 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"server/internal/appcontext"
+	"server/internal/metrics"
+	"server/internal/service"
+	"server/test/stub"
+	"testing"
+)
+
+func TestMetrics_HttpRequests(t *testing.T) {
+	// given
+	router := negotiationRouter(&appcontext.AppContext{})
+	packaged := metrics.HttpRequests.WithLabelValues("POST", "/api/v2/package", "200")
+	unmatched := metrics.HttpRequests.WithLabelValues("GET", "unmatched", "404")
+	packagedBefore, unmatchedBefore := testutil.ToFloat64(packaged), testutil.ToFloat64(unmatched)
+
+	// when
+	executeRequest(router, "POST", "/api/v2/package", `{"numberOfItems": 501}`)
+	executeRequest(router, "GET", "/api/v2/unknown/42", "")
+	response := executeRequest(router, "GET", "/metrics", "")
+
+	// then
+	assert.Equal(t, packagedBefore+1, testutil.ToFloat64(packaged))
+	assert.Equal(t, unmatchedBefore+1, testutil.ToFloat64(unmatched))
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), `http_request_duration_seconds_count{method="POST",route="/api/v2/package"`)
+	assert.Contains(t, response.Body.String(), "packaging_dp_table_size_bucket")
+	assert.Contains(t, response.Body.String(), "go_goroutines")
+}
+
+func TestMetrics_HttpRequestsOfNonStandardMethod(t *testing.T) {
+	// given
+	router := negotiationRouter(&appcontext.AppContext{})
+	other := metrics.HttpRequests.WithLabelValues("other", "unmatched", "404")
+	otherBefore := testutil.ToFloat64(other)
+
+	// when
+	executeRequest(router, "MADE-UP", "/api/v2/package", "")
+	response := executeRequest(router, "GET", "/metrics", "")
+
+	// then
+	assert.Equal(t, otherBefore+1, testutil.ToFloat64(other))
+	assert.NotContains(t, response.Body.String(), `method="MADE-UP"`)
+}
+
+func TestMetrics_PackagingSolves(t *testing.T) {
+	// given
+	packagingService := service.NewPackagingService(stub.PacksServiceStub{Sizes: []int{250, 500}})
+	bulk := metrics.PackagingSolves.WithLabelValues("true")
+	notBulk := metrics.PackagingSolves.WithLabelValues("false")
+	bulkBefore, notBulkBefore := testutil.ToFloat64(bulk), testutil.ToFloat64(notBulk)
+	tableSizesBefore := histogramCount(t, metrics.PackagingTableSize)
+	overageBefore := histogramSum(t, metrics.PackagingOverage)
+
+	// when
+	_, smallErr := packagingService.Calculate(context.Background(), 251)
+	_, largeErr := packagingService.Calculate(context.Background(), 1_000_000)
+
+	// then
+	assert.Nil(t, smallErr)
+	assert.Nil(t, largeErr)
+	assert.Equal(t, bulkBefore+1, testutil.ToFloat64(bulk))
+	assert.Equal(t, notBulkBefore+1, testutil.ToFloat64(notBulk))
+	assert.Equal(t, tableSizesBefore+2, histogramCount(t, metrics.PackagingTableSize))
+	// 251 items are shipped in 500, and 1000000 in 2000 packs of 500
+	assert.Equal(t, overageBefore+249, histogramSum(t, metrics.PackagingOverage))
+}
+
+func TestMetrics_PacksSyncs(t *testing.T) {
+	// given
+	failing := service.NewPacksService(stub.PacksRepositoryStub{Error: errors.New("constraint violation")})
+	succeeding := service.NewPacksService(stub.PacksRepositoryStub{})
+	failed := metrics.PacksSyncs.WithLabelValues("error")
+	succeeded := metrics.PacksSyncs.WithLabelValues("success")
+	failedBefore, succeededBefore := testutil.ToFloat64(failed), testutil.ToFloat64(succeeded)
+
+	// when
+	_ = failing.SyncPacks(context.Background(), []int{250})
+	_ = succeeding.SyncPacks(context.Background(), []int{250})
+
+	// then
+	assert.Equal(t, failedBefore+1, testutil.ToFloat64(failed))
+	assert.Equal(t, succeededBefore+1, testutil.ToFloat64(succeeded))
+}
+
+func histogramCount(t *testing.T, histogram prometheus.Histogram) uint64 {
+	return writeHistogram(t, histogram).GetSampleCount()
+}
+
+func histogramSum(t *testing.T, histogram prometheus.Histogram) float64 {
+	return writeHistogram(t, histogram).GetSampleSum()
+}
+
+func writeHistogram(t *testing.T, histogram prometheus.Histogram) *dto.Histogram {
+	var metric dto.Metric
+	if err := histogram.Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+
+	return metric.GetHistogram()
+}
@@ -0,0 +1,239 @@
+package test
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+	"net/http"
+	"server/internal/appcontext"
+	"server/internal/model"
+	"server/internal/service"
+	"server/test/stub"
+	"testing"
+	"time"
+)
+
+func TestNegotiation_Packs(t *testing.T) {
+	scenarios := []struct {
+		name        string
+		url         string
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"default", "/api/packs", "", "application/json", "[250,500]"},
+		{"any", "/api/packs", "*/*", "application/json", "[250,500]"},
+		{"csv", "/api/packs", "text/csv", "text/csv", "packSize\n250\n500\n"},
+		{
+			"ndjson", "/api/packs", "application/x-ndjson", "application/x-ndjson",
+			"{\"packSize\":250}\n{\"packSize\":500}\n",
+		},
+		{
+			"csv v2", "/api/v2/packs", "text/csv;q=0.9, application/xml", "text/csv",
+			"packSize,version,stale\n250," + packsVersion + ",false\n500," + packsVersion + ",false\n",
+		},
+		{
+			"ndjson v2", "/api/v2/packs", "application/x-ndjson", "application/x-ndjson",
+			"{\"packSize\":250,\"version\":\"" + packsVersion + "\",\"stale\":false}\n" +
+				"{\"packSize\":500,\"version\":\"" + packsVersion + "\",\"stale\":false}\n",
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				router := negotiationRouter(&appcontext.AppContext{})
+
+				// when
+				response := executeRequest(router, "GET", scenario.url, "", "Accept", scenario.accept)
+
+				// then
+				assert.Equal(t, http.StatusOK, response.Code)
+				assert.Contains(t, response.Header().Get("Content-Type"), scenario.contentType)
+				assert.Equal(t, "Accept", response.Header().Get("Vary"))
+				assert.Equal(t, scenario.body, response.Body.String())
+			},
+		)
+	}
+}
+
+func TestNegotiation_PacksMessagePack(t *testing.T) {
+	for _, accept := range []string{"application/msgpack", "application/x-msgpack"} {
+		t.Run(
+			accept, func(t *testing.T) {
+				// given
+				router := negotiationRouter(&appcontext.AppContext{})
+
+				// when
+				response := executeRequest(router, "GET", "/api/v2/packs", "", "Accept", accept)
+
+				// then
+				assert.Equal(t, http.StatusOK, response.Code)
+				assert.Equal(t, accept, response.Header().Get("Content-Type"))
+
+				var result model.PacksResponseV2
+				assert.Nil(t, codec.NewDecoderBytes(response.Body.Bytes(), new(codec.MsgpackHandle)).Decode(&result))
+				assert.Equal(t, model.PacksResponseV2{Packs: []int{250, 500}, Version: packsVersion}, result)
+			},
+		)
+	}
+}
+
+func TestNegotiation_Package(t *testing.T) {
+	scenarios := []struct {
+		name   string
+		url    string
+		accept string
+		body   string
+	}{
+		{"csv", "/api/package", "text/csv", "packSize,quantity\n500,1\n250,1\n"},
+		{
+			"ndjson", "/api/package", "application/x-ndjson",
+			"{\"packSize\":500,\"quantity\":1}\n{\"packSize\":250,\"quantity\":1}\n",
+		},
+		{
+			"csv v2", "/api/v2/package", "text/csv",
+			"numberOfItems,packSize,quantity,items,packsVersion,stale\n" +
+				"501,500,1,500," + packsVersion + ",false\n501,250,1,250," + packsVersion + ",false\n",
+		},
+		{
+			"ndjson v2", "/api/v2/package", "application/x-ndjson",
+			"{\"numberOfItems\":501,\"packSize\":500,\"quantity\":1,\"items\":500,\"packsVersion\":\"" +
+				packsVersion + "\",\"stale\":false}\n" +
+				"{\"numberOfItems\":501,\"packSize\":250,\"quantity\":1,\"items\":250,\"packsVersion\":\"" +
+				packsVersion + "\",\"stale\":false}\n",
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				router := negotiationRouter(&appcontext.AppContext{})
+
+				// when
+				response := executeRequest(router, "POST", scenario.url, `{"numberOfItems": 501}`, "Accept", scenario.accept)
+
+				// then
+				assert.Equal(t, http.StatusOK, response.Code)
+				assert.Equal(t, scenario.body, response.Body.String())
+			},
+		)
+	}
+}
+
+func TestNegotiation_History(t *testing.T) {
+	// given
+	createdAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	repository := stub.PackagingResultsRepositoryStub{
+		Results: []model.PackagingResult{
+			{
+				ID: 7, NumberOfItems: 501, Packs: map[int]int{250: 1, 500: 1}, PackSizes: []int{250, 500},
+				Objective: "min-items-then-min-packs", PacksVersion: "v1", Caller: "caller", CreatedAt: createdAt,
+			},
+		},
+		Total: 1,
+	}
+	router := negotiationRouter(
+		&appcontext.AppContext{HistoryService: service.NewPackagingHistoryService(repository)},
+	)
+
+	// when
+	csvResponse := executeRequest(router, "GET", "/api/package/history", "", "Accept", "text/csv")
+	ndjsonResponse := executeRequest(router, "GET", "/api/package/history", "", "Accept", "application/x-ndjson")
+
+	// then
+	assert.Equal(t, http.StatusOK, csvResponse.Code)
+	assert.Equal(t, "1", csvResponse.Header().Get("X-Total-Count"))
+	assert.Equal(
+		t,
+		"id,numberOfItems,packs,packSizes,objective,packsVersion,caller,createdAt\n"+
+			"7,501,500:1;250:1,250;500,min-items-then-min-packs,v1,caller,2025-01-01T10:00:00Z\n",
+		csvResponse.Body.String(),
+	)
+	assert.Equal(t, http.StatusOK, ndjsonResponse.Code)
+	assert.Equal(
+		t,
+		`{"id":7,"numberOfItems":501,"packs":{"250":1,"500":1},"packSizes":[250,500],`+
+			`"objective":"min-items-then-min-packs","packsVersion":"v1","caller":"caller",`+
+			`"createdAt":"2025-01-01T10:00:00Z"}`+"\n",
+		ndjsonResponse.Body.String(),
+	)
+}
+
+func TestNegotiation_CsvEscapesFormulas(t *testing.T) {
+	scenarios := []struct {
+		caller   string
+		expected string
+	}{
+		{"=HYPERLINK(\"https://evil.com\")", `"'=HYPERLINK(""https://evil.com"")"`},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\t=1", "'\t=1"},
+		{"caller=1", "caller=1"},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.caller, func(t *testing.T) {
+				// given
+				repository := stub.PackagingResultsRepositoryStub{
+					Results: []model.PackagingResult{
+						{
+							ID: 7, NumberOfItems: 501, Packs: map[int]int{250: 1, 500: 1}, PackSizes: []int{250, 500},
+							Objective: "min-items-then-min-packs", PacksVersion: "v1", Caller: scenario.caller,
+							CreatedAt: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+						},
+					},
+					Total: 1,
+				}
+				router := negotiationRouter(
+					&appcontext.AppContext{HistoryService: service.NewPackagingHistoryService(repository)},
+				)
+
+				// when
+				response := executeRequest(router, "GET", "/api/package/history", "", "Accept", "text/csv")
+
+				// then
+				assert.Equal(t, http.StatusOK, response.Code)
+				assert.Contains(
+					t, response.Body.String(), ",v1,"+scenario.expected+",2025-01-01T10:00:00Z\n",
+				)
+			},
+		)
+	}
+}
+
+func TestNegotiation_NotAcceptable(t *testing.T) {
+	// given
+	var saved []model.PackagingResult
+	router := negotiationRouter(
+		&appcontext.AppContext{
+			HistoryService: service.NewPackagingHistoryService(stub.PackagingResultsRepositoryStub{Saved: &saved}),
+		},
+	)
+
+	// when
+	packsResponse := executeRequest(router, "GET", "/api/packs", "", "Accept", "application/xml")
+	packageResponse := executeRequest(
+		router, "POST", "/api/v2/package", `{"numberOfItems": 501}`, "Accept", "application/xml",
+	)
+
+	// then
+	assert.Equal(t, http.StatusNotAcceptable, packsResponse.Code)
+	assert.Equal(t, http.StatusNotAcceptable, packageResponse.Code)
+	assert.Contains(t, packageResponse.Body.String(), "text/csv")
+	assert.Empty(t, saved)
+}
+
+var packsVersion = service.PacksConfigVersion([]int{250, 500})
+
+func negotiationRouter(appContext *appcontext.AppContext) *gin.Engine {
+	packsService := stub.PacksServiceStub{Sizes: []int{250, 500}}
+	appContext.PacksService = packsService
+	appContext.PackingService = service.NewPackagingService(packsService)
+
+	return testRouter(appContext)
+}
@@ -0,0 +1,151 @@
+package test
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/appcontext"
+	"server/internal/controller"
+	"server/internal/openapi"
+	"server/internal/service"
+	"server/test/stub"
+	"testing"
+)
+
+func TestOpenAPISpec_Valid(t *testing.T) {
+	// when
+	spec, err := openapi.Load()
+
+	// then
+	assert.Nil(t, err)
+	assert.NotNil(t, spec.Paths.Find("/package"))
+	assert.NotNil(t, spec.Paths.Find("/packs"))
+	assert.NotNil(t, spec.Paths.Find("/v2/package"))
+}
+
+func TestOpenAPISpec_Served(t *testing.T) {
+	// given
+	router := testRouter(&appcontext.AppContext{})
+
+	// when
+	response := executeJSONRequest(router, "GET", "/api/openapi.json", nil)
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var spec map[string]interface{}
+	assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &spec))
+	assert.Equal(t, "3.0.3", spec["openapi"])
+	assert.Contains(t, spec["paths"], "/package")
+}
+
+func TestSwaggerUI_Served(t *testing.T) {
+	// given
+	router := testRouter(&appcontext.AppContext{})
+
+	// when
+	response := executeJSONRequest(router, "GET", "/api/docs", nil)
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), "/api/openapi.json")
+}
+
+func TestOpenAPIValidator_InvalidRequest(t *testing.T) {
+	// given
+	gin.SetMode(gin.TestMode)
+	packsService := stub.PacksServiceStub{Sizes: []int{100}}
+	router := controller.SetupRouter(
+		&appcontext.AppContext{PackingService: service.NewPackagingService(packsService)},
+	)
+
+	// when
+	response := executeJSONRequest(router, "POST", "/api/package", map[string]interface{}{"numberOfItems": "many"})
+
+	// then
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Contains(t, response.Body.String(), "numberOfItems")
+}
+
+func TestOpenAPIValidator_UndocumentedRoute(t *testing.T) {
+	// given
+	spec, _ := openapi.Load()
+	router := gin.New()
+	api := router.Group("/api", controller.OpenAPIValidator(spec, true))
+	api.GET("/undocumented", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+
+	// when
+	response := executeJSONRequest(router, "GET", "/api/undocumented", nil)
+
+	// then
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.Contains(t, response.Body.String(), "not documented")
+}
+
+func TestOpenAPIValidator_UndocumentedRoute_NotValidatingResponses(t *testing.T) {
+	// given
+	spec, _ := openapi.Load()
+	router := gin.New()
+	api := router.Group("/api", controller.OpenAPIValidator(spec, false))
+	api.GET("/undocumented", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+
+	// when
+	response := executeJSONRequest(router, "GET", "/api/undocumented", nil)
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+}
+
+func TestOpenAPIValidator_InvalidResponse(t *testing.T) {
+	scenarios := []struct {
+		name    string
+		handler gin.HandlerFunc
+	}{
+		{
+			name:    "undocumented body",
+			handler: func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"packs": "none"}) },
+		},
+		{
+			name:    "undocumented status",
+			handler: func(c *gin.Context) { c.JSON(http.StatusTeapot, gin.H{"error": "teapot"}) },
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				spec, _ := openapi.Load()
+				router := gin.New()
+				api := router.Group("/api", controller.OpenAPIValidator(spec, true))
+				api.GET("/packs", scenario.handler)
+
+				// when
+				response := executeJSONRequest(router, "GET", "/api/packs", nil)
+
+				// then
+				assert.Equal(t, http.StatusInternalServerError, response.Code)
+				assert.Contains(t, response.Body.String(), "does not match the OpenAPI spec")
+			},
+		)
+	}
+}
+
+func TestOpenAPIValidator_ValidResponse(t *testing.T) {
+	// given
+	spec, _ := openapi.Load()
+	router := gin.New()
+	api := router.Group("/api", controller.OpenAPIValidator(spec, true))
+	api.GET("/packs", func(c *gin.Context) { c.JSON(http.StatusOK, []int{100, 200}) })
+
+	// when
+	response := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/packs", nil)
+	router.ServeHTTP(response, request)
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "[100,200]", response.Body.String())
+}
@@ -0,0 +1,327 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/appcontext"
+	"server/internal/model"
+	"server/internal/service"
+	"server/test/stub"
+	"testing"
+)
+
+func TestOrder(t *testing.T) {
+	// given
+	router := testRouter(ordersAppContext(&stub.OrdersRepositoryStub{}, []int{250, 500, 1000}))
+
+	// when
+	created := executeRequest(router, "POST", "/api/orders", `{"customerReference": "CUST-42", "quantity": 501}`)
+	order := orderOf(t, created)
+	found := executeRequest(router, "GET", "/api/orders/"+order.ID, "")
+
+	// then
+	assert.Equal(t, http.StatusCreated, created.Code)
+	assert.Equal(t, "/api/orders/"+order.ID, created.Header().Get("Location"))
+	assert.Equal(t, "CUST-42", order.CustomerReference)
+	assert.Equal(t, 501, order.Quantity)
+	assert.Equal(t, model.OrderStatusQuoted, order.Status)
+	assert.Equal(
+		t, []model.PackagingLine{{PackSize: 500, Quantity: 1, Items: 500}, {PackSize: 250, Quantity: 1, Items: 250}},
+		order.Lines,
+	)
+	assert.Equal(t, 2, order.TotalPacks)
+	assert.Equal(t, 750, order.TotalItems)
+	assert.Equal(t, 249, order.Overage)
+	assert.Equal(t, service.PacksConfigVersion([]int{250, 500, 1000}), order.PacksVersion)
+	assert.NotNil(t, order.QuotedAt)
+	assert.Nil(t, order.ConfirmedAt)
+
+	assert.Equal(t, http.StatusOK, found.Code)
+	assert.Equal(t, order, orderOf(t, found))
+}
+
+func TestOrder_Transitions(t *testing.T) {
+	// given
+	router := testRouter(ordersAppContext(&stub.OrdersRepositoryStub{}, []int{250}))
+	order := orderOf(
+		t, executeRequest(router, "POST", "/api/orders", `{"customerReference": "CUST-42", "quantity": 1}`),
+	)
+
+	// when
+	confirmed := executeTransitionRequest(router, order.ID, model.OrderStatusConfirmed)
+	packed := executeTransitionRequest(router, order.ID, model.OrderStatusPacked)
+	shipped := executeTransitionRequest(router, order.ID, model.OrderStatusShipped)
+	cancelled := executeTransitionRequest(router, order.ID, model.OrderStatusCancelled)
+	found := orderOf(t, executeRequest(router, "GET", "/api/orders/"+order.ID, ""))
+
+	// then
+	assert.Equal(t, http.StatusOK, confirmed.Code)
+	assert.Equal(t, model.OrderStatusConfirmed, orderOf(t, confirmed).Status)
+	assert.Equal(t, http.StatusOK, packed.Code)
+	assert.Equal(t, http.StatusOK, shipped.Code)
+	assert.Equal(t, http.StatusConflict, cancelled.Code)
+	assert.JSONEq(t, `{"error": "order `+order.ID+` can not go from shipped to cancelled"}`, cancelled.Body.String())
+
+	assert.Equal(t, model.OrderStatusShipped, found.Status)
+	assert.NotNil(t, found.ConfirmedAt)
+	assert.NotNil(t, found.PackedAt)
+	assert.NotNil(t, found.ShippedAt)
+	assert.Nil(t, found.CancelledAt)
+	assert.False(t, found.ShippedAt.Before(*found.PackedAt))
+	assert.Equal(t, *found.ShippedAt, found.UpdatedAt)
+}
+
+func TestOrdersService_Transition(t *testing.T) {
+	scenarios := []struct {
+		from     []string
+		to       string
+		expected error
+	}{
+		{nil, model.OrderStatusConfirmed, nil},
+		{nil, model.OrderStatusCancelled, nil},
+		{nil, model.OrderStatusPacked, &model.IllegalOrderTransition{From: "quoted", To: "packed"}},
+		{nil, model.OrderStatusQuoted, &model.IllegalOrderTransition{From: "quoted", To: "quoted"}},
+		{[]string{model.OrderStatusConfirmed}, model.OrderStatusPacked, nil},
+		{[]string{model.OrderStatusConfirmed}, model.OrderStatusCancelled, nil},
+		{
+			[]string{model.OrderStatusConfirmed}, model.OrderStatusShipped,
+			&model.IllegalOrderTransition{From: "confirmed", To: "shipped"},
+		},
+		{[]string{model.OrderStatusConfirmed, model.OrderStatusPacked}, model.OrderStatusCancelled, nil},
+		{
+			[]string{model.OrderStatusCancelled}, model.OrderStatusConfirmed,
+			&model.IllegalOrderTransition{From: "cancelled", To: "confirmed"},
+		},
+		{nil, "lost", &model.InvalidOrderStatus{Status: "lost"}},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.to+" after "+lastStatusOf(scenario.from), func(t *testing.T) {
+				// given
+				ordersService := testOrdersService(&stub.OrdersRepositoryStub{})
+				ctx := context.Background()
+				order, _ := ordersService.Create(ctx, model.OrderRequest{CustomerReference: "C", Quantity: 1}, "test")
+				for _, status := range scenario.from {
+					_, _ = ordersService.Transition(ctx, order.ID, status)
+				}
+
+				// when
+				changed, err := ordersService.Transition(ctx, order.ID, scenario.to)
+
+				// then
+				if illegalTransition, ok := scenario.expected.(*model.IllegalOrderTransition); ok {
+					illegalTransition.ID = order.ID
+				}
+				if scenario.expected != nil {
+					assert.Equal(t, scenario.expected, err)
+					assert.Nil(t, changed)
+				} else {
+					assert.Nil(t, err)
+					assert.Equal(t, scenario.to, changed.Status)
+				}
+			},
+		)
+	}
+}
+
+func TestOrdersService_ConcurrentTransition(t *testing.T) {
+	// given
+	repo := &stub.OrdersRepositoryStub{}
+	ordersService := testOrdersService(repo)
+	ctx := context.Background()
+	order, _ := ordersService.Create(ctx, model.OrderRequest{CustomerReference: "C", Quantity: 1}, "test")
+
+	// when
+	// the order is confirmed by another request while it is being cancelled, which it still can be
+	repo.BeforeUpdate = func(order *model.Order) { order.Status = model.OrderStatusConfirmed }
+	cancelled, cancelErr := ordersService.Transition(ctx, order.ID, model.OrderStatusCancelled)
+	// the order is cancelled by another request while it is being confirmed, which it then can not be
+	order, _ = ordersService.Create(ctx, model.OrderRequest{CustomerReference: "C", Quantity: 1}, "test")
+	repo.BeforeUpdate = func(order *model.Order) { order.Status = model.OrderStatusCancelled }
+	_, confirmErr := ordersService.Transition(ctx, order.ID, model.OrderStatusConfirmed)
+
+	// then
+	assert.Nil(t, cancelErr)
+	assert.Equal(t, model.OrderStatusCancelled, cancelled.Status)
+	assert.Equal(
+		t, &model.IllegalOrderTransition{ID: order.ID, From: "cancelled", To: "confirmed"}, confirmErr,
+	)
+}
+
+func TestOrder_Errors(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		method   string
+		url      string
+		body     string
+		sizes    []int
+		expected int
+	}{
+		{"missing customer reference", "POST", "/api/orders", `{"quantity": 1}`, []int{250}, http.StatusBadRequest},
+		{
+			"zero quantity", "POST", "/api/orders", `{"customerReference": "C", "quantity": 0}`, []int{250},
+			http.StatusBadRequest,
+		},
+		{
+			"no packs", "POST", "/api/orders", `{"customerReference": "C", "quantity": 1}`, nil,
+			http.StatusBadRequest,
+		},
+		{"unknown order", "GET", "/api/orders/unknown", "", []int{250}, http.StatusNotFound},
+		{
+			"transition of an unknown order", "POST", "/api/orders/unknown/transitions", `{"status": "confirmed"}`,
+			[]int{250}, http.StatusNotFound,
+		},
+		{
+			"unknown status", "POST", "/api/orders/unknown/transitions", `{"status": "lost"}`, []int{250},
+			http.StatusBadRequest,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				router := testRouter(ordersAppContext(&stub.OrdersRepositoryStub{}, scenario.sizes))
+
+				// when
+				response := executeRequest(router, scenario.method, scenario.url, scenario.body)
+
+				// then
+				assert.Equal(t, scenario.expected, response.Code)
+			},
+		)
+	}
+}
+
+func TestOrder_Authorization(t *testing.T) {
+	// given
+	repo := &stub.OrdersRepositoryStub{}
+	appContext := ordersAppContext(repo, []int{250})
+	appContext.Authenticator = testAPIKeyAuthenticator(t)
+	router := testRouter(appContext)
+	body := `{"customerReference": "C", "quantity": 1}`
+
+	// when
+	readerCreated := executeRequest(router, "POST", "/api/orders", body, "X-API-Key", "reader-key")
+	created := executeRequest(router, "POST", "/api/orders", body, "X-API-Key", "admin-key")
+	order := orderOf(t, created)
+	found := executeRequest(router, "GET", "/api/orders/"+order.ID, "", "X-API-Key", "reader-key")
+	transitionURL := "/api/orders/" + order.ID + "/transitions"
+	readerTransitioned := executeRequest(
+		router, "POST", transitionURL, `{"status": "confirmed"}`, "X-API-Key", "reader-key",
+	)
+	transitioned := executeRequest(
+		router, "POST", transitionURL, `{"status": "confirmed"}`, "X-API-Key", "admin-key",
+	)
+
+	// then
+	assert.Equal(t, http.StatusForbidden, readerCreated.Code)
+	assert.Equal(t, http.StatusCreated, created.Code)
+	assert.Equal(t, http.StatusOK, found.Code)
+	assert.Equal(t, http.StatusForbidden, readerTransitioned.Code)
+	assert.Equal(t, http.StatusOK, transitioned.Code)
+	assert.Equal(t, model.OrderStatusConfirmed, orderOf(t, transitioned).Status)
+}
+
+func TestOrder_StalePacks(t *testing.T) {
+	// given
+	var saved []model.PackagingResult
+	packsService := stub.PacksServiceStub{Sizes: []int{250}, Stale: true}
+	packagingService := service.NewPackagingService(packsService)
+	ordersService := service.NewOrdersService(
+		&stub.OrdersRepositoryStub{}, packagingService,
+		service.NewPackagingHistoryService(stub.PackagingResultsRepositoryStub{Saved: &saved}),
+	)
+	router := testRouter(
+		&appcontext.AppContext{
+			AuthDisabled:   true,
+			PacksService:   packsService,
+			PackingService: packagingService,
+			OrdersService:  ordersService,
+		},
+	)
+
+	// when
+	response := executeRequest(router, "POST", "/api/orders", `{"customerReference": "C", "quantity": 1}`)
+
+	// then
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.JSONEq(t, `{"error": "packs storage is unavailable"}`, response.Body.String())
+	assert.Empty(t, saved)
+}
+
+func TestOrder_WithoutDatabase(t *testing.T) {
+	// given
+	router := testRouter(&appcontext.AppContext{PacksService: stub.PacksServiceStub{}, AuthDisabled: true})
+
+	// when
+	response := executeRequest(router, "POST", "/api/orders", `{"customerReference": "C", "quantity": 1}`)
+
+	// then
+	assert.Equal(t, http.StatusNotImplemented, response.Code)
+	assert.JSONEq(t, `{"error": "orders are not available"}`, response.Body.String())
+}
+
+func TestOrdersService_RecordsPackaging(t *testing.T) {
+	// given
+	var saved []model.PackagingResult
+	ordersService := service.NewOrdersService(
+		&stub.OrdersRepositoryStub{}, service.NewPackagingService(stub.PacksServiceStub{Sizes: []int{250}}),
+		service.NewPackagingHistoryService(stub.PackagingResultsRepositoryStub{Saved: &saved}),
+	)
+
+	// when
+	_, err := ordersService.Create(
+		context.Background(), model.OrderRequest{CustomerReference: "CUST-42", Quantity: 251}, "caller",
+	)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(saved))
+	assert.Equal(t, 251, saved[0].NumberOfItems)
+	assert.Equal(t, map[int]int{250: 2}, saved[0].Packs)
+	assert.Equal(t, "caller", saved[0].Caller)
+}
+
+func testOrdersService(repo *stub.OrdersRepositoryStub) service.OrdersService {
+	return service.NewOrdersService(
+		repo, service.NewPackagingService(stub.PacksServiceStub{Sizes: []int{250}}), nil,
+	)
+}
+
+func ordersAppContext(repo *stub.OrdersRepositoryStub, sizes []int) *appcontext.AppContext {
+	packsService := stub.PacksServiceStub{Sizes: sizes}
+	packagingService := service.NewPackagingService(packsService)
+	return &appcontext.AppContext{
+		AuthDisabled:   true,
+		PacksService:   packsService,
+		PackingService: packagingService,
+		OrdersService:  service.NewOrdersService(repo, packagingService, nil),
+	}
+}
+
+func executeTransitionRequest(router *gin.Engine, id string, status string) *httptest.ResponseRecorder {
+	return executeRequest(router, "POST", "/api/orders/"+id+"/transitions", `{"status": "`+status+`"}`)
+}
+
+func orderOf(t *testing.T, response *httptest.ResponseRecorder) model.OrderResponse {
+	var order model.OrderResponse
+	if err := json.Unmarshal(response.Body.Bytes(), &order); err != nil {
+		t.Fatalf("response is not an order: %s", response.Body.String())
+	}
+
+	return order
+}
+
+func lastStatusOf(statuses []string) string {
+	if len(statuses) == 0 {
+		return model.OrderStatusQuoted
+	}
+
+	return statuses[len(statuses)-1]
+}
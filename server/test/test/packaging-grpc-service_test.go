@@ -0,0 +1,259 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"server/internal/appcontext"
+	"server/internal/grpcapi"
+	"server/internal/grpcapi/pb"
+	"server/internal/model"
+	"server/internal/repository"
+	"server/internal/service"
+	"server/test/stub"
+	"testing"
+)
+
+func TestGrpcPackItems(t *testing.T) {
+	// given
+	packsService := stub.PacksServiceStub{Sizes: []int{100, 200, 1000}}
+	conn := startGrpcServer(
+		t, &appcontext.AppContext{PacksService: packsService, PackingService: service.NewPackagingService(packsService)},
+	)
+	client := pb.NewPackagingServiceClient(conn)
+
+	// when
+	response, err := client.PackItems(context.Background(), &pb.ProductsPackageRequest{NumberOfItems: 1001})
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1001), response.GetNumberOfItems())
+	assert.Equal(t, 2, len(response.GetLines()))
+	assert.Equal(t, int64(1000), response.GetLines()[0].GetPackSize())
+	assert.Equal(t, int64(1), response.GetLines()[0].GetQuantity())
+	assert.Equal(t, int64(100), response.GetLines()[1].GetPackSize())
+	assert.Equal(t, int64(2), response.GetTotalPacks())
+	assert.Equal(t, int64(1100), response.GetTotalItems())
+	assert.Equal(t, int64(99), response.GetOverage())
+}
+
+func TestGrpcPackItems_InvalidRequest(t *testing.T) {
+	// given
+	packsService := stub.PacksServiceStub{Sizes: []int{100}}
+	conn := startGrpcServer(t, &appcontext.AppContext{PackingService: service.NewPackagingService(packsService)})
+	client := pb.NewPackagingServiceClient(conn)
+
+	// when
+	_, err := client.PackItems(context.Background(), &pb.ProductsPackageRequest{NumberOfItems: 0})
+
+	// then
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGrpcPackItems_EmptyPacksConfig(t *testing.T) {
+	// given
+	packsService := stub.PacksServiceStub{Sizes: []int{}}
+	conn := startGrpcServer(t, &appcontext.AppContext{PackingService: service.NewPackagingService(packsService)})
+	client := pb.NewPackagingServiceClient(conn)
+
+	// when
+	_, err := client.PackItems(context.Background(), &pb.ProductsPackageRequest{NumberOfItems: 1})
+
+	// then
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Equal(t, "no packs configured", status.Convert(err).Message())
+}
+
+func TestGrpcListPacks(t *testing.T) {
+	// given
+	conn := startGrpcServer(t, &appcontext.AppContext{PacksService: stub.PacksServiceStub{Sizes: []int{100, 200}}})
+	client := pb.NewPackagingServiceClient(conn)
+
+	// when
+	response, err := client.ListPacks(context.Background(), &pb.ListPacksRequest{})
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{100, 200}, response.GetPacks())
+	assert.Equal(t, service.PacksConfigVersion([]int{100, 200}), response.GetVersion())
+}
+
+func TestGrpcSyncPacks(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		err      error
+		expected codes.Code
+	}{
+		{name: "successful", err: nil, expected: codes.OK},
+		{name: "invalid packs", err: &model.InvalidPacksConfig{Reason: "reason"}, expected: codes.InvalidArgument},
+		{name: "storage unavailable", err: &model.PacksStorageUnavailable{}, expected: codes.Unavailable},
+		{name: "other error", err: errors.New("error"), expected: codes.Internal},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				conn := startGrpcServer(
+					t, &appcontext.AppContext{
+						AuthDisabled: true,
+						PacksService: service.NewPacksService(stub.PacksRepositoryStub{Error: scenario.err}),
+					},
+				)
+				client := pb.NewPackagingServiceClient(conn)
+
+				// when
+				_, err := client.SyncPacks(context.Background(), &pb.PacksSyncRequest{Packs: []int64{100, 200}})
+
+				// then
+				assert.Equal(t, scenario.expected, status.Code(err))
+			},
+		)
+	}
+}
+
+func TestGrpcSyncPacks_InvalidSizes(t *testing.T) {
+	scenarios := []struct {
+		name  string
+		packs []int64
+	}{
+		{"zero", []int64{0}},
+		{"negative", []int64{100, -200}},
+		{"duplicate", []int64{100, 100}},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				calls := 0
+				conn := startGrpcServer(
+					t, &appcontext.AppContext{
+						AuthDisabled: true,
+						PacksService: service.NewPacksService(stub.PacksRepositoryStub{Calls: &calls}),
+					},
+				)
+				client := pb.NewPackagingServiceClient(conn)
+
+				// when
+				_, err := client.SyncPacks(context.Background(), &pb.PacksSyncRequest{Packs: scenario.packs})
+
+				// then
+				assert.Equal(t, codes.InvalidArgument, status.Code(err))
+				assert.Equal(t, 0, calls)
+			},
+		)
+	}
+}
+
+func TestGrpcRecovery(t *testing.T) {
+	// given
+	// without a packaging service, the call panics
+	conn := startGrpcServer(t, &appcontext.AppContext{})
+	client := pb.NewPackagingServiceClient(conn)
+
+	// when
+	_, err := client.PackItems(context.Background(), &pb.ProductsPackageRequest{NumberOfItems: 1})
+	_, healthErr := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+	// then
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Nil(t, healthErr)
+}
+
+func TestGrpcHealth(t *testing.T) {
+	// given
+	conn := startGrpcServer(t, &appcontext.AppContext{})
+	client := healthpb.NewHealthClient(conn)
+
+	// when
+	response, err := client.Check(
+		context.Background(), &healthpb.HealthCheckRequest{Service: pb.PackagingService_ServiceDesc.ServiceName},
+	)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, response.GetStatus())
+}
+
+func TestGrpcRateLimit(t *testing.T) {
+	// given
+	packsService := stub.PacksServiceStub{Sizes: []int{100, 200}}
+	conn := startGrpcServer(
+		t, &appcontext.AppContext{
+			PacksService: packsService,
+			RateLimitService: service.NewRateLimitService(
+				repository.NewInMemoryRateLimitRepository(),
+				model.RateLimitsFile{
+					Routes: map[string]model.RateLimit{
+						"GRPC " + pb.PackagingService_ListPacks_FullMethodName: {RequestsPerSecond: 0.1, Burst: 1},
+					},
+				},
+			),
+		},
+	)
+	client := pb.NewPackagingServiceClient(conn)
+
+	// when
+	_, firstErr := client.ListPacks(context.Background(), &pb.ListPacksRequest{})
+	_, secondErr := client.ListPacks(context.Background(), &pb.ListPacksRequest{})
+	_, healthErr := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+	// then
+	assert.Nil(t, firstErr)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(secondErr))
+	assert.Nil(t, healthErr)
+}
+
+func TestGrpcRateLimit_RejectedCredentialsLimitedByIP(t *testing.T) {
+	// given
+	packsService := stub.PacksServiceStub{Sizes: []int{100, 200}}
+	conn := startGrpcServer(
+		t, &appcontext.AppContext{
+			PacksService:  packsService,
+			Authenticator: testAPIKeyAuthenticator(t),
+			RateLimitService: service.NewRateLimitService(
+				repository.NewInMemoryRateLimitRepository(),
+				model.RateLimitsFile{IP: &model.RateLimit{RequestsPerSecond: 0.1, Burst: 1}},
+			),
+		},
+	)
+	client := pb.NewPackagingServiceClient(conn)
+	wrongKeyCtx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "wrong-key")
+	readerCtx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "reader-key")
+
+	// when
+	_, wrongKeyErr := client.ListPacks(wrongKeyCtx, &pb.ListPacksRequest{})
+	_, readerErr := client.ListPacks(readerCtx, &pb.ListPacksRequest{})
+
+	// then
+	assert.Equal(t, codes.Unauthenticated, status.Code(wrongKeyErr))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(readerErr))
+}
+
+func startGrpcServer(t *testing.T, appContext *appcontext.AppContext) *grpc.ClientConn {
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpcapi.NewGrpcServer(appContext)
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
@@ -0,0 +1,163 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"server/internal/appcontext"
+	"server/internal/model"
+	"server/internal/service"
+	"server/test/stub"
+	"testing"
+	"time"
+)
+
+func TestRecord_Successful(t *testing.T) {
+	// given
+	var saved []model.PackagingResult
+	historyService := service.NewPackagingHistoryService(stub.PackagingResultsRepositoryStub{Saved: &saved})
+	calculation := &model.PackagingCalculation{
+		NumberOfItems: 1001,
+		Packs:         map[int]int{1000: 1, 100: 1},
+		PackSizes:     []int{100, 200, 1000},
+		PacksVersion:  "version",
+		Objective:     service.PackagingObjective,
+	}
+
+	// when
+	err := historyService.Record(context.Background(), calculation, "caller")
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(saved))
+	assert.Equal(t, 1001, saved[0].NumberOfItems)
+	assert.Equal(t, map[int]int{1000: 1, 100: 1}, saved[0].Packs)
+	assert.Equal(t, []int{100, 200, 1000}, saved[0].PackSizes)
+	assert.Equal(t, "version", saved[0].PacksVersion)
+	assert.Equal(t, service.PackagingObjective, saved[0].Objective)
+	assert.Equal(t, "caller", saved[0].Caller)
+	assert.False(t, saved[0].CreatedAt.IsZero())
+}
+
+func TestFind_DefaultPagination(t *testing.T) {
+	// given
+	var filter model.PackagingHistoryFilter
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repository := stub.PackagingResultsRepositoryStub{
+		Results: []model.PackagingResult{
+			{ID: 1, NumberOfItems: 1, Packs: map[int]int{100: 1}, Caller: "caller", CreatedAt: createdAt},
+		},
+		Total:      21,
+		LastFilter: &filter,
+	}
+	historyService := service.NewPackagingHistoryService(repository)
+
+	// when
+	result, err := historyService.Find(context.Background(), model.PackagingHistoryRequest{})
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, 0, filter.Offset)
+	assert.Equal(t, 20, filter.Limit)
+	assert.Equal(t, 1, result.Page)
+	assert.Equal(t, 20, result.PageSize)
+	assert.Equal(t, int64(21), result.Total)
+	assert.Equal(
+		t, []model.PackagingHistoryEntry{
+			{ID: 1, NumberOfItems: 1, Packs: map[int]int{100: 1}, Caller: "caller", CreatedAt: createdAt},
+		}, result.Items,
+	)
+}
+
+func TestFind_Filters(t *testing.T) {
+	// given
+	var filter model.PackagingHistoryFilter
+	historyService := service.NewPackagingHistoryService(stub.PackagingResultsRepositoryStub{LastFilter: &filter})
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	// when
+	_, err := historyService.Find(
+		context.Background(), model.PackagingHistoryRequest{
+			From: from, To: to, MinQuantity: 10, MaxQuantity: 100, Caller: "caller", Page: 3, PageSize: 5,
+		},
+	)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(
+		t, model.PackagingHistoryFilter{
+			From: from, To: to, MinQuantity: 10, MaxQuantity: 100, Caller: "caller", Offset: 10, Limit: 5,
+		}, filter,
+	)
+}
+
+func TestFind_Error(t *testing.T) {
+	// given
+	repoError := errors.New("repo error")
+	historyService := service.NewPackagingHistoryService(stub.PackagingResultsRepositoryStub{Error: repoError})
+
+	// when
+	result, err := historyService.Find(context.Background(), model.PackagingHistoryRequest{})
+
+	// then
+	assert.Nil(t, result)
+	assert.Equal(t, repoError, err)
+}
+
+func TestFind_InvalidFilters(t *testing.T) {
+	from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	scenarios := []struct {
+		name    string
+		request model.PackagingHistoryRequest
+		reason  string
+	}{
+		{
+			"from after to", model.PackagingHistoryRequest{From: from, To: from.Add(-time.Second)},
+			"from must not be after to",
+		},
+		{
+			"min quantity greater than max quantity", model.PackagingHistoryRequest{MinQuantity: 11, MaxQuantity: 10},
+			"minQuantity must not be greater than maxQuantity",
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				var filter model.PackagingHistoryFilter
+				historyService := service.NewPackagingHistoryService(
+					stub.PackagingResultsRepositoryStub{LastFilter: &filter},
+				)
+
+				// when
+				result, err := historyService.Find(context.Background(), scenario.request)
+
+				// then
+				assert.Nil(t, result)
+				assert.Equal(t, &model.InvalidHistoryFilter{Reason: scenario.reason}, err)
+				assert.Equal(t, model.PackagingHistoryFilter{}, filter)
+			},
+		)
+	}
+}
+
+func TestPackagingHistory_InvalidFilters(t *testing.T) {
+	// given
+	router := testRouter(
+		&appcontext.AppContext{
+			HistoryService: service.NewPackagingHistoryService(stub.PackagingResultsRepositoryStub{}),
+		},
+	)
+
+	// when
+	response := executeRequest(
+		router, "GET", "/api/package/history?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", "",
+	)
+
+	// then
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{"error": "invalid history filter: from must not be after to"}`, response.Body.String())
+}
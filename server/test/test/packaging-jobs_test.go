@@ -0,0 +1,428 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/appcontext"
+	"server/internal/model"
+	"server/internal/service"
+	"server/test/stub"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testJobsCaller is the caller of the jobs test requests, the jobs being only visible to their caller.
+const testJobsCaller = "jobs-caller"
+
+func TestPackagingJob(t *testing.T) {
+	// given
+	appContext := packagingJobsAppContext(&stub.PackagingJobsRepositoryStub{}, []int{250, 500, 1000})
+	router := testRouter(appContext)
+	runJobWorkers(t, appContext)
+
+	// when
+	submitted := executeCsvUpload(router, "order_id,quantity\nA-1,1\nA-2,501\nA-3,1001\n")
+	var job model.PackagingJobResponse
+	assert.Nil(t, json.Unmarshal(submitted.Body.Bytes(), &job))
+	completed := waitForPackagingJob(t, router, job.ID)
+	results := executeJobResultsRequest(router, job.ID, "text/csv")
+
+	// then
+	assert.Equal(t, http.StatusAccepted, submitted.Code)
+	assert.Equal(t, "/api/jobs/"+job.ID, submitted.Header().Get("Location"))
+	assert.Equal(t, model.JobStatusQueued, job.Status)
+	assert.Equal(t, 3, job.TotalLines)
+
+	version := service.PacksConfigVersion([]int{250, 500, 1000})
+	assert.Equal(t, 3, completed.ProcessedLines)
+	assert.Equal(t, 0, completed.FailedLines)
+	assert.Equal(t, 1.0, completed.Progress)
+	assert.Equal(t, version, completed.PacksVersion)
+	assert.NotNil(t, completed.CompletedAt)
+
+	assert.Equal(t, http.StatusOK, results.Code)
+	assert.Equal(t, "text/csv", results.Header().Get("Content-Type"))
+	assert.Equal(
+		t,
+		"order_id,quantity,packs,total_packs,total_items,overage,packs_version,error\n"+
+			"A-1,1,250:1,1,250,249,"+version+",\n"+
+			"A-2,501,500:1;250:1,2,750,249,"+version+",\n"+
+			"A-3,1001,1000:1;250:1,2,1250,249,"+version+",\n",
+		results.Body.String(),
+	)
+}
+
+func TestPackagingJob_CsvResultsEscapeFormulas(t *testing.T) {
+	// given
+	appContext := packagingJobsAppContext(&stub.PackagingJobsRepositoryStub{}, []int{250})
+	router := testRouter(appContext)
+	runJobWorkers(t, appContext)
+
+	// when
+	submitted := executeCsvUpload(router, "order_id,quantity\n=1+1,1\n@A-2,1\n")
+	var job model.PackagingJobResponse
+	assert.Nil(t, json.Unmarshal(submitted.Body.Bytes(), &job))
+	waitForPackagingJob(t, router, job.ID)
+	results := executeJobResultsRequest(router, job.ID, "text/csv")
+
+	// then
+	assert.Equal(t, http.StatusOK, results.Code)
+	version := service.PacksConfigVersion([]int{250})
+	assert.Equal(
+		t,
+		"order_id,quantity,packs,total_packs,total_items,overage,packs_version,error\n"+
+			"'=1+1,1,250:1,1,250,249,"+version+",\n"+
+			"'@A-2,1,250:1,1,250,249,"+version+",\n",
+		results.Body.String(),
+	)
+}
+
+func TestPackagingJob_NdjsonResults(t *testing.T) {
+	// given
+	appContext := packagingJobsAppContext(&stub.PackagingJobsRepositoryStub{}, []int{250, 500})
+	router := testRouter(appContext)
+	runJobWorkers(t, appContext)
+
+	submitted := executeCsvUpload(router, "quantity,order_id,comment\n501,A-1,first\n250,A-2,second\n")
+	var job model.PackagingJobResponse
+	assert.Nil(t, json.Unmarshal(submitted.Body.Bytes(), &job))
+	waitForPackagingJob(t, router, job.ID)
+
+	// when
+	results := executeJobResultsRequest(router, job.ID, "application/x-ndjson")
+
+	// then
+	assert.Equal(t, http.StatusOK, results.Code)
+	assert.Equal(t, "application/x-ndjson", results.Header().Get("Content-Type"))
+
+	lines := strings.Split(strings.TrimSpace(results.Body.String()), "\n")
+	assert.Len(t, lines, 2)
+	var first model.PackagingJobResult
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "A-1", first.OrderID)
+	assert.Equal(t, 501, first.NumberOfItems)
+	assert.Equal(
+		t, []model.PackagingLine{{PackSize: 500, Quantity: 1, Items: 500}, {PackSize: 250, Quantity: 1, Items: 250}},
+		first.Lines,
+	)
+	assert.Equal(t, 249, first.Overage)
+}
+
+func TestPackagingJob_FailsWithoutPacks(t *testing.T) {
+	// given
+	appContext := packagingJobsAppContext(&stub.PackagingJobsRepositoryStub{}, nil)
+	router := testRouter(appContext)
+	runJobWorkers(t, appContext)
+
+	submitted := executeCsvUpload(router, "order_id,quantity\nA-1,1\n")
+	var job model.PackagingJobResponse
+	assert.Nil(t, json.Unmarshal(submitted.Body.Bytes(), &job))
+
+	// when
+	failed := waitForPackagingJob(t, router, job.ID)
+	results := executeJobResultsRequest(router, job.ID, "text/csv")
+
+	// then
+	assert.Equal(t, model.JobStatusFailed, failed.Status)
+	assert.Equal(t, "no packs configured", failed.Error)
+	assert.Equal(t, http.StatusConflict, results.Code)
+}
+
+func TestPackagingJob_ResumesReleasedJob(t *testing.T) {
+	// given
+	repo := &stub.PackagingJobsRepositoryStub{}
+	appContext := packagingJobsAppContext(repo, []int{250})
+	job, err := appContext.PackagingJobsService.Submit(
+		t.Context(), []model.PackagingJobItem{{OrderID: "A-1", NumberOfItems: 1}, {OrderID: "A-2", NumberOfItems: 2}},
+		testJobsCaller,
+	)
+	assert.Nil(t, err)
+
+	// a worker processed the first line and was stopped
+	now := time.Now()
+	_, _ = repo.Claim(t.Context(), now, now)
+	processed := model.PackagingJobLine{
+		JobID: job.ID, LineNumber: 1, OrderID: "A-1", NumberOfItems: 1, Packs: map[int]int{250: 1},
+	}
+	_ = repo.SaveLines(t.Context(), job.ID, []model.PackagingJobLine{processed}, now)
+	_ = repo.Release(t.Context(), job.ID)
+
+	// when
+	runJobWorkers(t, appContext)
+	completed := waitForPackagingJob(t, testRouter(appContext), job.ID)
+
+	// then
+	assert.Equal(t, model.JobStatusCompleted, completed.Status)
+	assert.Equal(t, 2, completed.ProcessedLines)
+}
+
+func TestPackagingJob_KeepsItsPacksConfig(t *testing.T) {
+	// given
+	repo := &stub.PackagingJobsRepositoryStub{}
+	appContext := packagingJobsAppContext(repo, []int{500})
+	job, err := appContext.PackagingJobsService.Submit(
+		t.Context(), []model.PackagingJobItem{{OrderID: "A-1", NumberOfItems: 1}}, testJobsCaller,
+	)
+	assert.Nil(t, err)
+
+	// a worker started the job with other pack sizes, then the pack sizes changed before it was resumed
+	now := time.Now()
+	_, _ = repo.Claim(t.Context(), now, now)
+	_, _ = repo.SavePacksConfig(t.Context(), job.ID, []int{250}, service.PacksConfigVersion([]int{250}))
+	_ = repo.Release(t.Context(), job.ID)
+
+	// when
+	runJobWorkers(t, appContext)
+	router := testRouter(appContext)
+	completed := waitForPackagingJob(t, router, job.ID)
+	results := executeJobResultsRequest(router, job.ID, "text/csv")
+
+	// then
+	version := service.PacksConfigVersion([]int{250})
+	assert.Equal(t, version, completed.PacksVersion)
+	assert.Equal(
+		t,
+		"order_id,quantity,packs,total_packs,total_items,overage,packs_version,error\n"+
+			"A-1,1,250:1,1,250,249,"+version+",\n",
+		results.Body.String(),
+	)
+}
+
+func TestPackagingJob_OnlyVisibleToItsCaller(t *testing.T) {
+	// given
+	appContext := packagingJobsAppContext(&stub.PackagingJobsRepositoryStub{}, []int{250})
+	router := testRouter(appContext)
+	job, _ := appContext.PackagingJobsService.Submit(
+		t.Context(), []model.PackagingJobItem{{OrderID: "A-1", NumberOfItems: 1}}, "someone-else",
+	)
+
+	// when
+	found := executeRequest(router, "GET", "/api/jobs/"+job.ID, "", "X-Caller-ID", testJobsCaller)
+	results := executeJobResultsRequest(router, job.ID, "text/csv")
+
+	// then
+	assert.Equal(t, http.StatusNotFound, found.Code)
+	assert.Equal(t, http.StatusNotFound, results.Code)
+}
+
+func TestPackagingJob_RecordsPackaging(t *testing.T) {
+	// given
+	var saved []model.PackagingResult
+	appContext := packagingJobsAppContextWithHistory(
+		&stub.PackagingJobsRepositoryStub{}, []int{250},
+		service.NewPackagingHistoryService(stub.PackagingResultsRepositoryStub{Saved: &saved}),
+	)
+	router := testRouter(appContext)
+	runJobWorkers(t, appContext)
+
+	// when
+	submitted := executeCsvUpload(router, "order_id,quantity\nA-1,1\nA-2,251\n")
+	var job model.PackagingJobResponse
+	assert.Nil(t, json.Unmarshal(submitted.Body.Bytes(), &job))
+	waitForPackagingJob(t, router, job.ID)
+
+	// then
+	assert.Equal(t, 2, len(saved))
+	assert.Equal(t, 1, saved[0].NumberOfItems)
+	assert.Equal(t, 251, saved[1].NumberOfItems)
+	assert.Equal(t, testJobsCaller, saved[1].Caller)
+}
+
+func TestPackagingJob_InvalidUpload(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		csv            string
+		expectedStatus int
+		expectedErrors []string
+	}{
+		{
+			name:           "missing columns",
+			csv:            "id,quantity\nA-1,1\n",
+			expectedStatus: http.StatusBadRequest,
+			expectedErrors: []string{"order_id and quantity columns"},
+		},
+		{
+			name:           "no lines",
+			csv:            "order_id,quantity\n",
+			expectedStatus: http.StatusBadRequest,
+			expectedErrors: []string{"no lines to pack"},
+		},
+		{
+			name:           "invalid lines",
+			csv:            "order_id,quantity\nA-1,1\n,2\nA-3,0\nA-4,many\n",
+			expectedStatus: http.StatusBadRequest,
+			expectedErrors: []string{"line 3: order_id is empty", "line 4: quantity", "line 5: quantity"},
+		},
+		{
+			name:           "too many lines",
+			csv:            "order_id,quantity\n" + strings.Repeat("A,1\n", 11),
+			expectedStatus: http.StatusBadRequest,
+			expectedErrors: []string{"11 lines, more than the max of 10"},
+		},
+		{
+			name:           "too large",
+			csv:            "order_id,quantity\n" + strings.Repeat("A-1234567890,1\n", 100),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedErrors: []string{"larger than 1024 bytes"},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				router := testRouter(packagingJobsAppContext(&stub.PackagingJobsRepositoryStub{}, []int{250}))
+
+				// when
+				response := executeCsvUpload(router, scenario.csv)
+
+				// then
+				assert.Equal(t, scenario.expectedStatus, response.Code)
+				for _, expectedError := range scenario.expectedErrors {
+					assert.Contains(t, response.Body.String(), expectedError)
+				}
+			},
+		)
+	}
+}
+
+func TestPackagingJob_NotFound(t *testing.T) {
+	// given
+	router := testRouter(packagingJobsAppContext(&stub.PackagingJobsRepositoryStub{}, []int{250}))
+
+	// when
+	job := executeJSONRequest(router, "GET", "/api/jobs/unknown", nil)
+	results := executeJobResultsRequest(router, "unknown", "text/csv")
+
+	// then
+	assert.Equal(t, http.StatusNotFound, job.Code)
+	assert.Equal(t, http.StatusNotFound, results.Code)
+}
+
+func TestPackagingJob_Authorization(t *testing.T) {
+	// given
+	appContext := packagingJobsAppContext(&stub.PackagingJobsRepositoryStub{}, []int{250})
+	appContext.Authenticator = testAPIKeyAuthenticator(t)
+	router := testRouter(appContext)
+	csv := "order_id,quantity\nA-1,1\n"
+
+	// when
+	readerSubmitted := executeRequest(
+		router, "POST", "/api/jobs", csv, "Content-Type", "text/csv", "X-API-Key", "reader-key",
+	)
+	submitted := executeRequest(router, "POST", "/api/jobs", csv, "Content-Type", "text/csv", "X-API-Key", "admin-key")
+	var job model.PackagingJobResponse
+	assert.Nil(t, json.Unmarshal(submitted.Body.Bytes(), &job))
+	found := executeRequest(router, "GET", "/api/jobs/"+job.ID, "", "X-API-Key", "admin-key")
+	foundByReader := executeRequest(router, "GET", "/api/jobs/"+job.ID, "", "X-API-Key", "reader-key")
+
+	// then
+	assert.Equal(t, http.StatusForbidden, readerSubmitted.Code)
+	assert.Equal(t, http.StatusAccepted, submitted.Code)
+	assert.Equal(t, http.StatusOK, found.Code)
+	assert.Equal(t, http.StatusNotFound, foundByReader.Code)
+}
+
+func TestPackagingJob_Results(t *testing.T) {
+	// given
+	appContext := packagingJobsAppContext(&stub.PackagingJobsRepositoryStub{}, []int{250})
+	router := testRouter(appContext)
+	job, _ := appContext.PackagingJobsService.Submit(
+		t.Context(), []model.PackagingJobItem{{OrderID: "A-1", NumberOfItems: 1}}, testJobsCaller,
+	)
+
+	// when
+	notCompleted := executeJobResultsRequest(router, job.ID, "text/csv")
+	notAcceptable := executeJobResultsRequest(router, job.ID, "application/xml")
+
+	// then
+	assert.Equal(t, http.StatusConflict, notCompleted.Code)
+	assert.Contains(t, notCompleted.Body.String(), "is queued")
+	assert.Equal(t, http.StatusNotAcceptable, notAcceptable.Code)
+}
+
+func TestPackagingJob_NotAvailableWithoutDatabase(t *testing.T) {
+	// given
+	appContext := &appcontext.AppContext{
+		AuthDisabled:      true,
+		PacksService:      stub.PacksServiceStub{Sizes: []int{250}},
+		MaxJobUploadBytes: 1024,
+	}
+	router := testRouter(appContext)
+
+	// when
+	response := executeCsvUpload(router, "order_id,quantity\nA-1,1\n")
+
+	// then
+	assert.Equal(t, http.StatusNotImplemented, response.Code)
+}
+
+func packagingJobsAppContext(repo *stub.PackagingJobsRepositoryStub, sizes []int) *appcontext.AppContext {
+	return packagingJobsAppContextWithHistory(repo, sizes, nil)
+}
+
+func packagingJobsAppContextWithHistory(
+	repo *stub.PackagingJobsRepositoryStub, sizes []int, historyService service.PackagingHistoryService,
+) *appcontext.AppContext {
+	packsService := stub.PacksServiceStub{Sizes: sizes}
+	packagingService := service.NewPackagingService(packsService)
+	return &appcontext.AppContext{
+		AuthDisabled:   true,
+		PackingService: packagingService,
+		PackagingJobsService: service.NewPackagingJobsService(
+			repo, packsService, packagingService, historyService, model.PackagingJobsConfig{
+				Workers:      2,
+				BatchSize:    2,
+				MaxLines:     10,
+				LeaseTimeout: time.Minute,
+				PollInterval: 10 * time.Millisecond,
+				Retention:    time.Hour,
+			},
+		),
+		MaxJobUploadBytes: 1024,
+	}
+}
+
+func runJobWorkers(t *testing.T, appContext *appcontext.AppContext) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		appContext.PackagingJobsService.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(
+		func() {
+			cancel()
+			<-done
+		},
+	)
+}
+
+// waitForPackagingJob polls the job until it is finished, and returns it.
+func waitForPackagingJob(t *testing.T, router *gin.Engine, id string) model.PackagingJobResponse {
+	var job model.PackagingJobResponse
+	assert.Eventually(
+		t, func() bool {
+			response := executeRequest(router, "GET", "/api/jobs/"+id, "", "X-Caller-ID", testJobsCaller)
+			_ = json.Unmarshal(response.Body.Bytes(), &job)
+			return job.Status == model.JobStatusCompleted || job.Status == model.JobStatusFailed
+		}, 5*time.Second, 10*time.Millisecond,
+	)
+
+	return job
+}
+
+func executeCsvUpload(router *gin.Engine, csv string) *httptest.ResponseRecorder {
+	return executeRequest(router, "POST", "/api/jobs", csv, "Content-Type", "text/csv", "X-Caller-ID", testJobsCaller)
+}
+
+func executeJobResultsRequest(router *gin.Engine, id string, accept string) *httptest.ResponseRecorder {
+	return executeRequest(
+		router, "GET", "/api/jobs/"+id+"/results", "", "Accept", accept, "X-Caller-ID", testJobsCaller,
+	)
+}
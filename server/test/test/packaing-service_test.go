@@ -1,6 +1,7 @@
 package test
 
 import (
+	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"server/internal/model"
@@ -86,7 +87,7 @@ func TestPackItems(t *testing.T) {
 				service := service2.NewPackagingService(packsServiceStub)
 
 				// when
-				result, err := service.PackItems(scenario.itemsToPack)
+				result, err := service.PackItems(context.Background(), scenario.itemsToPack)
 
 				// then
 				assert.Nil(t, err)
@@ -103,7 +104,7 @@ func TestPackItems_PacksFetchError(t *testing.T) {
 	service := service2.NewPackagingService(packsServiceStub)
 
 	// when
-	result, err := service.PackItems(1)
+	result, err := service.PackItems(context.Background(), 1)
 
 	// then
 	assert.Equal(t, serviceErr, err)
@@ -116,9 +117,55 @@ func TestPackItems_EmptyPacksList(t *testing.T) {
 	service := service2.NewPackagingService(packsServiceStub)
 
 	// when
-	result, err := service.PackItems(1)
+	result, err := service.PackItems(context.Background(), 1)
 
 	// then
 	assert.Equal(t, &model.EmptyPacksConfig{}, err)
 	assert.Nil(t, result)
 }
+
+func TestCalculate(t *testing.T) {
+	// given
+	packsServiceStub := stub.PacksServiceStub{Sizes: []int{1000, 100, 200}}
+	service := service2.NewPackagingService(packsServiceStub)
+
+	// when
+	result, err := service.Calculate(context.Background(), 1001)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, 1001, result.NumberOfItems)
+	assert.Equal(t, map[int]int{1000: 1, 100: 1}, result.Packs)
+	assert.Equal(t, []int{1000, 100, 200}, result.PackSizes)
+	assert.Equal(t, service2.PacksConfigVersion([]int{100, 200, 1000}), result.PacksVersion)
+	assert.Equal(t, service2.PackagingObjective, result.Objective)
+}
+
+func TestCalculate_StalePacksConfig(t *testing.T) {
+	// given
+	packsServiceStub := stub.PacksServiceStub{Sizes: []int{100}, Stale: true}
+	service := service2.NewPackagingService(packsServiceStub)
+
+	// when
+	result, err := service.Calculate(context.Background(), 1)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]int{100: 1}, result.Packs)
+	assert.True(t, result.Stale)
+}
+
+func TestCalculate_ContextDone(t *testing.T) {
+	// given
+	packsServiceStub := stub.PacksServiceStub{Sizes: []int{999, 1000}}
+	service := service2.NewPackagingService(packsServiceStub)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	result, err := service.Calculate(ctx, 40000)
+
+	// then
+	assert.Nil(t, result)
+	assert.Equal(t, context.Canceled, err)
+}
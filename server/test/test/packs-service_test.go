@@ -1,9 +1,13 @@
 package test
 
 import (
+	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
+	"io"
+	"path/filepath"
 	"server/internal/model"
+	"server/internal/repository"
 	"server/internal/service"
 	"server/test/stub"
 	"testing"
@@ -17,7 +21,7 @@ func TestGetAll_Successful(t *testing.T) {
 	packsService := service.NewPacksService(repository)
 
 	// when
-	result, err := packsService.GetPacks()
+	result, err := packsService.GetPacks(context.Background())
 
 	// then
 	assert.Nil(t, err)
@@ -31,7 +35,7 @@ func TestGetAll_Error(t *testing.T) {
 	packsService := service.NewPacksService(repository)
 
 	// when
-	result, err := packsService.GetPacks()
+	result, err := packsService.GetPacks(context.Background())
 
 	// then
 	assert.Nil(t, result)
@@ -45,12 +49,25 @@ func TestSyncPacks_Successful(t *testing.T) {
 	sizes := []int{1, 2}
 
 	// when
-	err := packsService.SyncPacks(sizes)
+	err := packsService.SyncPacks(context.Background(), sizes)
 
 	// then
 	assert.Nil(t, err)
 }
 
+func TestSyncPacks_InvalidSizes(t *testing.T) {
+	// given
+	calls := 0
+	packsService := service.NewPacksService(stub.PacksRepositoryStub{Calls: &calls})
+
+	// when
+	err := packsService.SyncPacks(context.Background(), []int{250, 0})
+
+	// then
+	assert.Equal(t, &model.InvalidPacksConfig{Reason: "pack size must be positive, got 0"}, err)
+	assert.Equal(t, 0, calls)
+}
+
 func TestSyncPacks_Error(t *testing.T) {
 	// given
 	repoError := errors.New("repo error")
@@ -58,8 +75,122 @@ func TestSyncPacks_Error(t *testing.T) {
 	packsService := service.NewPacksService(repository)
 
 	// when
-	err := packsService.SyncPacks([]int{1, 2, 3})
+	err := packsService.SyncPacks(context.Background(), []int{1, 2, 3})
 
 	// then
 	assert.Equal(t, repoError, err)
 }
+
+func TestGetPacksConfig_Successful(t *testing.T) {
+	// given
+	repository := stub.PacksRepositoryStub{Packs: []model.Pack{{Size: 1}, {Size: 2}}}
+	packsService := service.NewPacksService(repository)
+
+	// when
+	result, err := packsService.GetPacksConfig(context.Background())
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, result.Sizes)
+	assert.Equal(t, service.PacksConfigVersion([]int{2, 1}), result.Version)
+	assert.NotEqual(t, service.PacksConfigVersion([]int{1, 3}), result.Version)
+}
+
+func TestGetPacksConfig_StorageUnavailable_UsesLastKnown(t *testing.T) {
+	// given
+	repository := &stub.PacksRepositoryStub{Packs: []model.Pack{{Size: 1}, {Size: 2}}}
+	packsService := service.NewPacksService(repository)
+	_, _ = packsService.GetPacksConfig(context.Background())
+
+	// when
+	repository.Error = &model.PacksStorageUnavailable{}
+	result, err := packsService.GetPacksConfig(context.Background())
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, result.Sizes)
+	assert.True(t, result.Stale)
+}
+
+func TestGetPacksConfig_StorageUnavailable_UsesSnapshot(t *testing.T) {
+	// given
+	snapshot := repository.NewFilePacksSnapshotStore(filepath.Join(t.TempDir(), "snapshot.json"))
+	repo := &stub.PacksRepositoryStub{Packs: []model.Pack{{Size: 1}, {Size: 2}}}
+	_, _ = service.NewPacksServiceWithSnapshot(repo, snapshot).GetPacksConfig(context.Background())
+
+	// when
+	repo.Error = io.ErrUnexpectedEOF
+	result, err := service.NewPacksServiceWithSnapshot(repo, snapshot).GetPacksConfig(context.Background())
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, result.Sizes)
+	assert.Equal(t, service.PacksConfigVersion([]int{1, 2}), result.Version)
+	assert.True(t, result.Stale)
+}
+
+func TestGetPacksConfig_StorageUnavailable_NoLastKnown(t *testing.T) {
+	// given
+	repository := stub.PacksRepositoryStub{Error: io.ErrUnexpectedEOF}
+	packsService := service.NewPacksService(repository)
+
+	// when
+	result, err := packsService.GetPacksConfig(context.Background())
+
+	// then
+	assert.Nil(t, result)
+	assert.Equal(t, &model.PacksStorageUnavailable{Cause: io.ErrUnexpectedEOF}, err)
+}
+
+func TestGetPacksConfig_OtherError_DoesNotUseLastKnown(t *testing.T) {
+	// given
+	repository := &stub.PacksRepositoryStub{Packs: []model.Pack{{Size: 1}}}
+	packsService := service.NewPacksService(repository)
+	_, _ = packsService.GetPacksConfig(context.Background())
+
+	// when
+	repoError := errors.New("repo error")
+	repository.Error = repoError
+	result, err := packsService.GetPacksConfig(context.Background())
+
+	// then
+	assert.Nil(t, result)
+	assert.Equal(t, repoError, err)
+}
+
+func TestSyncPacks_StorageUnavailable(t *testing.T) {
+	// given
+	repository := stub.PacksRepositoryStub{Error: io.ErrUnexpectedEOF}
+	packsService := service.NewPacksService(repository)
+
+	// when
+	err := packsService.SyncPacks(context.Background(), []int{1, 2, 3})
+
+	// then
+	assert.Equal(t, &model.PacksStorageUnavailable{Cause: io.ErrUnexpectedEOF}, err)
+}
+
+func TestPacksService_CacheStats(t *testing.T) {
+	// given
+	repository := &stub.PacksRepositoryStub{Error: io.ErrUnexpectedEOF}
+	packsService := service.NewPacksService(repository)
+	_, _ = packsService.GetPacksConfig(context.Background())
+	repository.Error = nil
+	repository.Packs = []model.Pack{{Size: 1}, {Size: 2}}
+	_, _ = packsService.GetPacksConfig(context.Background())
+	_, _ = packsService.GetPacksConfig(context.Background())
+
+	// when
+	repository.Error = io.ErrUnexpectedEOF
+	_, _ = packsService.GetPacksConfig(context.Background())
+	stats := packsService.CacheStats()
+
+	// then
+	assert.True(t, stats.Cached)
+	assert.Equal(t, []int{1, 2}, stats.Sizes)
+	assert.Equal(t, service.PacksConfigVersion([]int{1, 2}), stats.Version)
+	assert.False(t, stats.StoredAt.IsZero())
+	assert.Equal(t, int64(1), stats.Updates)
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+}
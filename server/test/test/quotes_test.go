@@ -0,0 +1,319 @@
+package test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/appcontext"
+	"server/internal/model"
+	"server/internal/service"
+	"server/test/stub"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testQuoteSigningKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestQuote(t *testing.T) {
+	// given
+	router := quotesRouter(&stub.QuotesRepositoryStub{}, []int{250, 500, 1000}, time.Hour, nil)
+
+	// when
+	created := executeRequest(router, "POST", "/api/quotes", `{"numberOfItems": 501}`)
+	quote := quoteOf(t, created)
+	found := executeRequest(router, "GET", "/api/quotes/"+quote.ID, "")
+
+	// then
+	assert.Equal(t, http.StatusCreated, created.Code)
+	assert.Equal(t, "/api/quotes/"+quote.ID, created.Header().Get("Location"))
+	assert.Equal(t, 501, quote.NumberOfItems)
+	assert.Equal(
+		t, []model.PackagingLine{{PackSize: 500, Quantity: 1, Items: 500}, {PackSize: 250, Quantity: 1, Items: 250}},
+		quote.Lines,
+	)
+	assert.Equal(t, 2, quote.TotalPacks)
+	assert.Equal(t, 750, quote.TotalItems)
+	assert.Equal(t, 249, quote.Overage)
+	assert.Equal(t, []int{250, 500, 1000}, quote.PackSizes)
+	assert.Equal(t, service.PacksConfigVersion([]int{250, 500, 1000}), quote.PacksVersion)
+	assert.Equal(t, service.PackagingObjective, quote.Objective)
+	assert.Equal(t, time.Hour, quote.ExpiresAt.Sub(quote.CreatedAt))
+	assert.Empty(t, quote.Token)
+
+	assert.Equal(t, http.StatusOK, found.Code)
+	assert.JSONEq(t, created.Body.String(), found.Body.String())
+}
+
+func TestQuote_UnchangedByPacksUpdate(t *testing.T) {
+	// given
+	repo := &stub.QuotesRepositoryStub{}
+	quote := quoteOf(
+		t, executeRequest(
+			quotesRouter(repo, []int{250, 500}, time.Hour, testQuoteSigningKey), "POST", "/api/quotes",
+			`{"numberOfItems": 501}`,
+		),
+	)
+
+	// when
+	found := executeRequest(
+		quotesRouter(repo, []int{1000}, time.Hour, testQuoteSigningKey), "GET", "/api/quotes/"+quote.ID, "",
+	)
+
+	// then
+	assert.Equal(t, http.StatusOK, found.Code)
+	assert.Equal(t, quote, quoteOf(t, found))
+}
+
+func TestQuote_Expired(t *testing.T) {
+	// given
+	router := quotesRouter(&stub.QuotesRepositoryStub{}, []int{250}, time.Millisecond, nil)
+	quote := quoteOf(t, executeRequest(router, "POST", "/api/quotes", `{"numberOfItems": 1}`))
+	time.Sleep(5 * time.Millisecond)
+
+	// when
+	response := executeRequest(router, "GET", "/api/quotes/"+quote.ID, "")
+
+	// then
+	assert.Equal(t, http.StatusGone, response.Code)
+	assert.JSONEq(
+		t, `{"error": "quote `+quote.ID+` expired at `+quote.ExpiresAt.Format(time.RFC3339)+`"}`,
+		response.Body.String(),
+	)
+}
+
+func TestQuote_Errors(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		method   string
+		url      string
+		body     string
+		sizes    []int
+		expected int
+	}{
+		{"missing number of items", "POST", "/api/quotes", `{}`, []int{250}, http.StatusBadRequest},
+		{"zero items", "POST", "/api/quotes", `{"numberOfItems": 0}`, []int{250}, http.StatusBadRequest},
+		{"no packs", "POST", "/api/quotes", `{"numberOfItems": 1}`, nil, http.StatusBadRequest},
+		{"unknown quote", "GET", "/api/quotes/unknown", "", []int{250}, http.StatusNotFound},
+		{"tokens disabled", "POST", "/api/quotes/verify", `{"token": "a.b"}`, []int{250}, http.StatusNotImplemented},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				router := quotesRouter(&stub.QuotesRepositoryStub{}, scenario.sizes, time.Hour, nil)
+
+				// when
+				response := executeRequest(router, scenario.method, scenario.url, scenario.body)
+
+				// then
+				assert.Equal(t, scenario.expected, response.Code)
+			},
+		)
+	}
+}
+
+func TestQuote_WithoutDatabase(t *testing.T) {
+	// given
+	router := testRouter(&appcontext.AppContext{PacksService: stub.PacksServiceStub{}, AuthDisabled: true})
+
+	// when
+	response := executeRequest(router, "POST", "/api/quotes", `{"numberOfItems": 1}`)
+
+	// then
+	assert.Equal(t, http.StatusNotImplemented, response.Code)
+	assert.JSONEq(t, `{"error": "quotes are not available"}`, response.Body.String())
+}
+
+func TestQuote_Authorization(t *testing.T) {
+	// given
+	appContext := quotesAppContext(&stub.QuotesRepositoryStub{}, []int{250}, time.Hour, nil)
+	appContext.Authenticator = testAPIKeyAuthenticator(t)
+	router := testRouter(appContext)
+	body := `{"numberOfItems": 1}`
+
+	// when
+	readerCreated := executeRequest(router, "POST", "/api/quotes", body, "X-API-Key", "reader-key")
+	created := executeRequest(router, "POST", "/api/quotes", body, "X-API-Key", "admin-key")
+	found := executeRequest(router, "GET", "/api/quotes/"+quoteOf(t, created).ID, "", "X-API-Key", "reader-key")
+
+	// then
+	assert.Equal(t, http.StatusForbidden, readerCreated.Code)
+	assert.Equal(t, http.StatusCreated, created.Code)
+	assert.Equal(t, http.StatusOK, found.Code)
+}
+
+func TestQuote_VerifyToken(t *testing.T) {
+	// given
+	router := quotesRouter(&stub.QuotesRepositoryStub{}, []int{250, 500}, time.Hour, testQuoteSigningKey)
+	quote := quoteOf(t, executeRequest(router, "POST", "/api/quotes", `{"numberOfItems": 501}`))
+
+	// when
+	response := executeRequest(router, "POST", "/api/quotes/verify", `{"token": "`+quote.Token+`"}`)
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+	var claims model.QuoteClaims
+	assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &claims))
+	assert.Equal(
+		t, model.QuoteClaims{
+			ID:            quote.ID,
+			NumberOfItems: 501,
+			Packs:         map[int]int{500: 1, 250: 1},
+			PacksVersion:  quote.PacksVersion,
+			ExpiresAt:     quote.ExpiresAt,
+		}, claims,
+	)
+}
+
+func TestQuotesService_Verify(t *testing.T) {
+	// given
+	quotesService := testQuotesService(time.Hour, testQuoteSigningKey)
+	quote, _ := quotesService.Create(context.Background(), 1, "test")
+	payload, signature, _ := strings.Cut(quote.Token, ".")
+	tamperedPayload := base64.RawURLEncoding.EncodeToString(
+		[]byte(`{"id":"` + quote.ID + `","numberOfItems":1000,"packs":{"250":4},"expiresAt":"2100-01-01T00:00:00Z"}`),
+	)
+	otherKeyQuote, _ := testQuotesService(time.Hour, []byte("fedcba9876543210fedcba9876543210")).Create(
+		context.Background(), 1, "test",
+	)
+	expiredQuote, _ := testQuotesService(time.Millisecond, testQuoteSigningKey).Create(context.Background(), 1, "test")
+	time.Sleep(5 * time.Millisecond)
+
+	scenarios := []struct {
+		name     string
+		token    string
+		expected error
+	}{
+		{"valid", quote.Token, nil},
+		{"malformed", payload, &model.InvalidQuoteToken{Reason: "malformed token"}},
+		{
+			"tampered payload", tamperedPayload + "." + signature,
+			&model.InvalidQuoteToken{Reason: "signature does not match"},
+		},
+		{"signed with another key", otherKeyQuote.Token, &model.InvalidQuoteToken{Reason: "signature does not match"}},
+		{"expired", expiredQuote.Token, &model.QuoteExpired{ID: expiredQuote.ID, ExpiresAt: expiredQuote.ExpiresAt}},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// when
+				claims, err := quotesService.Verify(scenario.token)
+
+				// then
+				if scenario.expected != nil {
+					assert.Equal(t, scenario.expected, err)
+					assert.Nil(t, claims)
+				} else {
+					assert.Nil(t, err)
+					assert.Equal(t, quote.ID, claims.ID)
+				}
+			},
+		)
+	}
+}
+
+func TestQuotesService_PurgeExpired(t *testing.T) {
+	// given
+	repo := &stub.QuotesRepositoryStub{}
+	expired := &model.Quote{ID: "expired", ExpiresAt: time.Now().Add(-48 * time.Hour)}
+	recentlyExpired := &model.Quote{ID: "recently-expired", ExpiresAt: time.Now().Add(-time.Hour)}
+	_ = repo.Create(context.Background(), expired)
+	_ = repo.Create(context.Background(), recentlyExpired)
+	quotesService := service.NewQuotesService(repo, nil, nil, time.Hour, nil)
+
+	// when
+	purged, err := quotesService.PurgeExpired(context.Background())
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), purged)
+	_, err = quotesService.Find(context.Background(), "expired")
+	assert.Equal(t, &model.QuoteNotFound{ID: "expired"}, err)
+	_, err = quotesService.Find(context.Background(), "recently-expired")
+	assert.Equal(t, &model.QuoteExpired{ID: "recently-expired", ExpiresAt: recentlyExpired.ExpiresAt}, err)
+}
+
+func TestQuote_StalePacks(t *testing.T) {
+	// given
+	var saved []model.PackagingResult
+	packsService := stub.PacksServiceStub{Sizes: []int{250}, Stale: true}
+	packagingService := service.NewPackagingService(packsService)
+	quotesService := service.NewQuotesService(
+		&stub.QuotesRepositoryStub{}, packagingService,
+		service.NewPackagingHistoryService(stub.PackagingResultsRepositoryStub{Saved: &saved}), time.Hour, nil,
+	)
+	router := testRouter(
+		&appcontext.AppContext{
+			AuthDisabled:   true,
+			PacksService:   packsService,
+			PackingService: packagingService,
+			QuotesService:  quotesService,
+		},
+	)
+
+	// when
+	response := executeRequest(router, "POST", "/api/quotes", `{"numberOfItems": 1}`)
+
+	// then
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.JSONEq(t, `{"error": "packs storage is unavailable"}`, response.Body.String())
+	assert.Empty(t, saved)
+}
+
+func TestQuotesService_RecordsPackaging(t *testing.T) {
+	// given
+	var saved []model.PackagingResult
+	quotesService := service.NewQuotesService(
+		&stub.QuotesRepositoryStub{}, service.NewPackagingService(stub.PacksServiceStub{Sizes: []int{250}}),
+		service.NewPackagingHistoryService(stub.PackagingResultsRepositoryStub{Saved: &saved}), time.Hour, nil,
+	)
+
+	// when
+	_, err := quotesService.Create(context.Background(), 251, "caller")
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(saved))
+	assert.Equal(t, 251, saved[0].NumberOfItems)
+	assert.Equal(t, map[int]int{250: 2}, saved[0].Packs)
+	assert.Equal(t, "caller", saved[0].Caller)
+}
+
+func testQuotesService(ttl time.Duration, signingKey []byte) service.QuotesService {
+	return service.NewQuotesService(
+		&stub.QuotesRepositoryStub{}, service.NewPackagingService(stub.PacksServiceStub{Sizes: []int{250}}), nil, ttl,
+		signingKey,
+	)
+}
+
+func quotesRouter(
+	repo *stub.QuotesRepositoryStub, sizes []int, ttl time.Duration, signingKey []byte,
+) *gin.Engine {
+	return testRouter(quotesAppContext(repo, sizes, ttl, signingKey))
+}
+
+func quotesAppContext(
+	repo *stub.QuotesRepositoryStub, sizes []int, ttl time.Duration, signingKey []byte,
+) *appcontext.AppContext {
+	packsService := stub.PacksServiceStub{Sizes: sizes}
+	packagingService := service.NewPackagingService(packsService)
+	return &appcontext.AppContext{
+		AuthDisabled:   true,
+		PacksService:   packsService,
+		PackingService: packagingService,
+		QuotesService:  service.NewQuotesService(repo, packagingService, nil, ttl, signingKey),
+	}
+}
+
+func quoteOf(t *testing.T, response *httptest.ResponseRecorder) model.QuoteResponse {
+	var quote model.QuoteResponse
+	assert.Nil(t, json.Unmarshal(response.Body.Bytes(), &quote))
+	return quote
+}
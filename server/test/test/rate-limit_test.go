@@ -0,0 +1,306 @@
+package test
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/appcontext"
+	"server/internal/model"
+	"server/internal/repository"
+	"server/internal/service"
+	"server/test/stub"
+	"testing"
+	"time"
+)
+
+func TestRateLimitRepository_TokenBucket(t *testing.T) {
+	// given
+	repo := repository.NewInMemoryRateLimitRepository()
+	limit := model.RateLimit{RequestsPerSecond: 1, Burst: 2}
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	// when
+	first, _ := repo.Take(ctx, "client", limit, now)
+	second, _ := repo.Take(ctx, "client", limit, now)
+	third, _ := repo.Take(ctx, "client", limit, now)
+	afterRefill, _ := repo.Take(ctx, "client", limit, now.Add(time.Second))
+
+	// then
+	assert.Equal(t, model.RateLimitDecision{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}, first)
+	assert.Equal(t, model.RateLimitDecision{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}, second)
+	assert.Equal(
+		t, model.RateLimitDecision{Limit: 2, Remaining: 0, Reset: 2 * time.Second, RetryAfter: time.Second}, third,
+	)
+	assert.True(t, afterRefill.Allowed)
+}
+
+func TestRateLimitRepository_SeparateClients(t *testing.T) {
+	// given
+	repo := repository.NewInMemoryRateLimitRepository()
+	limit := model.RateLimit{RequestsPerSecond: 1, Burst: 1}
+	now := time.Now()
+	ctx := context.Background()
+	_, _ = repo.Take(ctx, "first", limit, now)
+
+	// when
+	decision, err := repo.Take(ctx, "second", limit, now)
+
+	// then
+	assert.Nil(t, err)
+	assert.True(t, decision.Allowed)
+}
+
+func TestRateLimitRepository_DailyQuota(t *testing.T) {
+	// given
+	repo := repository.NewInMemoryRateLimitRepository()
+	limit := model.RateLimit{RequestsPerSecond: 100, Burst: 100, DailyQuota: 2}
+	now := time.Date(2026, 1, 10, 23, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	// when
+	first, _ := repo.Take(ctx, "client", limit, now)
+	second, _ := repo.Take(ctx, "client", limit, now)
+	third, _ := repo.Take(ctx, "client", limit, now)
+	nextDay, _ := repo.Take(ctx, "client", limit, now.Add(time.Hour))
+
+	// then
+	assert.True(t, first.Allowed)
+	assert.Equal(t, model.RateLimitDecision{Allowed: true, Limit: 2, Remaining: 0, Reset: time.Hour}, second)
+	assert.Equal(
+		t,
+		model.RateLimitDecision{QuotaExceeded: true, Limit: 2, Remaining: 0, Reset: time.Hour, RetryAfter: time.Hour},
+		third,
+	)
+	assert.True(t, nextDay.Allowed)
+	assert.Equal(t, 1, nextDay.Remaining)
+}
+
+func TestRateLimitRepository_DeleteIdle(t *testing.T) {
+	// given
+	repo := repository.NewInMemoryRateLimitRepository()
+	limit := model.RateLimit{RequestsPerSecond: 1, Burst: 1}
+	now := time.Now()
+	ctx := context.Background()
+	_, _ = repo.Take(ctx, "idle", limit, now.Add(-2*time.Hour))
+	_, _ = repo.Take(ctx, "active", limit, now)
+
+	// when
+	deleted, err := repo.DeleteIdle(ctx, now.Add(-time.Hour))
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), deleted)
+	decision, _ := repo.Take(ctx, "active", limit, now)
+	assert.False(t, decision.Allowed)
+}
+
+func TestRateLimitService_LimitOf(t *testing.T) {
+	// given
+	defaultLimit := model.RateLimit{RequestsPerSecond: 10, Burst: 20}
+	packageLimit := model.RateLimit{RequestsPerSecond: 1, Burst: 5}
+	rateLimitService := service.NewRateLimitService(
+		repository.NewInMemoryRateLimitRepository(),
+		model.RateLimitsFile{Default: &defaultLimit, Routes: map[string]model.RateLimit{"POST /api/package": packageLimit}},
+	)
+
+	// then
+	assert.Equal(t, &packageLimit, rateLimitService.LimitOf("POST /api/package"))
+	assert.Equal(t, &defaultLimit, rateLimitService.LimitOf("GET /api/packs"))
+}
+
+func TestRateLimitService_NotLimited(t *testing.T) {
+	// given
+	rateLimitService := service.NewRateLimitService(
+		repository.NewInMemoryRateLimitRepository(),
+		model.RateLimitsFile{Routes: map[string]model.RateLimit{"POST /api/package": {RequestsPerSecond: 1, Burst: 1}}},
+	)
+
+	// when
+	decision, err := rateLimitService.Take(context.Background(), "GET /api/packs", "client")
+
+	// then
+	assert.Nil(t, err)
+	assert.Nil(t, decision)
+}
+
+func TestValidateRateLimits(t *testing.T) {
+	// given
+	limits := model.RateLimitsFile{
+		Default: &model.RateLimit{RequestsPerSecond: 0, Burst: 1},
+		Routes:  map[string]model.RateLimit{"/api/package": {RequestsPerSecond: 1, Burst: 0, DailyQuota: -1}},
+		IP:      &model.RateLimit{RequestsPerSecond: 1, Burst: 0},
+	}
+
+	// when
+	err := service.ValidateRateLimits(limits)
+
+	// then
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "default: requestsPerSecond must be positive")
+	assert.Contains(t, err.Error(), `route "/api/package" must be a method and a path`)
+	assert.Contains(t, err.Error(), "/api/package: burst must be at least 1")
+	assert.Contains(t, err.Error(), "/api/package: dailyQuota must not be negative")
+	assert.Contains(t, err.Error(), "ip: burst must be at least 1")
+}
+
+func TestRateLimit(t *testing.T) {
+	// given
+	router := testRouter(rateLimitedAppContext(model.RateLimit{RequestsPerSecond: 0.1, Burst: 2, DailyQuota: 100}))
+
+	// when
+	first := executeRateLimitedRequest(router, "1.1.1.1", "")
+	second := executeRateLimitedRequest(router, "1.1.1.1", "")
+	third := executeRateLimitedRequest(router, "1.1.1.1", "")
+	otherClient := executeRateLimitedRequest(router, "2.2.2.2", "")
+
+	// then
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "10", first.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=20, 100;w=86400", first.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, http.StatusTooManyRequests, third.Code)
+	assert.Equal(t, "0", third.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "10", third.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error": "rate limit exceeded"}`, third.Body.String())
+	assert.Equal(t, http.StatusOK, otherClient.Code)
+}
+
+func TestRateLimit_KeyedByPrincipal(t *testing.T) {
+	// given
+	appContext := rateLimitedAppContext(model.RateLimit{RequestsPerSecond: 0.1, Burst: 1})
+	appContext.Authenticator = testAPIKeyAuthenticator(t)
+	router := testRouter(appContext)
+
+	// when
+	first := executeRateLimitedRequest(router, "1.1.1.1", "reader-key")
+	sameKey := executeRateLimitedRequest(router, "2.2.2.2", "reader-key")
+	otherKey := executeRateLimitedRequest(router, "1.1.1.1", "admin-key")
+
+	// then
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusTooManyRequests, sameKey.Code)
+	assert.Equal(t, http.StatusOK, otherKey.Code)
+}
+
+func TestRateLimit_UnauthenticatedAPIKeysKeyedByIP(t *testing.T) {
+	// given
+	router := testRouter(rateLimitedAppContext(model.RateLimit{RequestsPerSecond: 0.1, Burst: 1}))
+
+	// when
+	first := executeRateLimitedRequest(router, "1.1.1.1", "first-key")
+	otherKey := executeRateLimitedRequest(router, "1.1.1.1", "second-key")
+
+	// then
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusTooManyRequests, otherKey.Code)
+}
+
+func TestRateLimit_RejectedCredentialsLimitedByIP(t *testing.T) {
+	// given
+	appContext := rateLimitedAppContext(model.RateLimit{RequestsPerSecond: 10, Burst: 10})
+	appContext.RateLimitService = service.NewRateLimitService(
+		repository.NewInMemoryRateLimitRepository(),
+		model.RateLimitsFile{IP: &model.RateLimit{RequestsPerSecond: 0.1, Burst: 2}},
+	)
+	appContext.Authenticator = testAPIKeyAuthenticator(t)
+	router := testRouter(appContext)
+
+	// when
+	first := executeRateLimitedRequest(router, "1.1.1.1", "wrong-key")
+	second := executeRateLimitedRequest(router, "1.1.1.1", "other-wrong-key")
+	third := executeRateLimitedRequest(router, "1.1.1.1", "reader-key")
+	otherIP := executeRateLimitedRequest(router, "2.2.2.2", "reader-key")
+
+	// then
+	assert.Equal(t, http.StatusUnauthorized, first.Code)
+	assert.Equal(t, http.StatusUnauthorized, second.Code)
+	assert.Equal(t, http.StatusTooManyRequests, third.Code)
+	assert.Equal(t, "2;w=20", third.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, http.StatusOK, otherIP.Code)
+}
+
+func TestRateLimit_ForwardedFor(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		trustedProxies []string
+		expected       int
+	}{
+		{"from an untrusted proxy", nil, http.StatusTooManyRequests},
+		{"from a trusted proxy", []string{"10.0.0.0/8"}, http.StatusOK},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				appContext := rateLimitedAppContext(model.RateLimit{RequestsPerSecond: 0.1, Burst: 1})
+				appContext.HttpServerConfig.TrustedProxies = scenario.trustedProxies
+				router := testRouter(appContext)
+
+				// when
+				executeRateLimitedRequest(router, "10.0.0.1", "", "X-Forwarded-For", "1.1.1.1")
+				other := executeRateLimitedRequest(router, "10.0.0.1", "", "X-Forwarded-For", "2.2.2.2")
+
+				// then
+				assert.Equal(t, scenario.expected, other.Code)
+			},
+		)
+	}
+}
+
+func TestRateLimit_DailyQuota(t *testing.T) {
+	// given
+	router := testRouter(rateLimitedAppContext(model.RateLimit{RequestsPerSecond: 100, Burst: 100, DailyQuota: 1}))
+
+	// when
+	first := executeRateLimitedRequest(router, "1.1.1.1", "")
+	second := executeRateLimitedRequest(router, "1.1.1.1", "")
+
+	// then
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusTooManyRequests, second.Code)
+	assert.JSONEq(t, `{"error": "daily quota exceeded"}`, second.Body.String())
+	assert.NotEmpty(t, second.Header().Get("Retry-After"))
+}
+
+func TestRateLimit_OtherRoutesNotLimited(t *testing.T) {
+	// given
+	router := testRouter(rateLimitedAppContext(model.RateLimit{RequestsPerSecond: 0.1, Burst: 1}))
+	executeRateLimitedRequest(router, "1.1.1.1", "")
+
+	// when
+	response := executeJSONRequest(router, "GET", "/api/packs", nil)
+
+	// then
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Empty(t, response.Header().Get("RateLimit-Limit"))
+}
+
+func rateLimitedAppContext(packageLimit model.RateLimit) *appcontext.AppContext {
+	packsService := stub.PacksServiceStub{Sizes: []int{250, 500}}
+	return &appcontext.AppContext{
+		PacksService:   packsService,
+		PackingService: service.NewPackagingService(packsService),
+		RateLimitService: service.NewRateLimitService(
+			repository.NewInMemoryRateLimitRepository(),
+			model.RateLimitsFile{Routes: map[string]model.RateLimit{"POST /api/package": packageLimit}},
+		),
+	}
+}
+
+// executeRateLimitedRequest sends a packaging request from the IP, with the headers given as name and value pairs.
+func executeRateLimitedRequest(
+	router *gin.Engine, ip string, apiKey string, headers ...string,
+) *httptest.ResponseRecorder {
+	req := newTestRequest(
+		"POST", "/api/package", `{"numberOfItems": 1}`, append([]string{"X-API-Key", apiKey}, headers...)...,
+	)
+	req.RemoteAddr = ip + ":1234"
+
+	return serveTestRequest(router, req)
+}
@@ -0,0 +1,136 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"server/internal/appcontext"
+	"server/internal/service"
+	"server/test/stub"
+	"testing"
+	"time"
+)
+
+func TestRequestTimeout_Exceeded(t *testing.T) {
+	// given
+	router := testRouter(packagingAppContext(0, 0))
+
+	// when
+	response := executeRequest(
+		router, "POST", "/api/package", `{"numberOfItems": 40000}`, "X-Request-Timeout", "1ns",
+	)
+
+	// then
+	assert.Equal(t, http.StatusGatewayTimeout, response.Code)
+	assert.JSONEq(t, `{"error": "request timed out"}`, response.Body.String())
+}
+
+func TestRequestTimeout_DefaultTimeoutExceeded(t *testing.T) {
+	// given
+	router := testRouter(packagingAppContext(time.Nanosecond, 0))
+
+	// when
+	response := executeRequest(
+		router, "POST", "/api/package", `{"numberOfItems": 40000}`, "X-Request-Timeout", "",
+	)
+
+	// then
+	assert.Equal(t, http.StatusGatewayTimeout, response.Code)
+}
+
+func TestRequestTimeout_CappedToMaxTimeout(t *testing.T) {
+	scenarios := []struct {
+		name   string
+		header string
+	}{
+		{name: "duration", header: "1h"},
+		{name: "seconds", header: "3600"},
+		{name: "seconds too large for a duration", header: "1e300"},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				router := testRouter(packagingAppContext(0, time.Nanosecond))
+
+				// when
+				response := executeRequest(
+					router, "POST", "/api/package", `{"numberOfItems": 40000}`, "X-Request-Timeout", scenario.header,
+				)
+
+				// then
+				assert.Equal(t, http.StatusGatewayTimeout, response.Code)
+			},
+		)
+	}
+}
+
+func TestRequestTimeout_NotExceeded(t *testing.T) {
+	scenarios := []struct {
+		name   string
+		header string
+	}{
+		{name: "duration", header: "10s"},
+		{name: "seconds", header: "10"},
+		{name: "no header", header: ""},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				router := testRouter(packagingAppContext(0, time.Minute))
+
+				// when
+				response := executeRequest(
+					router, "POST", "/api/package", `{"numberOfItems": 1001}`, "X-Request-Timeout", scenario.header,
+				)
+
+				// then
+				assert.Equal(t, http.StatusOK, response.Code)
+			},
+		)
+	}
+}
+
+func TestRequestTimeout_InvalidHeader(t *testing.T) {
+	scenarios := []struct {
+		name   string
+		header string
+	}{
+		{name: "not a duration", header: "soon"},
+		{name: "negative", header: "-1s"},
+		{name: "zero", header: "0"},
+		{name: "not a number", header: "NaN"},
+		{name: "infinite", header: "Inf"},
+		{name: "too large", header: "1e300"},
+		{name: "too small", header: "-1e300"},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// given
+				router := testRouter(packagingAppContext(0, 0))
+
+				// when
+				response := executeRequest(
+					router, "POST", "/api/package", `{"numberOfItems": 1001}`, "X-Request-Timeout", scenario.header,
+				)
+
+				// then
+				assert.Equal(t, http.StatusBadRequest, response.Code)
+			},
+		)
+	}
+}
+
+func packagingAppContext(requestTimeout time.Duration, maxRequestTimeout time.Duration) *appcontext.AppContext {
+	packsService := stub.PacksServiceStub{Sizes: []int{999, 1000}}
+	return &appcontext.AppContext{
+		PacksService:      packsService,
+		PackingService:    service.NewPackagingService(packsService),
+		RequestTimeout:    requestTimeout,
+		MaxRequestTimeout: maxRequestTimeout,
+	}
+}
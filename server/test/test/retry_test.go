@@ -0,0 +1,102 @@
+package test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"server/internal/repository"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsTransientError(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "nil", err: nil, expected: false},
+		{name: "generic error", err: errors.New("error"), expected: false},
+		{name: "serialization failure", err: &pgconn.PgError{Code: "40001"}, expected: true},
+		{name: "deadlock", err: &pgconn.PgError{Code: "40P01"}, expected: true},
+		{name: "connection failure", err: &pgconn.PgError{Code: "08006"}, expected: true},
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}, expected: false},
+		{name: "wrapped serialization failure", err: fmt.Errorf("tx: %w", &pgconn.PgError{Code: "40001"}), expected: true},
+		{name: "bad connection", err: driver.ErrBadConn, expected: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, expected: true},
+		{name: "connection reset", err: syscall.ECONNRESET, expected: true},
+		{name: "context canceled", err: context.Canceled, expected: false},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				assert.Equal(t, scenario.expected, repository.IsTransientError(scenario.err))
+			},
+		)
+	}
+}
+
+func TestRetryPolicy_RetriesTransientErrors(t *testing.T) {
+	// given
+	policy := repository.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	calls := 0
+
+	// when
+	err := policy.Do(
+		context.Background(), func() error {
+			calls++
+			if calls < 3 {
+				return &pgconn.PgError{Code: "40001"}
+			}
+			return nil
+		},
+	)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetryPolicy_StopsAfterMaxAttempts(t *testing.T) {
+	// given
+	policy := repository.RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}
+	transientErr := &pgconn.PgError{Code: "40001"}
+	calls := 0
+
+	// when
+	err := policy.Do(
+		context.Background(), func() error {
+			calls++
+			return transientErr
+		},
+	)
+
+	// then
+	assert.Equal(t, transientErr, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestRetryPolicy_DoesNotRetryPermanentErrors(t *testing.T) {
+	// given
+	policy := repository.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	permanentErr := errors.New("error")
+	calls := 0
+
+	// when
+	err := policy.Do(
+		context.Background(), func() error {
+			calls++
+			return permanentErr
+		},
+	)
+
+	// then
+	assert.Equal(t, permanentErr, err)
+	assert.Equal(t, 1, calls)
+}
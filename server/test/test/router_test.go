@@ -0,0 +1,56 @@
+package test
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/appcontext"
+	"server/internal/controller"
+	"strings"
+)
+
+// testRouter creates the API router of the app context, in test mode so that the responses are validated
+// against the OpenAPI spec.
+func testRouter(appContext *appcontext.AppContext) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	return controller.SetupRouter(appContext)
+}
+
+// newTestRequest creates a request with the body, sent as JSON unless a Content-Type header is given,
+// and the headers given as name and value pairs. The headers with an empty value are not set.
+func newTestRequest(method string, url string, body string, headers ...string) *http.Request {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		if headers[i+1] != "" {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+	}
+
+	return req
+}
+
+func serveTestRequest(router http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w
+}
+
+// executeRequest sends a request to the router, see newTestRequest.
+func executeRequest(
+	router http.Handler, method string, url string, body string, headers ...string,
+) *httptest.ResponseRecorder {
+	return serveTestRequest(router, newTestRequest(method, url, body, headers...))
+}
+
+func executeJSONRequest(
+	router http.Handler, method string, url string, body map[string]interface{},
+) *httptest.ResponseRecorder {
+	jsonBody, _ := json.Marshal(body)
+
+	return executeRequest(router, method, url, string(jsonBody))
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"net/http"
+	"server/internal/controller"
+	"server/internal/model"
+	"testing"
+	"time"
+)
+
+func TestNewHttpServer(t *testing.T) {
+	// given
+	config := model.HttpServerConfig{
+		Address:           ":8081",
+		ReadTimeout:       time.Second,
+		ReadHeaderTimeout: 2 * time.Second,
+		WriteTimeout:      3 * time.Second,
+		IdleTimeout:       4 * time.Second,
+		MaxHeaderBytes:    1024,
+	}
+
+	// when
+	server := controller.NewHttpServer(config, nil, http.NotFoundHandler())
+
+	// then
+	assert.Equal(t, ":8081", server.Addr)
+	assert.Equal(t, time.Second, server.ReadTimeout)
+	assert.Equal(t, 2*time.Second, server.ReadHeaderTimeout)
+	assert.Equal(t, 3*time.Second, server.WriteTimeout)
+	assert.Equal(t, 4*time.Second, server.IdleTimeout)
+	assert.Equal(t, 1024, server.MaxHeaderBytes)
+}
+
+func TestShutdownHttpServer_DrainsInFlightRequests(t *testing.T) {
+	// given
+	started := make(chan struct{})
+	server, url := startHttpServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte("done"))
+	})
+	responses := make(chan string, 1)
+	go func() {
+		responses <- get(url)
+	}()
+	<-started
+
+	// when
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := controller.ShutdownHttpServer(ctx, server)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, "done", <-responses)
+	assert.Equal(t, "", get(url))
+}
+
+func TestShutdownHttpServer_ClosesRequestsOverDeadline(t *testing.T) {
+	// given
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	server, url := startHttpServer(t, func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		_, _ = w.Write([]byte("done"))
+	})
+	responses := make(chan string, 1)
+	go func() {
+		responses <- get(url)
+	}()
+	<-started
+
+	// when
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := controller.ShutdownHttpServer(ctx, server)
+
+	// then
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, "", <-responses)
+}
+
+func startHttpServer(t *testing.T, handler http.HandlerFunc) (*http.Server, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	server := controller.NewHttpServer(model.HttpServerConfig{}, nil, handler)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	return server, "http://" + listener.Addr().String()
+}
+
+// get returns the response body, or an empty string when the request fails.
+func get(url string) string {
+	response, err := http.Get(url)
+	if err != nil {
+		return ""
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return ""
+	}
+
+	return string(body)
+}
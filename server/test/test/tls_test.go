@@ -0,0 +1,379 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"server/internal/auth"
+	"server/internal/controller"
+	"server/internal/model"
+	"server/internal/tlsconfig"
+	"testing"
+	"time"
+)
+
+func TestClientCertificateAuthenticator(t *testing.T) {
+	// given
+	authenticator, err := auth.NewClientCertificateAuthenticator(
+		[]auth.ClientCertificate{
+			{Subject: "CN=billing,O=Acme", Roles: []string{auth.RoleAdmin}},
+			{CommonName: "reporting", Roles: []string{auth.RoleReader}},
+		},
+	)
+	assert.Nil(t, err)
+
+	scenarios := []struct {
+		name        string
+		subject     pkix.Name
+		expected    *auth.Principal
+		expectedErr bool
+	}{
+		{
+			name:     "by subject",
+			subject:  pkix.Name{CommonName: "billing", Organization: []string{"Acme"}},
+			expected: &auth.Principal{Name: "billing", Roles: []string{auth.RoleAdmin}},
+		},
+		{
+			name:     "by common name",
+			subject:  pkix.Name{CommonName: "reporting", Organization: []string{"Other"}},
+			expected: &auth.Principal{Name: "reporting", Roles: []string{auth.RoleReader}},
+		},
+		{
+			name:        "unknown subject",
+			subject:     pkix.Name{CommonName: "billing", Organization: []string{"Other"}},
+			expectedErr: true,
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// when
+				principal, err := authenticator.Authenticate(
+					t.Context(), auth.Credentials{ClientCertificate: &x509.Certificate{Subject: scenario.subject}},
+				)
+
+				// then
+				assert.Equal(t, scenario.expected, principal)
+				if scenario.expectedErr {
+					var invalidCredentialsError *model.InvalidCredentials
+					assert.ErrorAs(t, err, &invalidCredentialsError)
+				} else {
+					assert.Nil(t, err)
+				}
+			},
+		)
+	}
+}
+
+func TestClientCertificateAuthenticator_WithoutCertificate(t *testing.T) {
+	// given
+	authenticator, _ := auth.NewClientCertificateAuthenticator(
+		[]auth.ClientCertificate{{CommonName: "billing", Roles: []string{auth.RoleAdmin}}},
+	)
+
+	// when
+	principal, err := authenticator.Authenticate(t.Context(), auth.Credentials{APIKey: "key"})
+
+	// then
+	assert.Nil(t, principal)
+	assert.Nil(t, err)
+}
+
+func TestClientCertificateAuthenticator_InvalidConfig(t *testing.T) {
+	scenarios := map[string][]auth.ClientCertificate{
+		"no subject":    {{Roles: []string{auth.RoleAdmin}}},
+		"both":          {{Subject: "CN=billing", CommonName: "billing"}},
+		"defined twice": {{CommonName: "billing"}, {CommonName: "billing"}},
+	}
+
+	for name, certificates := range scenarios {
+		t.Run(
+			name, func(t *testing.T) {
+				// when
+				_, err := auth.NewClientCertificateAuthenticator(certificates)
+
+				// then
+				assert.NotNil(t, err)
+			},
+		)
+	}
+}
+
+func TestValidateTlsConfig(t *testing.T) {
+	scenarios := []struct {
+		name     string
+		config   model.TlsConfig
+		problems []string
+	}{
+		{
+			name:   "TLS",
+			config: model.TlsConfig{CertFile: "cert.pem", KeyFile: "key.pem"},
+		},
+		{
+			name:   "mutual TLS by default with client CAs",
+			config: model.TlsConfig{CertFile: "cert.pem", KeyFile: "key.pem", ClientCAFile: "ca.pem"},
+		},
+		{
+			name: "optional mutual TLS",
+			config: model.TlsConfig{
+				CertFile: "cert.pem", KeyFile: "key.pem", ClientCAFile: "ca.pem", ClientAuth: model.ClientAuthOptional,
+			},
+		},
+		{
+			name:     "no key, mutual TLS without client CAs",
+			config:   model.TlsConfig{CertFile: "cert.pem", ClientAuth: model.ClientAuthRequire},
+			problems: []string{"certificate and the key", "needs the client CA file"},
+		},
+		{
+			name: "unused client CAs, negative reload interval",
+			config: model.TlsConfig{
+				CertFile: "cert.pem", KeyFile: "key.pem", ClientCAFile: "ca.pem", ClientAuth: model.ClientAuthNone,
+				ReloadInterval: -time.Second,
+			},
+			problems: []string{"not verified", "reload interval"},
+		},
+		{
+			name:     "unknown client auth",
+			config:   model.TlsConfig{CertFile: "cert.pem", KeyFile: "key.pem", ClientAuth: "always"},
+			problems: []string{`"always" must be one of`},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// when
+				err := tlsconfig.ValidateTlsConfig(scenario.config)
+
+				// then
+				if len(scenario.problems) == 0 {
+					assert.Nil(t, err)
+					return
+				}
+				assert.NotNil(t, err)
+				for _, problem := range scenario.problems {
+					assert.Contains(t, err.Error(), problem)
+				}
+			},
+		)
+	}
+}
+
+func TestCertificateReloader_ReloadsRotatedCertificate(t *testing.T) {
+	// given
+	ca := newTestCA(t, "ca")
+	certFile, keyFile := ca.writeCertificate(t, "server", "server")
+	reloader, err := tlsconfig.NewCertificateReloader(certFile, keyFile, "")
+	assert.Nil(t, err)
+
+	// when
+	unchanged, unchangedErr := reloader.Reload()
+	ca.writeCertificate(t, "rotated", "server")
+	touch(t, certFile, keyFile)
+	reloaded, reloadedErr := reloader.Reload()
+
+	// then
+	assert.False(t, unchanged)
+	assert.Nil(t, unchangedErr)
+	assert.True(t, reloaded)
+	assert.Nil(t, reloadedErr)
+	assert.Equal(t, "rotated", servedCommonName(t, reloader))
+}
+
+func TestCertificateReloader_KeepsCertificateOnFailedRotation(t *testing.T) {
+	// given
+	ca := newTestCA(t, "ca")
+	certFile, keyFile := ca.writeCertificate(t, "server", "server")
+	reloader, err := tlsconfig.NewCertificateReloader(certFile, keyFile, "")
+	assert.Nil(t, err)
+
+	// when
+	assert.Nil(t, os.WriteFile(keyFile, []byte("not a key"), 0600))
+	touch(t, keyFile)
+	reloaded, err := reloader.Reload()
+
+	// then
+	assert.False(t, reloaded)
+	assert.NotNil(t, err)
+	assert.Equal(t, "server", servedCommonName(t, reloader))
+}
+
+func TestMutualTls(t *testing.T) {
+	// given
+	ca := newTestCA(t, "ca")
+	otherCA := newTestCA(t, "other-ca")
+	certFile, keyFile := ca.writeCertificate(t, "server", "server")
+	reloader, err := tlsconfig.NewCertificateReloader(certFile, keyFile, ca.file)
+	assert.Nil(t, err)
+	tlsConfig, err := tlsconfig.NewServerConfig(
+		model.TlsConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: ca.file}, reloader,
+	)
+	assert.Nil(t, err)
+
+	authenticator, _ := auth.NewClientCertificateAuthenticator(
+		[]auth.ClientCertificate{
+			{CommonName: "billing", Roles: []string{auth.RoleAdmin}},
+			{CommonName: "reporting", Roles: []string{auth.RoleReader}},
+		},
+	)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin", controller.Authorize(authenticator, false, auth.RoleAdmin), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	url := startHttpsServer(t, tlsConfig, router) + "/admin"
+
+	scenarios := []struct {
+		name           string
+		certificate    *tls.Certificate
+		expectedStatus int
+	}{
+		{name: "admin", certificate: ca.issue(t, "billing"), expectedStatus: http.StatusOK},
+		{name: "reader", certificate: ca.issue(t, "reporting"), expectedStatus: http.StatusForbidden},
+		{name: "unknown subject", certificate: ca.issue(t, "unknown"), expectedStatus: http.StatusUnauthorized},
+		{name: "untrusted CA", certificate: otherCA.issue(t, "billing")},
+		{name: "no certificate"},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(
+			scenario.name, func(t *testing.T) {
+				// when
+				response, err := ca.client(scenario.certificate).Get(url)
+
+				// then
+				if scenario.expectedStatus == 0 {
+					assert.NotNil(t, err)
+					return
+				}
+				assert.Nil(t, err)
+				defer response.Body.Close()
+				assert.Equal(t, scenario.expectedStatus, response.StatusCode)
+			},
+		)
+	}
+}
+
+type testCA struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	file        string
+	dir         string
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, name+".pem")
+	assert.Nil(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+
+	return &testCA{certificate: certificate, key: key, file: file, dir: dir}
+}
+
+// issue issues a certificate for a server on 127.0.0.1 and for clients.
+func (ca *testCA) issue(t *testing.T, commonName string) *tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	assert.Nil(t, err)
+
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// writeCertificate issues a certificate, and writes it and its key to the name.pem and name-key.pem files.
+func (ca *testCA) writeCertificate(t *testing.T, commonName string, name string) (string, string) {
+	certificate := ca.issue(t, commonName)
+	keyDer, err := x509.MarshalPKCS8PrivateKey(certificate.PrivateKey)
+	assert.Nil(t, err)
+
+	certFile := filepath.Join(ca.dir, name+".pem")
+	keyFile := filepath.Join(ca.dir, name+"-key.pem")
+	assert.Nil(
+		t, os.WriteFile(
+			certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]}), 0600,
+		),
+	)
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}), 0600))
+
+	return certFile, keyFile
+}
+
+// client creates an HTTPS client trusting the CA, sending the certificate when not nil.
+func (ca *testCA) client(certificate *tls.Certificate) *http.Client {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.certificate)
+	tlsConfig := &tls.Config{RootCAs: roots}
+	if certificate != nil {
+		tlsConfig.Certificates = []tls.Certificate{*certificate}
+	}
+
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}, Timeout: 5 * time.Second}
+}
+
+func startHttpsServer(t *testing.T, tlsConfig *tls.Config, handler http.Handler) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	server := controller.NewHttpServer(model.HttpServerConfig{}, tlsConfig, handler)
+	go func() {
+		_ = server.ServeTLS(listener, "", "")
+	}()
+	t.Cleanup(func() { _ = server.Close() })
+
+	return "https://" + listener.Addr().String()
+}
+
+func servedCommonName(t *testing.T, reloader *tlsconfig.CertificateReloader) string {
+	certificate, err := reloader.GetCertificate(nil)
+	assert.Nil(t, err)
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	assert.Nil(t, err)
+
+	return leaf.Subject.CommonName
+}
+
+// touch moves the modification time of the files forward, so the change is seen whatever the file system precision.
+func touch(t *testing.T, files ...string) {
+	modTime := time.Now().Add(time.Minute)
+	for _, file := range files {
+		assert.Nil(t, os.Chtimes(file, modTime, modTime))
+	}
+}
@@ -0,0 +1,136 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"server/internal/appcontext"
+	"server/internal/config"
+	"server/internal/controller"
+	"server/internal/model"
+	"server/internal/service"
+	"server/internal/tracing"
+	"server/test/stub"
+	"sync"
+	"testing"
+)
+
+const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+func TestTracing_PackagingRequest(t *testing.T) {
+	// given
+	recorder := recordSpans(t)
+	gin.SetMode(gin.TestMode)
+	packsService := service.NewPacksService(stub.PacksRepositoryStub{Packs: []model.Pack{{Size: 250}, {Size: 500}}})
+	router := controller.SetupRouter(
+		&appcontext.AppContext{PacksService: packsService, PackingService: service.NewPackagingService(packsService)},
+	)
+	req, _ := http.NewRequest("POST", "/api/v2/package", bytes.NewBufferString(`{"numberOfItems": 501}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	// when
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, w.Code)
+	spans := spansOfTrace(recorder, traceID)
+	assert.Contains(t, spans, "POST /api/v2/package")
+	assert.Equal(
+		t, spans["POST /api/v2/package"].SpanContext().SpanID(), spans["PacksService.GetPacksConfig"].Parent().SpanID(),
+	)
+	assert.Contains(t, spans, "PacksService.GetPacks")
+	packItems := spans["PackagingService.PackItems"]
+	if assert.NotNil(t, packItems) {
+		assert.Equal(t, spans["POST /api/v2/package"].SpanContext().SpanID(), packItems.Parent().SpanID())
+		attributes := attributesOf(packItems)
+		assert.Equal(t, int64(501), attributes["packaging.quantity"].AsInt64())
+		assert.Equal(t, int64(2), attributes["packaging.pack_sizes"].AsInt64())
+		assert.Equal(t, int64(2), attributes["packaging.pack_count"].AsInt64())
+		assert.Equal(t, int64(1002), attributes["packaging.table_size"].AsInt64())
+		assert.False(t, attributes["packaging.bulk_shortcut"].AsBool())
+	}
+}
+
+func TestTracing_Error(t *testing.T) {
+	// given
+	recorder := recordSpans(t)
+	packsService := service.NewPacksService(stub.PacksRepositoryStub{Error: &stubError{}})
+	ctx, span := otel.Tracer("test").Start(context.Background(), "test")
+
+	// when
+	_, err := packsService.GetPacksConfig(ctx)
+	span.End()
+
+	// then
+	assert.NotNil(t, err)
+	spans := spansOfTrace(recorder, span.SpanContext().TraceID().String())
+	assert.Equal(t, codes.Error, spans["PacksService.GetPacks"].Status().Code)
+	assert.Equal(t, codes.Error, spans["PacksService.GetPacksConfig"].Status().Code)
+}
+
+func TestTracing_Disabled(t *testing.T) {
+	// given
+	tracingConfig := config.Default().Tracing
+
+	// when
+	shutdown, err := tracing.Setup(tracingConfig)
+
+	// then
+	assert.Nil(t, err)
+	assert.Nil(t, shutdown(context.Background()))
+	assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+}
+
+type stubError struct{}
+
+func (e *stubError) Error() string {
+	return "stub error"
+}
+
+var (
+	spanRecorder     = tracetest.NewSpanRecorder()
+	spanRecorderOnce sync.Once
+)
+
+// recordSpans records the spans of the test. The tracers bind to the first global tracer provider,
+// so the same one is kept for all the tests, and the spans are told apart by their trace.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	spanRecorderOnce.Do(
+		func() {
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+			_, _ = tracing.Setup(config.Default().Tracing)
+		},
+	)
+
+	return spanRecorder
+}
+
+func spansOfTrace(recorder *tracetest.SpanRecorder, traceID string) map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() == traceID {
+			spans[span.Name()] = span
+		}
+	}
+
+	return spans
+}
+
+func attributesOf(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attributes := make(map[attribute.Key]attribute.Value)
+	for _, attribute := range span.Attributes() {
+		attributes[attribute.Key] = attribute.Value
+	}
+
+	return attributes
+}